@@ -21,6 +21,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"slices"
 
 	"github.com/suse/elemental/v3/internal/cli/action"
 	"github.com/suse/elemental/v3/internal/cli/app"
@@ -31,15 +32,19 @@ func main() {
 	appName := app.Name()
 	application := app.New(
 		cmd.Usage,
-		cmd.GlobalFlags(),
+		slices.Concat(cmd.GlobalFlags(), cmd.SystemLockFlags()),
 		cmd.Setup,
 		cmd.Teardown,
 		cmd.NewInstallCommand(appName, action.Install),
 		cmd.NewUpgradeCommand(appName, action.Upgrade),
+		cmd.NewRecoverCommand(appName, action.Recover),
 		cmd.NewKernelModulesCommand(appName, action.ManageKernelModules),
 		cmd.NewUnpackImageCommand(appName, action.Unpack),
 		cmd.NewBuildInstallerCommand(appName, action.BuildInstaller),
 		cmd.NewResetCommand(appName, action.Reset),
+		cmd.NewSnapshotCommand(appName, action.PinSnapshot, action.UnpinSnapshot),
+		cmd.NewCmdlineCommand(appName, action.GetCmdline, action.SetCmdline, action.AppendCmdline, action.RemoveCmdline),
+		cmd.NewEFICommand(appName, action.ListEFIEntries, action.PruneEFIEntries),
 		cmd.NewVersionCommand(appName))
 
 	if err := application.Run(context.Background(), os.Args); err != nil {
@@ -21,6 +21,7 @@ package build
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/suse/elemental/v3/internal/config"
@@ -28,10 +29,12 @@ import (
 	imginstall "github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
 	"github.com/suse/elemental/v3/pkg/install"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
@@ -50,7 +53,12 @@ type Builder struct {
 
 func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Output) error {
 	logger := b.System.Logger()
-	runner := b.System.Runner()
+
+	format, err := diskimage.ParseFormat(d.Configuration.Installation.RAW.Format)
+	if err != nil {
+		logger.Error("Parsing disk image format failed")
+		return err
+	}
 
 	logger.Info("Configuring image components")
 	rm, err := b.ConfigManager.ConfigureComponents(ctx, d.Configuration, output)
@@ -59,8 +67,46 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 		return err
 	}
 
+	var bom *sbom.Document
+	if len(d.Image.SBOMFormats) > 0 {
+		if bom, err = sbom.New(""); err != nil {
+			logger.Error("Preparing SBOM failed")
+			return err
+		}
+		bom.Add(sbom.ManifestComponents(rm)...)
+	}
+
+	if err = b.installDisk(ctx, d, rm, output, bom); err != nil {
+		return err
+	}
+
+	image, err := diskimage.Convert(ctx, b.System, d.Image.OutputImageName, format)
+	if err != nil {
+		logger.Error("Converting RAW disk image failed")
+		return err
+	}
+
+	if bom != nil {
+		bom.Name = filepath.Base(image)
+		if err = sbom.Write(b.System, bom, image, d.Image.SBOMFormats...); err != nil {
+			logger.Error("Writing SBOM failed")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// installDisk installs the OS into the RAW disk image attached to a loop device. The OS image and
+// its packages are added to the given bill of materials, if any.
+func (b *Builder) installDisk(
+	ctx context.Context, d *image.Definition, rm *resolver.ResolvedManifest, output config.Output, bom *sbom.Document,
+) error {
+	logger := b.System.Logger()
+	runner := b.System.Runner()
+
 	logger.Info("Creating RAW disk image")
-	if err = createDisk(runner, d.Image, d.Configuration.Installation.RAW.DiskSize); err != nil {
+	if err := createDisk(runner, d.Image, d.Configuration.Installation.RAW.DiskSize); err != nil {
 		logger.Error("Creating RAW disk image failed")
 		return err
 	}
@@ -96,7 +142,11 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 		return err
 	}
 
-	boot, err := bootloader.New(dep.BootConfig.Bootloader, b.System)
+	blOpts := []bootloader.Option{bootloader.WithMenu(dep.BootConfig.Menu)}
+	if disk := dep.GetBIOSDisk(); disk != nil {
+		blOpts = append(blOpts, bootloader.WithBIOS(disk.Device))
+	}
+	boot, err := bootloader.New(dep.BootConfig.Bootloader, b.System, blOpts...)
 	if err != nil {
 		logger.Error("Parsing boot config failed")
 		return err
@@ -104,10 +154,26 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 
 	unpackOpts := unpack.WithLocal(b.Local)
 	manager := firmware.NewEfiBootManager(b.System)
-	upgrader := upgrade.New(
-		ctx, b.System, upgrade.WithBootManager(manager), upgrade.WithBootloader(boot),
+	upgradeOpts := []upgrade.Option{
+		upgrade.WithBootManager(manager), upgrade.WithBootloader(boot),
 		upgrade.WithUnpackOpts(unpackOpts),
-	)
+	}
+	if bom != nil {
+		upgradeOpts = append(upgradeOpts, upgrade.WithPostSyncFunc(func(root string) error {
+			packages, err := sbom.ReadPackages(ctx, b.System, root)
+			if err != nil {
+				return err
+			}
+			osImage := sbom.ImageComponent(sbom.OperatingSystem, dep.SourceOS.URI())
+			if digest := dep.SourceOS.GetDigest(); digest != "" {
+				osImage.Digest = digest
+			}
+			bom.Add(osImage)
+			bom.Add(packages...)
+			return nil
+		}))
+	}
+	upgrader := upgrade.New(ctx, b.System, upgradeOpts...)
 	installer := install.New(
 		ctx, b.System, install.WithUpgrader(upgrader),
 		install.WithUnpackOpts(unpackOpts),
@@ -144,6 +210,10 @@ func newDeployment(
 		deploymentOpts = append(deploymentOpts, deployment.WithConfigPartition(deployment.MiB(configSize)))
 	}
 
+	if installation.BIOS {
+		deploymentOpts = append(deploymentOpts, deployment.WithBIOSPartition())
+	}
+
 	d := deployment.New(deploymentOpts...)
 
 	d.Disks[0].Device = installationDevice
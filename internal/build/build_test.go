@@ -22,9 +22,51 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/internal/config"
+	imginstall "github.com/suse/elemental/v3/internal/image/install"
+	"github.com/suse/elemental/v3/pkg/crypto"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 )
 
 func TestBuildSuite(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Build test suite")
 }
+
+var _ = Describe("Deployment", func() {
+	var s *sys.System
+	var cleanup func()
+	var output config.Output
+	var installation *imginstall.Installation
+	BeforeEach(func() {
+		fs, cleanupFS, err := sysmock.TestFS(map[string]any{"/dev/loop0": []byte{}})
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = cleanupFS
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		output = config.Output{RootPath: "/build"}
+		installation = &imginstall.Installation{Bootloader: "grub", CryptoPolicy: crypto.DefaultPolicy}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("does not include a BIOS boot partition by default", func() {
+		d, err := newDeployment(s, "/dev/loop0", "registry.org/os:latest", installation, output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.GetBIOSDisk()).To(BeNil())
+	})
+	It("includes a BIOS boot partition if requested", func() {
+		installation.BIOS = true
+		d, err := newDeployment(s, "/dev/loop0", "registry.org/os:latest", installation, output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.GetBIOSDisk()).To(Equal(d.Disks[0]))
+		Expect(d.Disks[0].Device).To(Equal("/dev/loop0"))
+		Expect(d.Disks[0].Partitions[0].Role).To(Equal(deployment.BIOS))
+		Expect(d.Disks[0].Partitions[0].Size).To(Equal(deployment.BiosSize))
+		Expect(d.GetEfiPartition()).NotTo(BeNil())
+	})
+})
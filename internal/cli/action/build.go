@@ -33,8 +33,10 @@ import (
 	"github.com/suse/elemental/v3/internal/config"
 	v0 "github.com/suse/elemental/v3/internal/config/v0"
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -125,6 +127,14 @@ func validateArgs(fs vfs.FS, args *cmdpkg.BuildFlags) error {
 		return fmt.Errorf("malformed platform %q", args.Platform)
 	}
 
+	if _, err := diskimage.ParseFormat(args.Format); err != nil {
+		return err
+	}
+
+	if _, err := sbom.ParseFormats(args.SBOM); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -145,11 +155,21 @@ func parseImageDefinition(f vfs.FS, args *cmdpkg.BuildFlags) (*image.Definition,
 		return nil, fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
 	}
 
+	if args.Format != "" {
+		conf.Installation.RAW.Format = args.Format
+	}
+
+	sbomFormats, err := sbom.ParseFormats(args.SBOM)
+	if err != nil {
+		return nil, err
+	}
+
 	return &image.Definition{
 		Image: image.Image{
 			ImageType:       args.ImageType,
 			Platform:        p,
 			OutputImageName: outputPath,
+			SBOMFormats:     sbomFormats,
 		},
 		Configuration: conf,
 	}, nil
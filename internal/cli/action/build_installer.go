@@ -28,8 +28,11 @@ import (
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/env"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
@@ -115,10 +118,37 @@ func digestInstallerMedia(ctx context.Context, s *sys.System, flags *cmdpkg.Inst
 		return nil, err
 	}
 
-	media := installer.NewMedia(
-		ctx, s, mType,
+	opts := []installer.Option{
 		installer.WithUnpackOpts(unpack.WithLocal(flags.Local), unpack.WithVerify(flags.Verify)),
-	)
+	}
+
+	epoch, ok, err := env.LookupSourceDateEpoch()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		opts = append(opts, installer.WithSourceDateEpoch(epoch))
+	}
+
+	liveImage, err := digestLiveImage(flags)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, installer.WithLiveImage(liveImage))
+
+	sbomFormats, err := sbom.ParseFormats(flags.SBOM)
+	if err != nil {
+		return nil, err
+	}
+	if len(sbomFormats) > 0 {
+		doc, err := sbom.New("")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, installer.WithSBOM(doc, sbomFormats...))
+	}
+
+	media := installer.NewMedia(ctx, s, mType, opts...)
 
 	if flags.Name != "" {
 		media.Name = flags.Name
@@ -131,9 +161,30 @@ func digestInstallerMedia(ctx context.Context, s *sys.System, flags *cmdpkg.Inst
 	if flags.Label != "" {
 		media.Label = flags.Label
 	}
+
+	media.NetbootURL = flags.NetbootURL
 	return media, nil
 }
 
+func digestLiveImage(flags *cmdpkg.InstallerFlags) (filesystem.LiveImage, error) {
+	var err error
+	liveImage := filesystem.LiveImage{Level: flags.LiveCompressionLevel}
+
+	liveImage.Type, err = filesystem.ParseLiveImageType(flags.LiveFS)
+	if err != nil {
+		return liveImage, err
+	}
+	liveImage.Compression, err = filesystem.ParseCompression(flags.LiveCompression)
+	if err != nil {
+		return liveImage, err
+	}
+	liveImage.BlockSize, err = filesystem.ParseBlockSize(flags.LiveBlockSize)
+	if err != nil {
+		return liveImage, err
+	}
+	return liveImage, liveImage.Validate()
+}
+
 func applyInstallSpec(s *sys.System, d *deployment.Deployment, flags cmdpkg.InstallFlags) error {
 	if flags.Description != "" {
 		err := loadDescriptionFile(s, flags.Description, d)
@@ -0,0 +1,243 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// cmdlineEdit computes a new kernel command line from the current one and the given arguments
+type cmdlineEdit func(cmdline string, args ...string) string
+
+func GetCmdline(_ context.Context, cmd *cli.Command) error {
+	args := &cmdpkg.CmdlineArgs
+
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	d, editor, err := cmdlineSetup(s)
+	if err != nil {
+		return err
+	}
+	espDir := d.GetEfiPartition().MountPoint
+
+	entries, err := cmdlineEntries(editor, espDir, args)
+	if err != nil {
+		return err
+	}
+
+	if !args.All {
+		cmdline, err := editor.Cmdline(espDir, entries[0])
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(cmd.Root().Writer, cmdline)
+		return nil
+	}
+
+	for _, entry := range entries {
+		cmdline, err := editor.Cmdline(espDir, entry)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(cmd.Root().Writer, "%s: %s\n", entry, cmdline)
+	}
+	return nil
+}
+
+func SetCmdline(_ context.Context, cmd *cli.Command) error {
+	return editCmdline(cmd, func(d *deployment.Deployment) cmdlineEdit {
+		// only user defined arguments are replaced, arguments required to boot the system are kept
+		return func(cmdline string, args ...string) string {
+			return bootloader.AppendArgs(bootloader.RemoveArgs(cmdline, d.BootConfig.KernelCmdline), args...)
+		}
+	}, false)
+}
+
+func AppendCmdline(_ context.Context, cmd *cli.Command) error {
+	return editCmdline(cmd, func(*deployment.Deployment) cmdlineEdit {
+		return bootloader.AppendArgs
+	}, true)
+}
+
+func RemoveCmdline(_ context.Context, cmd *cli.Command) error {
+	return editCmdline(cmd, func(*deployment.Deployment) cmdlineEdit {
+		return bootloader.RemoveArgs
+	}, true)
+}
+
+func editCmdline(cmd *cli.Command, newEdit func(*deployment.Deployment) cmdlineEdit, argsRequired bool) error {
+	args := &cmdpkg.CmdlineArgs
+
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	if argsRequired && cmd.Args().Len() == 0 {
+		return fmt.Errorf("at least one kernel command line argument is required")
+	}
+	if args.NextBootOnly && args.All {
+		return fmt.Errorf("'--next-boot-only' and '--all' cannot be used together")
+	}
+
+	unlock, err := lockSystem(s)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	d, editor, err := cmdlineSetup(s)
+	if err != nil {
+		return err
+	}
+	espDir := d.GetEfiPartition().MountPoint
+	edit := newEdit(d)
+
+	entries, err := cmdlineEntries(editor, espDir, args)
+	if err != nil {
+		return err
+	}
+
+	if args.NextBootOnly {
+		cmdline, err := editor.Cmdline(espDir, entries[0])
+		if err != nil {
+			return err
+		}
+		err = editor.SetNextBootCmdline(espDir, entries[0], edit(cmdline, cmd.Args().Slice()...))
+		if err != nil {
+			s.Logger().Error("Failed setting kernel command line for next boot")
+			return err
+		}
+		s.Logger().Info("Boot entry '%s' updated for next boot only", entries[0])
+		return nil
+	}
+
+	for _, entry := range entries {
+		cmdline, err := editor.Cmdline(espDir, entry)
+		if err != nil {
+			return err
+		}
+		err = editor.SetCmdline(espDir, entry, edit(cmdline, cmd.Args().Slice()...))
+		if err != nil {
+			s.Logger().Error("Failed setting kernel command line of boot entry '%s'", entry)
+			return err
+		}
+		s.Logger().Info("Boot entry '%s' updated", entry)
+	}
+
+	// the deployment is only updated if the active boot entry changes, so future
+	// upgrades keep the kernel command line of the running system
+	if slices.Contains(entries, bootloader.DefaultBootID) {
+		d.BootConfig.KernelCmdline = edit(d.BootConfig.KernelCmdline, cmd.Args().Slice()...)
+		err = d.WriteDeploymentFile(s, "/")
+		if err != nil {
+			s.Logger().Error("Failed updating the deployment file")
+			return err
+		}
+	}
+	return nil
+}
+
+// cmdlineSetup parses the deployment of the running system and returns the bootloader in use
+func cmdlineSetup(s *sys.System) (*deployment.Deployment, bootloader.CmdlineEditor, error) {
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		s.Logger().Error("Failed to parse deployment")
+		return nil, nil, err
+	} else if d == nil {
+		return nil, nil, fmt.Errorf("deployment not found")
+	}
+
+	if d.BootConfig == nil {
+		return nil, nil, fmt.Errorf("no bootloader defined in deployment")
+	}
+	if d.GetEfiPartition() == nil {
+		return nil, nil, fmt.Errorf("no EFI partition defined in deployment")
+	}
+
+	bl, err := bootloader.New(d.BootConfig.Bootloader, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	editor, ok := bl.(bootloader.CmdlineEditor)
+	if !ok {
+		return nil, nil, fmt.Errorf("editing kernel command line with bootloader '%s': %w", d.BootConfig.Bootloader, errors.ErrUnsupported)
+	}
+	return d, editor, nil
+}
+
+// cmdlineEntries returns the boot entries targeted by the given flags. The active boot entry goes
+// first, it is followed by the entry of the snapshot it was installed from, which is the first
+// snapshot entry listed.
+func cmdlineEntries(editor bootloader.CmdlineEditor, espDir string, args *cmdpkg.CmdlineFlags) ([]string, error) {
+	if args.All && args.Snapshot != 0 {
+		return nil, fmt.Errorf("'--snapshot' and '--all' cannot be used together")
+	}
+
+	installed, err := editor.Entries(espDir)
+	if err != nil {
+		return nil, fmt.Errorf("listing boot entries: %w", err)
+	}
+
+	var active string
+	for _, entry := range installed {
+		if entry != bootloader.DefaultBootID && entry != bootloader.RecoveryBootID {
+			active = entry
+			break
+		}
+	}
+
+	switch {
+	case args.All:
+		return slices.DeleteFunc(installed, func(entry string) bool {
+			return entry == bootloader.RecoveryBootID
+		}), nil
+	case args.Snapshot != 0:
+		entry := strconv.Itoa(args.Snapshot)
+		if !slices.Contains(installed, entry) {
+			return nil, fmt.Errorf("no boot entry found for snapshot %d", args.Snapshot)
+		}
+		if entry == active && slices.Contains(installed, bootloader.DefaultBootID) {
+			return []string{bootloader.DefaultBootID, entry}, nil
+		}
+		return []string{entry}, nil
+	}
+
+	if !slices.Contains(installed, bootloader.DefaultBootID) {
+		return nil, fmt.Errorf("active boot entry not found")
+	}
+	entries := []string{bootloader.DefaultBootID}
+	if active != "" {
+		entries = append(entries, active)
+	}
+	return entries, nil
+}
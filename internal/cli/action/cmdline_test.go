@@ -0,0 +1,185 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const cmdlineConfig = `
+disks:
+- partitions:
+  - label: EFI
+    role: efi
+    fileSystem: vfat
+    mountPoint: /boot/efi
+  - label: SYSTEM
+    role: system
+    fileSystem: btrfs
+bootloader:
+  name: grub
+  kernelCmdline: quiet
+`
+
+var _ = Describe("Cmdline action", Label("cmdline"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var buffer *bytes.Buffer
+	var runner *sysmock.Runner
+
+	readEntry := func(entry string) string {
+		data, err := tfs.ReadFile(filepath.Join("/boot/efi/loader/entries", entry))
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		cmd.CmdlineArgs = cmd.CmdlineFlags{}
+		buffer = &bytes.Buffer{}
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/elemental/deployment.yaml":    cmdlineConfig,
+			"/boot/efi/grubenv":                 "# GRUB Environment Block\nentries=active 3 2 recovery\n",
+			"/boot/efi/loader/entries/active":   "# GRUB Environment Block\ncmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/3/snapshot quiet\n",
+			"/boot/efi/loader/entries/3":        "# GRUB Environment Block\ncmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/3/snapshot quiet\n",
+			"/boot/efi/loader/entries/2":        "# GRUB Environment Block\ncmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/2/snapshot quiet\n",
+			"/boot/efi/loader/entries/recovery": "# GRUB Environment Block\ncmdline=root=live:LABEL=RECOVERY\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs),
+			sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Writer: buffer,
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.GetCmdline(context.Background(), cliCmd)).NotTo(Succeed())
+		Expect(action.AppendCmdline(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("fails if the deployment file does not exist", func() {
+		Expect(tfs.RemoveAll("/etc/elemental")).To(Succeed())
+		Expect(action.GetCmdline(context.Background(), cliCmd)).To(MatchError("deployment not found"))
+	})
+	It("fails if '--snapshot' and '--all' are combined", func() {
+		cmd.CmdlineArgs.All = true
+		cmd.CmdlineArgs.Snapshot = 2
+		Expect(action.GetCmdline(context.Background(), cliCmd)).To(MatchError(ContainSubstring("cannot be used together")))
+	})
+	It("fails if the snapshot has no boot entry", func() {
+		cmd.CmdlineArgs.Snapshot = 5
+		Expect(action.GetCmdline(context.Background(), cliCmd)).To(MatchError("no boot entry found for snapshot 5"))
+	})
+	It("shows the kernel command line of the active entry", func() {
+		Expect(action.GetCmdline(context.Background(), cliCmd)).To(Succeed())
+		Expect(buffer.String()).To(Equal("root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/3/snapshot quiet\n"))
+	})
+	It("shows the kernel command line of all entries", func() {
+		cmd.CmdlineArgs.All = true
+		Expect(action.GetCmdline(context.Background(), cliCmd)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("2: root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/2/snapshot quiet\n"))
+		Expect(buffer.String()).NotTo(ContainSubstring("recovery:"))
+	})
+	It("appends arguments to the active entry and the deployment", func() {
+		cliCmd.Action = action.AppendCmdline
+		Expect(cliCmd.Run(context.Background(), []string{"cmdline", "console=ttyS0", "quiet"})).To(Succeed())
+
+		Expect(readEntry("active")).To(ContainSubstring("cmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/3/snapshot quiet console=ttyS0\n"))
+		Expect(readEntry("3")).To(ContainSubstring("cmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/3/snapshot quiet console=ttyS0\n"))
+		Expect(readEntry("2")).To(ContainSubstring("cmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/2/snapshot quiet\n"))
+
+		d, err := deployment.Parse(s, "/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.BootConfig.KernelCmdline).To(Equal("quiet console=ttyS0"))
+	})
+	It("replaces user arguments of the given snapshot only", func() {
+		cmd.CmdlineArgs.Snapshot = 2
+		cliCmd.Action = action.SetCmdline
+		Expect(cliCmd.Run(context.Background(), []string{"cmdline", "splash"})).To(Succeed())
+
+		Expect(readEntry("2")).To(ContainSubstring("cmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/2/snapshot splash\n"))
+		Expect(readEntry("active")).To(ContainSubstring("quiet\n"))
+
+		d, err := deployment.Parse(s, "/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.BootConfig.KernelCmdline).To(Equal("quiet"))
+	})
+	It("removes arguments from all entries except the recovery one", func() {
+		cmd.CmdlineArgs.All = true
+		cliCmd.Action = action.RemoveCmdline
+		Expect(cliCmd.Run(context.Background(), []string{"cmdline", "quiet", "root"})).To(Succeed())
+
+		Expect(readEntry("active")).To(ContainSubstring("cmdline=rootflags=subvol=@/.snapshots/3/snapshot\n"))
+		Expect(readEntry("2")).To(ContainSubstring("cmdline=rootflags=subvol=@/.snapshots/2/snapshot\n"))
+		Expect(readEntry("recovery")).To(ContainSubstring("cmdline=root=live:LABEL=RECOVERY\n"))
+	})
+	It("sets the kernel command line of the next boot only", func() {
+		cmd.CmdlineArgs.NextBootOnly = true
+		cliCmd.Action = action.AppendCmdline
+		Expect(cliCmd.Run(context.Background(), []string{"cmdline", "debug"})).To(Succeed())
+
+		Expect(readEntry("next")).To(ContainSubstring("cmdline=root=LABEL=SYSTEM rootflags=subvol=@/.snapshots/3/snapshot quiet debug\n"))
+		Expect(readEntry("active")).To(ContainSubstring("quiet\n"))
+		grubEnv, err := tfs.ReadFile("/boot/efi/grubenv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(grubEnv)).To(ContainSubstring("next_entry=next\n"))
+
+		d, err := deployment.Parse(s, "/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.BootConfig.KernelCmdline).To(Equal("quiet"))
+	})
+	It("fails to set the next boot for all entries", func() {
+		cmd.CmdlineArgs.NextBootOnly = true
+		cmd.CmdlineArgs.All = true
+		cliCmd.Action = action.AppendCmdline
+		Expect(cliCmd.Run(context.Background(), []string{"cmdline", "debug"})).To(MatchError(ContainSubstring("cannot be used together")))
+	})
+	It("fails to append without arguments", func() {
+		cliCmd.Action = action.AppendCmdline
+		Expect(cliCmd.Run(context.Background(), []string{"cmdline"})).To(MatchError(ContainSubstring("at least one")))
+	})
+})
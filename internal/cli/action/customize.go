@@ -33,9 +33,11 @@ import (
 	v0 "github.com/suse/elemental/v3/internal/config/v0"
 	"github.com/suse/elemental/v3/internal/customize"
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/extractor"
 	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -125,6 +127,8 @@ func setupCustomizeRunner(
 		System:        s,
 		ConfigManager: setupConfigManager(s, args.ConfigDir, output, args.Local),
 		FileExtractor: extr,
+		Version:       cmdpkg.Version(),
+		ConfigDir:     args.ConfigDir,
 	}, nil
 }
 
@@ -169,11 +173,24 @@ func digestCustomizeDefinition(f vfs.FS, args *cmdpkg.CustomizeFlags, imagePath
 		return nil, fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
 	}
 
+	if args.Format != "" {
+		if _, err = diskimage.ParseFormat(args.Format); err != nil {
+			return nil, err
+		}
+		conf.Installation.RAW.Format = args.Format
+	}
+
+	sbomFormats, err := sbom.ParseFormats(args.SBOM)
+	if err != nil {
+		return nil, err
+	}
+
 	return &image.Definition{
 		Image: image.Image{
 			ImageType:       args.MediaType,
 			Platform:        p,
 			OutputImageName: imagePath,
+			SBOMFormats:     sbomFormats,
 		},
 		Configuration: conf,
 	}, nil
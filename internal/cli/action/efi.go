@@ -0,0 +1,89 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func ListEFIEntries(_ context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	state, err := firmware.NewEfiBootManager(s).ReadBootState()
+	if err != nil {
+		s.Logger().Error("Failed reading EFI boot entries")
+		return err
+	}
+
+	w := cmd.Root().Writer
+	_, _ = fmt.Fprintf(w, "BootCurrent: %s\n", state.Current)
+	_, _ = fmt.Fprintf(w, "BootOrder: %s\n", strings.Join(state.Order, ","))
+	for _, v := range state.Variables {
+		active := " "
+		if v.Active {
+			active = "*"
+		}
+		_, _ = fmt.Fprintf(w, "Boot%s%s %s\t%s\n", v.Num, active, v.Label, v.Loader)
+	}
+	return nil
+}
+
+func PruneEFIEntries(_ context.Context, cmd *cli.Command) error {
+	args := &cmdpkg.EFIArgs
+
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	unlock, err := lockSystem(s)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stale, err := firmware.NewEfiBootManager(s).PruneBootEntries(args.DryRun)
+	if err != nil {
+		s.Logger().Error("Failed pruning EFI boot entries")
+		return err
+	}
+
+	if len(stale) == 0 {
+		s.Logger().Info("No stale EFI boot entries found")
+		return nil
+	}
+	for _, v := range stale {
+		if args.DryRun {
+			s.Logger().Info("Stale EFI boot entry Boot%s (%s) would be removed", v.Num, v.Label)
+			continue
+		}
+		s.Logger().Info("Stale EFI boot entry Boot%s (%s) removed", v.Num, v.Label)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const efiBootState = "BootCurrent: 0001\n" +
+	"BootOrder: 0001,0002\n" +
+	"Boot0001* elemental-shim\tHD(1,GPT,3a9a3c5e,0x800,0x200000)/\\EFI\\ELEMENTAL\\bootx64.efi\n" +
+	"Boot0002* elemental-shim\tHD(1,GPT,3a9a3c5e,0x800,0x200000)/\\EFI\\ELEMENTAL\\bootx64.efi\n"
+
+var _ = Describe("EFI action", Label("efi"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var buffer *bytes.Buffer
+	var runner *sysmock.Runner
+
+	BeforeEach(func() {
+		cmd.EFIArgs = cmd.EFIFlags{}
+		buffer = &bytes.Buffer{}
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			if command == "efibootmgr" && slices.Contains(args, "--verbose") {
+				return []byte(efiBootState), nil
+			}
+			return nil, nil
+		}
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs),
+			sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Writer: buffer,
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.ListEFIEntries(context.Background(), cliCmd)).NotTo(Succeed())
+		Expect(action.PruneEFIEntries(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("lists the EFI boot entries", func() {
+		Expect(action.ListEFIEntries(context.Background(), cliCmd)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("BootOrder: 0001,0002\n"))
+		Expect(buffer.String()).To(ContainSubstring("Boot0002* elemental-shim\t/EFI/ELEMENTAL/bootx64.efi\n"))
+	})
+	It("only reports stale EFI boot entries on dry runs", func() {
+		cmd.EFIArgs.DryRun = true
+		Expect(action.PruneEFIEntries(context.Background(), cliCmd)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Boot0002 (elemental-shim) would be removed"))
+		Expect(runner.IncludesCmds([][]string{{"efibootmgr", "--bootnum"}})).NotTo(Succeed())
+	})
+	It("prunes stale EFI boot entries", func() {
+		Expect(action.PruneEFIEntries(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"efibootmgr", "--bootnum", "0002", "--delete-bootnum"}})).To(Succeed())
+	})
+})
@@ -19,6 +19,7 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/signal"
 	"syscall"
@@ -32,6 +33,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/http"
 	"github.com/suse/elemental/v3/pkg/install"
 	"github.com/suse/elemental/v3/pkg/installer"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -49,10 +51,16 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 	}
 	s = cmd.Root().Metadata["system"].(*sys.System)
 
+	unlock, err := lockSystem(s)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	s.Logger().Info("Starting install action")
 	s.Logger().Debug("Install action called with args: %+v", args)
 
-	d, err := digestInstallSetup(s, args)
+	d, err := digestInstallSetup(ctx, s, args)
 	if err != nil {
 		s.Logger().Error("Failed to collect installation setup")
 		return err
@@ -75,6 +83,9 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 
 	err = installer.Install(d)
 	if err != nil {
+		if errors.Is(err, install.ErrPreflightChecks) {
+			s.Logger().Error("Host does not meet the installation requirements, use --skip-checks to ignore them")
+		}
 		s.Logger().Error("Installation failed")
 		return err
 	}
@@ -85,7 +96,11 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 }
 
 func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment, args *cmdpkg.InstallFlags) (*install.Installer, error) {
-	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s)
+	blOpts := []bootloader.Option{bootloader.WithMenu(d.BootConfig.Menu)}
+	if disk := d.GetBIOSDisk(); disk != nil {
+		blOpts = append(blOpts, bootloader.WithBIOS(disk.Device))
+	}
+	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s, blOpts...)
 	if err != nil {
 		s.Logger().Error("Parsing boot config failed")
 		return nil, err
@@ -108,6 +123,7 @@ func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment,
 		ctx, s, install.WithUpgrader(upgrader),
 		install.WithUnpackOpts(unpackOpts...),
 		install.WithBootloader(bootloader),
+		install.WithSkipChecks(args.SkipChecks),
 	)
 	return installer, nil
 }
@@ -152,7 +168,7 @@ func setBootloader(s *sys.System, d *deployment.Deployment, bootloaderType, cmdl
 }
 
 // digestInstallSetup produces the Deployment object required to describe the installation parameters
-func digestInstallSetup(s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.Deployment, error) {
+func digestInstallSetup(ctx context.Context, s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.Deployment, error) {
 	d := deployment.DefaultDeployment()
 
 	// Given flags always have precedence compared to in-place configuration of live media
@@ -161,12 +177,30 @@ func digestInstallSetup(s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.
 		if err != nil {
 			return nil, err
 		}
-	} else if install.IsLiveMedia(s) {
-		if ok, _ := vfs.Exists(s.FS(), installer.InstallDesc); ok {
-			err := loadDescriptionFile(s, installer.InstallDesc, d)
+	} else {
+		if install.IsLiveMedia(s) {
+			// Check the media before relying on its content, a damaged copy must not wipe any disk
+			if err := installer.VerifyMedia(s, installer.LiveMountPoint); err != nil {
+				return nil, err
+			}
+			if ok, _ := vfs.Exists(s.FS(), installer.InstallDesc); ok {
+				err := loadDescriptionFile(s, installer.InstallDesc, d)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// A description referenced in the kernel command line is applied over the one of the media
+		if ref := installer.ConfigFromCmdline(s); ref != "" {
+			remote, err := installer.LoadRemoteInstallDesc(ctx, s, ref, installer.RemoteConfigDir, http.DownloadFile)
 			if err != nil {
 				return nil, err
 			}
+			err = deployment.Merge(d, remote)
+			if err != nil {
+				return nil, fmt.Errorf("merging install description '%s': %w", ref, err)
+			}
 		}
 	}
 
@@ -225,6 +259,10 @@ func applyInstallFlags(s *sys.System, d *deployment.Deployment, flags *cmdpkg.In
 				sysPart.RWVolumes = nil
 			}
 		}
+
+		if d.Snapshotter.Name == "ab" && d.GetSystemBPartition() == nil {
+			return fmt.Errorf("'ab' snapshotter requires a 'system-b' partition defined in the deployment")
+		}
 	}
 
 	err := d.Sanitize(s)
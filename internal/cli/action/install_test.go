@@ -65,6 +65,7 @@ var _ = Describe("Install action", Label("install"), func() {
 		tfs, cleanup, err = sysmock.TestFS(map[string]string{
 			"/configDir/bad_config.yaml": badConfig,
 			"/dev/device":                "device",
+			"/proc/cmdline":              "quiet",
 		})
 		Expect(err).NotTo(HaveOccurred())
 		s, err = sys.NewSystem(
@@ -102,6 +103,17 @@ var _ = Describe("Install action", Label("install"), func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("inconsistent deployment"))
 	})
+	It("applies the install description referenced in the kernel command line", func() {
+		cmd.InstallArgs.Target = "/dev/device"
+		cmd.InstallArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		Expect(tfs.WriteFile("/proc/cmdline", []byte("elemental.install.config=file:///configDir/bad_config.yaml"), vfs.FilePerm)).To(Succeed())
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("inconsistent deployment")))
+
+		Expect(tfs.WriteFile("/proc/cmdline", []byte("elemental.install.config=file:///configDir/missing.yaml"), vfs.FilePerm)).To(Succeed())
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("failed fetching install description 'file:///configDir/missing.yaml'")))
+	})
 	It("fails if the given OS uri is not valid", func() {
 		cmd.InstallArgs.Target = "/dev/device"
 		cmd.InstallArgs.OperatingSystemImage = "https://example.com/my/image"
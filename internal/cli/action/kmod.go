@@ -40,6 +40,12 @@ func ManageKernelModules(ctx context.Context, cmd *cli.Command) error {
 	}
 	system := cmd.Root().Metadata["system"].(*sys.System)
 
+	unlock, err := lockSystem(system)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	ctxCancel, cancelFunc := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
 	defer cancelFunc()
 
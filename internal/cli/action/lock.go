@@ -0,0 +1,44 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/lock"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// lockSystem acquires the system wide lock, so no other command modifies the system
+// concurrently. The returned function releases the lock.
+func lockSystem(s *sys.System) (func(), error) {
+	var opts []lock.Option
+	if cmdpkg.LockArgs.Wait {
+		opts = append(opts, lock.WithWait(cmdpkg.LockArgs.Timeout))
+	}
+
+	l, err := lock.Acquire(s, lock.DefaultPath, opts...)
+	if err != nil {
+		s.Logger().Error("Failed to acquire the system lock, use '--wait' to wait for it to be released")
+		return nil, err
+	}
+	return func() {
+		if err := l.Release(); err != nil {
+			s.Logger().Warn("Failed releasing the system lock: %v", err)
+		}
+	}, nil
+}
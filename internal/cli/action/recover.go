@@ -0,0 +1,79 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/journal"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/transaction"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+func Recover(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	unlock, err := lockSystem(s)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		s.Logger().Error("Failed to parse deployment")
+		return err
+	} else if d == nil {
+		return fmt.Errorf("deployment not found")
+	}
+
+	opts := []upgrade.Option{upgrade.WithJournal(journal.New(s, journal.DefaultDir))}
+	if d.BootConfig != nil {
+		// the bootloader prunes the restored boot entries of systems no longer available
+		bl, err := bootloader.New(d.BootConfig.Bootloader, s)
+		if err != nil {
+			s.Logger().Error("Parsing boot config failed")
+			return err
+		}
+		opts = append(opts, upgrade.WithBootloader(bl))
+	}
+	if d.Snapshotter != nil && d.Snapshotter.Name != "" {
+		snapshotter, err := transaction.New(ctx, s, d, d.Snapshotter.Name)
+		if err != nil {
+			s.Logger().Error("Parsing snapshotter config failed")
+			return err
+		}
+		opts = append(opts, upgrade.WithSnapshotter(snapshotter))
+	}
+
+	err = upgrade.New(ctx, s, opts...).Recover(d)
+	if err != nil {
+		s.Logger().Error("Recovery of interrupted transaction failed")
+		return err
+	}
+	return nil
+}
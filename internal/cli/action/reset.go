@@ -42,6 +42,12 @@ func Reset(ctx context.Context, cmd *cli.Command) error {
 	}
 	s = cmd.Root().Metadata["system"].(*sys.System)
 
+	unlock, err := lockSystem(s)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	s.Logger().Info("Starting reset action")
 	s.Logger().Debug("Reset action called with args: %+v", args)
 
@@ -85,6 +91,11 @@ func digestResetSetup(s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.De
 		return nil, fmt.Errorf("reset command requires booting from recovery system")
 	}
 
+	// Check the recovery media before relying on its content, a damaged copy must not wipe any disk
+	if err := installer.VerifyMedia(s, installer.LiveMountPoint); err != nil {
+		return nil, err
+	}
+
 	descriptionFile := installer.InstallDesc
 	if flags.Description != "" {
 		descriptionFile = flags.Description
@@ -20,6 +20,8 @@ package action_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -104,6 +106,13 @@ var _ = Describe("Reset action", Label("reset"), func() {
 		tfs.RemoveAll(installer.SquashfsPath)
 		Expect(action.Reset(context.Background(), cliCmd)).To(MatchError(ContainSubstring("requires booting from recovery system")))
 	})
+	It("fails if the recovery media is damaged", func() {
+		sums := filepath.Join(installer.LiveMountPoint, installer.ChecksumsRelPath)
+		Expect(tfs.WriteFile(sums, []byte(fmt.Sprintf("%064d  LiveOS/squashfs.img\n", 0)), vfs.FilePerm)).To(Succeed())
+		err := action.Reset(context.Background(), cliCmd)
+		Expect(err).To(MatchError(installer.ErrCorruptedMedia))
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch for 'LiveOS/squashfs.img'")))
+	})
 	It("recovery partition not found", func() {
 		Expect(action.Reset(context.Background(), cliCmd)).To(MatchError(ContainSubstring("live mount point not found")))
 	})
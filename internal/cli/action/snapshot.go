@@ -0,0 +1,74 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+func PinSnapshot(_ context.Context, cmd *cli.Command) error {
+	return pinSnapshot(cmd, true)
+}
+
+func UnpinSnapshot(_ context.Context, cmd *cli.Command) error {
+	return pinSnapshot(cmd, false)
+}
+
+func pinSnapshot(cmd *cli.Command, pin bool) error {
+	args := &cmdpkg.SnapshotArgs
+
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	unlock, err := lockSystem(s)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("a single snapshot ID is required")
+	}
+	id, err := strconv.Atoi(cmd.Args().First())
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid snapshot ID '%s'", cmd.Args().First())
+	}
+
+	err = snapper.New(s).Pin("/", args.Volume, id, pin)
+	if err != nil {
+		s.Logger().Error("Failed to modify snapshot %d of volume '%s'", id, args.Volume)
+		return err
+	}
+
+	if pin {
+		s.Logger().Info("Snapshot %d of volume '%s' pinned", id, args.Volume)
+	} else {
+		s.Logger().Info("Snapshot %d of volume '%s' unpinned", id, args.Volume)
+	}
+	return nil
+}
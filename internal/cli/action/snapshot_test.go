@@ -0,0 +1,120 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+)
+
+const snapshotList = `{
+  "root": [
+    {"number": 2, "default": false, "active": false, "cleanup": "number", "userdata": null},
+    {"number": 3, "default": true, "active": true, "userdata": {"pinned": "yes", "pinned-cleanup": "timeline"}}
+  ]
+}`
+
+var _ = Describe("Snapshot actions", Label("snapshot"), func() {
+	var s *sys.System
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var runner *sysmock.Runner
+
+	BeforeEach(func() {
+		cmd.SnapshotArgs = cmd.SnapshotFlags{Volume: "/"}
+		buffer := &bytes.Buffer{}
+		fs, c, err := sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		cleanup = c
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			if command == "snapper" && slices.Contains(args, "list") {
+				return []byte(snapshotList), nil
+			}
+			return nil, nil
+		}
+		s, err = sys.NewSystem(
+			sys.WithFS(fs),
+			sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd = &cli.Command{
+			Writer: buffer,
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.PinSnapshot(context.Background(), cliCmd)).NotTo(Succeed())
+		Expect(action.UnpinSnapshot(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("fails if no snapshot ID is given", func() {
+		cliCmd.Action = action.PinSnapshot
+		err = cliCmd.Run(context.Background(), []string{"pin"})
+		Expect(err).To(MatchError("a single snapshot ID is required"))
+	})
+	It("fails if the snapshot ID is not a positive number", func() {
+		cliCmd.Action = action.PinSnapshot
+		err = cliCmd.Run(context.Background(), []string{"pin", "0"})
+		Expect(err).To(MatchError("invalid snapshot ID '0'"))
+		err = cliCmd.Run(context.Background(), []string{"pin", "latest"})
+		Expect(err).To(MatchError("invalid snapshot ID 'latest'"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails if the snapshot does not exist", func() {
+		cliCmd.Action = action.PinSnapshot
+		err = cliCmd.Run(context.Background(), []string{"pin", "5"})
+		Expect(err).To(MatchError("snapshot '5' not found"))
+	})
+	It("pins a snapshot", func() {
+		cliCmd.Action = action.PinSnapshot
+		Expect(cliCmd.Run(context.Background(), []string{"pin", "2"})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{
+			"snapper", "--no-dbus", "-c", "root", "modify",
+			"--userdata", "pinned=yes,pinned-cleanup=number", "--cleanup-algorithm", "", "2",
+		}})).To(Succeed())
+	})
+	It("unpins a snapshot", func() {
+		cliCmd.Action = action.UnpinSnapshot
+		Expect(cliCmd.Run(context.Background(), []string{"unpin", "3"})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{
+			"snapper", "--no-dbus", "-c", "root", "modify",
+			"--userdata", "pinned=,pinned-cleanup=", "--cleanup-algorithm", "timeline", "3",
+		}})).To(Succeed())
+	})
+})
@@ -20,6 +20,7 @@ package action
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"syscall"
 
@@ -29,7 +30,9 @@ import (
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/journal"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/transaction"
 	"github.com/suse/elemental/v3/pkg/unpack"
 	"github.com/suse/elemental/v3/pkg/upgrade"
 )
@@ -42,13 +45,39 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 	}
 	s = cmd.Root().Metadata["system"].(*sys.System)
 
-	s.Logger().Info("Starting upgrade action with args: %+v", args)
+	err := validateUpgradeFlags(args)
+	if err != nil {
+		return err
+	}
 
-	d, err := digestUpgradeSetup(s, args)
+	unlock, err := lockSystem(s)
 	if err != nil {
-		s.Logger().Error("Failed to collect upgrade setup")
 		return err
 	}
+	defer unlock()
+
+	s.Logger().Info("Starting upgrade action with args: %+v", args)
+
+	if args.At != "" {
+		return scheduleActivation(s, args.At, args.Reboot)
+	}
+
+	var d *deployment.Deployment
+	if args.Activate || args.Discard {
+		d, err = deployment.Parse(s, "/")
+		if err != nil {
+			s.Logger().Error("Failed to parse deployment")
+			return fmt.Errorf("parsing deployment: %w", err)
+		} else if d == nil {
+			return fmt.Errorf("deployment not found")
+		}
+	} else {
+		d, err = digestUpgradeSetup(s, args)
+		if err != nil {
+			s.Logger().Error("Failed to collect upgrade setup")
+			return err
+		}
+	}
 
 	s.Logger().Info("Checked configuration, running upgrade process")
 
@@ -60,19 +89,44 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s)
+	blOpts := []bootloader.Option{bootloader.WithMenu(d.BootConfig.Menu)}
+	if d.GetBIOSPartition() != nil {
+		// the core image is already installed, upgrades only refresh the BIOS grub configuration
+		blOpts = append(blOpts, bootloader.WithBIOS(""))
+	}
+	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s, blOpts...)
 	if err != nil {
 		s.Logger().Error("Parsing boot config failed")
 		return err
 	}
 
 	manager := firmware.NewEfiBootManager(s)
-	upgrader := upgrade.New(
-		ctxCancel, s, upgrade.WithBootloader(bootloader), upgrade.WithBootManager(manager),
+	opts := []upgrade.Option{
+		upgrade.WithBootloader(bootloader), upgrade.WithBootManager(manager),
 		upgrade.WithUnpackOpts(unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local)),
-	)
-
-	err = upgrader.Upgrade(d)
+		upgrade.WithJournal(journal.New(s, journal.DefaultDir)),
+		upgrade.WithReboot(upgrade.RebootMode(args.Reboot)),
+	}
+	if d.Snapshotter != nil && d.Snapshotter.Name != "" {
+		snapshotter, err := transaction.New(ctxCancel, s, d, d.Snapshotter.Name)
+		if err != nil {
+			s.Logger().Error("Parsing snapshotter config failed")
+			return err
+		}
+		opts = append(opts, upgrade.WithSnapshotter(snapshotter))
+	}
+	upgrader := upgrade.New(ctxCancel, s, opts...)
+
+	switch {
+	case args.Stage:
+		err = upgrader.Stage(d)
+	case args.Activate:
+		err = upgrader.Activate(d)
+	case args.Discard:
+		err = upgrader.Discard(d)
+	default:
+		err = upgrader.Upgrade(d)
+	}
 	if err != nil {
 		s.Logger().Error("Upgrade failed")
 		return err
@@ -83,6 +137,54 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+func validateUpgradeFlags(flags *cmdpkg.UpgradeFlags) error {
+	modes := 0
+	for _, set := range []bool{flags.Stage, flags.Activate, flags.Discard} {
+		if set {
+			modes++
+		}
+	}
+	switch {
+	case modes > 1:
+		return fmt.Errorf("only one of '--stage', '--activate' or '--discard' can be set")
+	case flags.At != "" && !flags.Activate:
+		return fmt.Errorf("'--at' requires '--activate'")
+	case (flags.Activate || flags.Discard) && flags.OperatingSystemImage != "":
+		return fmt.Errorf("'--os-image' can't be set together with '--activate' or '--discard'")
+	case !flags.Activate && !flags.Discard && flags.OperatingSystemImage == "":
+		return fmt.Errorf("'--os-image' is required")
+	case flags.Reboot != "" && (flags.Stage || flags.Discard):
+		return fmt.Errorf("'--reboot' can't be set together with '--stage' or '--discard'")
+	}
+
+	switch upgrade.RebootMode(flags.Reboot) {
+	case upgrade.NoReboot, upgrade.KexecReboot, upgrade.SoftReboot:
+	default:
+		return fmt.Errorf("invalid reboot mode '%s', valid modes are 'kexec' and 'soft'", flags.Reboot)
+	}
+	return nil
+}
+
+// scheduleActivation sets a persistent systemd timer to activate the staged upgrade at the given time
+// and to reboot into it with the given reboot mode, if any
+func scheduleActivation(s *sys.System, at, reboot string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding executable path: %w", err)
+	}
+
+	command := []string{exe, "--wait", "upgrade", "--activate"}
+	if reboot != "" {
+		command = append(command, "--reboot", reboot)
+	}
+	err = upgrade.New(context.Background(), s).ScheduleActivation(at, command...)
+	if err != nil {
+		s.Logger().Error("Failed to schedule the activation of the staged upgrade")
+		return err
+	}
+	return nil
+}
+
 func digestUpgradeSetup(s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.Deployment, error) {
 	d, err := deployment.Parse(s, "/")
 	if err != nil {
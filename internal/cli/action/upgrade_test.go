@@ -20,6 +20,7 @@ package action_test
 import (
 	"bytes"
 	"context"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -32,6 +33,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
 )
 
 var _ = Describe("Upgrade action", Label("upgrade"), func() {
@@ -94,4 +96,60 @@ var _ = Describe("Upgrade action", Label("upgrade"), func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("image source type not supported"))
 	})
+	It("fails if the OS image is missing", func() {
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("'--os-image' is required"))
+	})
+	It("fails if several upgrade modes are set", func() {
+		cmd.UpgradeArgs.Stage = true
+		cmd.UpgradeArgs.Discard = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("only one of '--stage', '--activate' or '--discard' can be set"))
+	})
+	It("fails to schedule an activation without '--activate'", func() {
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.UpgradeArgs.At = "Sat 02:00"
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("'--at' requires '--activate'"))
+	})
+	It("fails to schedule an activation if there is no staged upgrade", func() {
+		cmd.UpgradeArgs.Activate = true
+		cmd.UpgradeArgs.At = "Sat 02:00"
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("no staged upgrade found"))
+	})
+	It("schedules the activation keeping the reboot mode", func() {
+		runner := sysmock.NewRunner()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cliCmd.Metadata["system"] = s
+		Expect(vfs.MkdirAll(tfs, filepath.Dir(upgrade.StagedFile), vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile(upgrade.StagedFile, []byte("transactionID: 3\npath: /some/path\n"), vfs.FilePerm)).To(Succeed())
+
+		cmd.UpgradeArgs.Activate = true
+		cmd.UpgradeArgs.At = "Sat 02:00"
+		cmd.UpgradeArgs.Reboot = "kexec"
+		Expect(action.Upgrade(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"systemd-analyze", "calendar", "Sat 02:00"},
+			{"systemctl", "daemon-reload"},
+			{"systemctl", "enable", "--now", "elemental-upgrade-activate.timer"},
+		})).To(Succeed())
+		timer, err := tfs.ReadFile("/etc/systemd/system/elemental-upgrade-activate.timer")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(timer)).To(ContainSubstring("OnCalendar=Sat 02:00\n"))
+		Expect(string(timer)).To(ContainSubstring("Persistent=true\n"))
+		service, err := tfs.ReadFile("/etc/systemd/system/elemental-upgrade-activate.service")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(service)).To(MatchRegexp("ExecStart=.* --wait upgrade --activate --reboot kexec\n"))
+	})
+	It("fails if the reboot mode is not valid", func() {
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.UpgradeArgs.Reboot = "firmware"
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("invalid reboot mode 'firmware', valid modes are 'kexec' and 'soft'"))
+	})
 })
@@ -32,6 +32,8 @@ type BuildFlags struct {
 	BuildDir   string
 	OutputPath string
 	Local      bool
+	Format     string
+	SBOM       string
 }
 
 var BuildArgs BuildFlags
@@ -80,6 +82,17 @@ func NewBuildCommand(appName string, action func(context.Context, *cli.Command)
 				Usage:       "Load OCI images from the local container storage instead of a remote registry",
 				Destination: &BuildArgs.Local,
 			},
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "Disk image format of RAW images: 'raw', 'qcow2', 'vmdk', 'vhdx', 'vhd', 'raw.zst' or 'raw.xz'",
+				Destination: &BuildArgs.Format,
+				DefaultText: "format from the configuration directory, or 'raw'",
+			},
+			&cli.StringFlag{
+				Name:        "sbom",
+				Usage:       "Comma separated SBOM formats to write next to the output image: 'spdx' and/or 'cyclonedx'",
+				Destination: &BuildArgs.SBOM,
+			},
 		},
 	}
 }
@@ -36,6 +36,12 @@ type InstallerFlags struct {
 	Label                string
 	KernelCmdLine        string
 	Type                 string
+	NetbootURL           string
+	SBOM                 string
+	LiveFS               string
+	LiveCompression      string
+	LiveCompressionLevel int
+	LiveBlockSize        string
 }
 
 var InstallerArgs InstallerFlags
@@ -123,10 +129,41 @@ func NewBuildInstallerCommand(appName string, action func(context.Context, *cli.
 			},
 			&cli.StringFlag{
 				Name:        "type",
-				Usage:       "Type of the installer media, 'iso' or 'raw'",
+				Usage:       "Type of the installer media, 'iso', 'raw' or 'netboot'",
 				Destination: &InstallerArgs.Type,
 				Required:    true,
 			},
+			&cli.StringFlag{
+				Name:        "netboot-url",
+				Usage:       "Base URL the netboot artifacts are served from, required for 'netboot' media",
+				Destination: &InstallerArgs.NetbootURL,
+			},
+			&cli.StringFlag{
+				Name:        "live-fs",
+				Usage:       "Filesystem of the live root image, 'squashfs' or 'erofs'",
+				Value:       "squashfs",
+				Destination: &InstallerArgs.LiveFS,
+			},
+			&cli.StringFlag{
+				Name:        "live-compression",
+				Usage:       "Compression of the live root image, 'xz', 'zstd', 'lz4' or 'none'. Defaults to gzip for squashfs and lz4hc for erofs",
+				Destination: &InstallerArgs.LiveCompression,
+			},
+			&cli.IntFlag{
+				Name:        "live-compression-level",
+				Usage:       "Compression level of the live root image, the compressor default is used if unset",
+				Destination: &InstallerArgs.LiveCompressionLevel,
+			},
+			&cli.StringFlag{
+				Name:        "live-block-size",
+				Usage:       "Block size of the live root image, e.g. '128k' or '1M'. For erofs it sets the maximum physical cluster size",
+				Destination: &InstallerArgs.LiveBlockSize,
+			},
+			&cli.StringFlag{
+				Name:        "sbom",
+				Usage:       "Comma separated SBOM formats to write next to the output image: 'spdx' and/or 'cyclonedx'",
+				Destination: &InstallerArgs.SBOM,
+			},
 		},
 	}
 }
@@ -0,0 +1,92 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type CmdlineFlags struct {
+	Snapshot     int
+	All          bool
+	NextBootOnly bool
+}
+
+var CmdlineArgs CmdlineFlags
+
+func NewCmdlineCommand(appName string, get, set, appendArgs, remove func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "cmdline",
+		Usage:     "Manage the kernel command line of the installed system",
+		UsageText: fmt.Sprintf("%s cmdline COMMAND [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "get",
+				Usage:     "Show the kernel command line of boot entries",
+				UsageText: fmt.Sprintf("%s cmdline get [OPTIONS]", appName),
+				Action:    get,
+				Flags:     []cli.Flag{cmdlineSnapshotFlag(), cmdlineAllFlag()},
+			}, {
+				Name:      "set",
+				Usage:     "Replace the user defined kernel command line arguments",
+				UsageText: fmt.Sprintf("%s cmdline set [OPTIONS] ARGS...", appName),
+				Action:    set,
+				Flags:     []cli.Flag{cmdlineSnapshotFlag(), cmdlineAllFlag(), cmdlineNextBootFlag()},
+			}, {
+				Name:      "append",
+				Usage:     "Append arguments to the kernel command line",
+				UsageText: fmt.Sprintf("%s cmdline append [OPTIONS] ARGS...", appName),
+				Action:    appendArgs,
+				Flags:     []cli.Flag{cmdlineSnapshotFlag(), cmdlineAllFlag(), cmdlineNextBootFlag()},
+			}, {
+				Name:      "remove",
+				Usage:     "Remove arguments from the kernel command line",
+				UsageText: fmt.Sprintf("%s cmdline remove [OPTIONS] ARGS...", appName),
+				Action:    remove,
+				Flags:     []cli.Flag{cmdlineSnapshotFlag(), cmdlineAllFlag(), cmdlineNextBootFlag()},
+			},
+		},
+	}
+}
+
+func cmdlineSnapshotFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:        "snapshot",
+		Usage:       "Boot entry of the given snapshot ID, the active boot entry is used if unset",
+		Destination: &CmdlineArgs.Snapshot,
+	}
+}
+
+func cmdlineAllFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "all",
+		Usage:       "All boot entries except the recovery one",
+		Destination: &CmdlineArgs.All,
+	}
+}
+
+func cmdlineNextBootFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "next-boot-only",
+		Usage:       "Apply the change on next boot only",
+		Destination: &CmdlineArgs.NextBootOnly,
+	}
+}
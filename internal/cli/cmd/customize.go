@@ -34,6 +34,8 @@ type CustomizeFlags struct {
 	Platform   string
 	MediaType  string
 	Local      bool
+	Format     string
+	SBOM       string
 }
 
 var CustomizeArgs CustomizeFlags
@@ -90,6 +92,17 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 				Usage:       "Load OCI images from the local container storage instead of a remote registry",
 				Destination: &CustomizeArgs.Local,
 			},
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "Disk image format of RAW images: 'raw', 'qcow2', 'vmdk', 'vhdx', 'vhd', 'raw.zst' or 'raw.xz'",
+				Destination: &CustomizeArgs.Format,
+				DefaultText: "format from the configuration directory, or 'raw'",
+			},
+			&cli.StringFlag{
+				Name:        "sbom",
+				Usage:       "Comma separated SBOM formats to write next to the output image: 'spdx' and/or 'cyclonedx'",
+				Destination: &CustomizeArgs.SBOM,
+			},
 		},
 	}
 }
@@ -0,0 +1,59 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type EFIFlags struct {
+	DryRun bool
+}
+
+var EFIArgs EFIFlags
+
+func NewEFICommand(appName string, list, prune func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "efi",
+		Usage:     "Manage EFI boot entries",
+		UsageText: fmt.Sprintf("%s efi COMMAND [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List EFI boot entries and the boot order",
+				UsageText: fmt.Sprintf("%s efi list", appName),
+				Action:    list,
+			}, {
+				Name:      "prune",
+				Usage:     "Remove duplicated and stale EFI boot entries created by Elemental",
+				UsageText: fmt.Sprintf("%s efi prune [OPTIONS]", appName),
+				Action:    prune,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "dry-run",
+						Usage:       "Only list the boot entries to remove",
+						Destination: &EFIArgs.DryRun,
+					},
+				},
+			},
+		},
+	}
+}
@@ -37,6 +37,7 @@ type InstallFlags struct {
 	Local                bool
 	CryptoPolicy         string
 	Snapshotter          string
+	SkipChecks           bool
 }
 
 var InstallArgs InstallFlags
@@ -111,10 +112,15 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 			},
 			&cli.StringFlag{
 				Name:        "snapshotter",
-				Usage:       "Snapshotter [snapper, overwrite]",
+				Usage:       "Snapshotter [snapper, overwrite, ab]",
 				Value:       "snapper",
 				Destination: &InstallArgs.Snapshotter,
 			},
+			&cli.BoolFlag{
+				Name:        "skip-checks",
+				Usage:       "Only warn about hardware requirements not met by the host instead of failing",
+				Destination: &InstallArgs.SkipChecks,
+			},
 		},
 	}
 }
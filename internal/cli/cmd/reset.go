@@ -78,7 +78,7 @@ func NewResetCommand(appName string, action func(context.Context, *cli.Command)
 			},
 			&cli.StringFlag{
 				Name:        "snapshotter",
-				Usage:       "Snapshotter [snapper, overwrite]",
+				Usage:       "Snapshotter [snapper, overwrite, ab]",
 				Value:       "snapper",
 				Destination: &InstallArgs.Snapshotter,
 			},
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/urfave/cli/v3"
 
@@ -35,6 +36,14 @@ var (
 	logFile *os.File
 )
 
+type LockFlags struct {
+	Wait    bool
+	Timeout time.Duration
+}
+
+// LockArgs sets how the commands modifying the system acquire the system lock
+var LockArgs LockFlags
+
 func GlobalFlags() []cli.Flag {
 	return []cli.Flag{
 		&cli.BoolFlag{
@@ -48,6 +57,23 @@ func GlobalFlags() []cli.Flag {
 	}
 }
 
+// SystemLockFlags returns the flags setting how the system lock is acquired, they only apply to
+// applications including commands modifying the host system.
+func SystemLockFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "wait",
+			Usage:       "Wait for the system lock held by another command instead of failing",
+			Destination: &LockArgs.Wait,
+		},
+		&cli.DurationFlag{
+			Name:        "wait-timeout",
+			Usage:       "Maximum time to wait for the system lock, zero waits forever",
+			Destination: &LockArgs.Timeout,
+		},
+	}
+}
+
 func Setup(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 	s, err := sys.NewSystem()
 	if err != nil {
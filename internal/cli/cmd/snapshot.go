@@ -0,0 +1,63 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type SnapshotFlags struct {
+	Volume string
+}
+
+var SnapshotArgs SnapshotFlags
+
+func NewSnapshotCommand(appName string, pin, unpin func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "snapshot",
+		Usage:     "Manage system snapshots",
+		UsageText: fmt.Sprintf("%s snapshot COMMAND [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "pin",
+				Usage:     "Pin a snapshot so it is never cleaned up",
+				UsageText: fmt.Sprintf("%s snapshot pin [OPTIONS] ID", appName),
+				Action:    pin,
+				Flags:     []cli.Flag{snapshotVolumeFlag()},
+			}, {
+				Name:      "unpin",
+				Usage:     "Unpin a snapshot so it is subject to the retention policy again",
+				UsageText: fmt.Sprintf("%s snapshot unpin [OPTIONS] ID", appName),
+				Action:    unpin,
+				Flags:     []cli.Flag{snapshotVolumeFlag()},
+			},
+		},
+	}
+}
+
+func snapshotVolumeFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "volume",
+		Value:       "/",
+		Usage:       "Path of the snapshotted volume the snapshot belongs to",
+		Destination: &SnapshotArgs.Volume,
+	}
+}
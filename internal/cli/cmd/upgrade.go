@@ -31,6 +31,11 @@ type UpgradeFlags struct {
 	Verify               bool
 	CreateBootEntry      bool
 	Local                bool
+	Stage                bool
+	Activate             bool
+	Discard              bool
+	At                   string
+	Reboot               string
 }
 
 var UpgradeArgs UpgradeFlags
@@ -46,7 +51,6 @@ func NewUpgradeCommand(appName string, action func(context.Context, *cli.Command
 				Name:        "os-image",
 				Usage:       "URI to the image containing the operating system",
 				Destination: &UpgradeArgs.OperatingSystemImage,
-				Required:    true,
 			},
 			&cli.StringFlag{
 				Name:        "config",
@@ -74,6 +78,31 @@ func NewUpgradeCommand(appName string, action func(context.Context, *cli.Command
 				Usage:       "Load OCI images from the local container storage instead of a remote registry",
 				Destination: &UpgradeArgs.Local,
 			},
+			&cli.BoolFlag{
+				Name:        "stage",
+				Usage:       "Prepare the upgrade without activating it",
+				Destination: &UpgradeArgs.Stage,
+			},
+			&cli.BoolFlag{
+				Name:        "activate",
+				Usage:       "Activate the staged upgrade, so it is booted by default",
+				Destination: &UpgradeArgs.Activate,
+			},
+			&cli.StringFlag{
+				Name:        "at",
+				Usage:       "Schedule the activation of the staged upgrade at the given systemd calendar time",
+				Destination: &UpgradeArgs.At,
+			},
+			&cli.StringFlag{
+				Name:        "reboot",
+				Usage:       "Reboot into the new snapshot once activated, either 'kexec' or 'soft' (userspace only)",
+				Destination: &UpgradeArgs.Reboot,
+			},
+			&cli.BoolFlag{
+				Name:        "discard",
+				Usage:       "Discard the staged upgrade",
+				Destination: &UpgradeArgs.Discard,
+			},
 		},
 	}
 }
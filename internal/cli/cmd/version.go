@@ -30,6 +30,15 @@ var (
 	gitCommit = ""
 )
 
+// Version returns the program version including the abbreviated git commit
+func Version() string {
+	commit := gitCommit
+	if len(commit) > 7 {
+		commit = gitCommit[:7]
+	}
+	return fmt.Sprintf("%s+g%s", version, commit)
+}
+
 func NewVersionCommand(appName string) *cli.Command {
 	return &cli.Command{
 		Name:      "version",
@@ -37,12 +46,7 @@ func NewVersionCommand(appName string) *cli.Command {
 		Usage:     "Inspect program version",
 		UsageText: fmt.Sprintf("%s version", appName),
 		Action: func(_ context.Context, _ *cli.Command) error {
-			commit := gitCommit
-			if len(commit) > 7 {
-				commit = gitCommit[:7]
-			}
-
-			fmt.Printf("%s+g%s\n", version, commit)
+			fmt.Println(Version())
 
 			return nil
 		},
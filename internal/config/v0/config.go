@@ -85,6 +85,11 @@ func (dir Dir) CustomDir() string {
 	return filepath.Join(string(dir), "custom")
 }
 
+// InputPaths returns the paths, relative to the configuration directory, holding the image configuration
+func (dir Dir) InputPaths() []string {
+	return []string{"install.yaml", "release.yaml", "butane.yaml", "kubernetes", "network", "custom"}
+}
+
 func Parse(f vfs.FS, configDir Dir) (conf *image.Configuration, err error) {
 	conf = &image.Configuration{}
 
@@ -45,6 +45,7 @@ kernelCmdLine: "console=ttyS0 quiet loglevel=3"
 cryptoPolicy: fips
 raw:
   diskSize: 35G
+  format: qcow2
 iso:
   device: /dev/sda
 `
@@ -119,6 +120,7 @@ var _ = Describe("Configuration", Label("configuration"), func() {
 		Expect(conf.Installation.Bootloader).To(Equal("grub"))
 		Expect(conf.Installation.KernelCmdLine).To(Equal("console=ttyS0 quiet loglevel=3"))
 		Expect(conf.Installation.RAW.DiskSize).To(Equal(install.DiskSize("35G")))
+		Expect(conf.Installation.RAW.Format).To(Equal("qcow2"))
 		Expect(conf.Installation.ISO.Device).To(Equal("/dev/sda"))
 		Expect(conf.Installation.CryptoPolicy).To(Equal(crypto.FIPSPolicy))
 
@@ -266,6 +268,7 @@ schema: v0
 bootloader: invalid
 raw:
   diskSize: 35X
+  format: iso
 `
 		Expect(fs.WriteFile(installFile, []byte(invalidInstallYAML), 0644)).To(Succeed())
 
@@ -274,6 +277,7 @@ raw:
 		Expect(err.Error()).To(ContainSubstring("validating configuration"))
 		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.Bootloader\" must be one of [grub none], but got \"invalid\""))
 		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.RAW.DiskSize\" must be a valid disk size (e.g., 10G, 500M), but got \"35X\""))
+		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.RAW.Format\" must be one of [raw qcow2 vmdk vhdx vhd raw.zst raw.xz], but got \"iso\""))
 	})
 
 	It("Fails on missing required release configuration", func() {
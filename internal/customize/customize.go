@@ -30,9 +30,12 @@ import (
 	"github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/internal/template"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/installer"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/provenance"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
@@ -61,6 +64,10 @@ type Runner struct {
 	ConfigManager configManager
 	FileExtractor ociFileExtractor
 	Media         media
+	// Version is the elemental version recorded in the provenance file
+	Version string
+	// ConfigDir is the configuration directory recorded in the provenance file
+	ConfigDir string
 }
 
 func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.Output) (err error) {
@@ -94,6 +101,10 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		logger.Error("Parsing media type failed")
 		return err
 	}
+	if mediaType == installer.Netboot {
+		logger.Error("Netboot media cannot be customized")
+		return fmt.Errorf("customizing %s media: %w", mediaType, errors.ErrUnsupported)
+	}
 
 	dep, err := parseDeployment(
 		r.System.FS(),
@@ -124,6 +135,33 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 			return fmt.Errorf("could not parse disk size '%s': %w", diskSizeStr, err)
 		}
 		mediaOpts = append(mediaOpts, installer.WithRawDiskSize(deployment.MiB(diskMiB)))
+
+		format, err := diskimage.ParseFormat(def.Configuration.Installation.RAW.Format)
+		if err != nil {
+			return err
+		}
+		mediaOpts = append(mediaOpts, installer.WithDiskFormat(format))
+	}
+
+	if len(def.Image.SBOMFormats) > 0 {
+		doc, err := sbom.New("")
+		if err != nil {
+			return err
+		}
+		doc.Add(sbom.ImageComponent(sbom.OperatingSystem, containerImage))
+		doc.Add(sbom.ManifestComponents(rm)...)
+		mediaOpts = append(mediaOpts, installer.WithSBOM(doc, def.Image.SBOMFormats...))
+	}
+
+	logger.Info("Recording build provenance")
+	st, err := r.newProvenance(def, rm, containerImage, iso, output)
+	if err != nil {
+		logger.Error("Recording build provenance failed")
+		return err
+	}
+	if err = provenance.Write(r.System, st, filepath.Join(output.OverlaysDir(), provenance.EmbeddedPath)); err != nil {
+		logger.Error("Embedding build provenance failed")
+		return err
 	}
 
 	// TODO(ipetrov117): Consider refactoring installer.Media, as right now
@@ -144,6 +182,25 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		return err
 	}
 
+	outputImage := def.Image.OutputImageName
+	if mediaType == installer.Disk {
+		format, _ := diskimage.ParseFormat(def.Configuration.Installation.RAW.Format)
+		outputImage = diskimage.OutputPath(outputImage, format)
+	}
+	digest, err := provenance.FileDigest(r.System.FS(), outputImage)
+	if err != nil {
+		logger.Error("Computing customized image digest failed")
+		return err
+	}
+	if err = st.Finish(provenance.ResourceDescriptor{Name: filepath.Base(outputImage), Digest: digest}); err != nil {
+		return err
+	}
+	if err = provenance.Write(r.System, st, provenance.OutputPath(outputImage)); err != nil {
+		logger.Error("Writing build provenance failed")
+		return err
+	}
+	logger.Info("Build provenance written to %s", provenance.OutputPath(outputImage))
+
 	logger.Info("Customize complete")
 	return nil
 }
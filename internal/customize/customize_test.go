@@ -19,12 +19,14 @@ package customize_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.yaml.in/yaml/v3"
 
 	"github.com/suse/elemental/v3/internal/config"
 	"github.com/suse/elemental/v3/internal/customize"
@@ -32,8 +34,11 @@ import (
 	"github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/pkg/crypto"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/helm"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/provenance"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -87,7 +92,8 @@ disks:
         - path: /opt
         - path: /srv
         - path: /home`
-		expectedISO = "https://registry.foo.bar/uc-base-kernel-default-iso:0.0.1"
+		expectedISO     = "https://registry.foo.bar/uc-base-kernel-default-iso:0.0.1"
+		expectedISOFile = "/iso/uc-base-kernel-default-iso.iso"
 	)
 
 	var fs vfs.FS
@@ -116,6 +122,8 @@ disks:
 			return sysRunner.ReturnValue, sysRunner.ReturnError
 		}
 		Expect(vfs.MkdirAll(fs, output.RootPath, vfs.DirPerm)).To(Succeed())
+		Expect(vfs.MkdirAll(fs, filepath.Dir(expectedISOFile), vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(expectedISOFile, []byte("installer iso"), vfs.FilePerm)).To(Succeed())
 
 		customizeRunner = &customize.Runner{
 			System: s,
@@ -136,7 +144,7 @@ disks:
 			},
 			FileExtractor: &fileExtractorMock{
 				extractFunc: func(uri string) (path string, err error) {
-					return "", nil
+					return expectedISOFile, nil
 				},
 			},
 			Media: &mediaMock{
@@ -160,7 +168,7 @@ disks:
 		customizeRunner.FileExtractor = &fileExtractorMock{
 			extractFunc: func(uri string) (path string, err error) {
 				Expect(uri).To(Equal(expectedISO))
-				return "", nil
+				return expectedISOFile, nil
 			},
 		}
 
@@ -168,12 +176,13 @@ disks:
 		customizeRunner.Media = &mediaMock{
 			customizeFunc: func(d *deployment.Deployment) error {
 				customizeDeployment = d
-				return nil
+				return fs.WriteFile("/customized.iso", []byte("customized iso"), vfs.FilePerm)
 			},
 		}
 		def := &image.Definition{
 			Image: image.Image{
-				ImageType: "iso",
+				ImageType:       "iso",
+				OutputImageName: "/customized.iso",
 			},
 			Configuration: &image.Configuration{
 				Installation: install.Installation{
@@ -229,7 +238,7 @@ disks:
 		customizeRunner.FileExtractor = &fileExtractorMock{
 			extractFunc: func(uri string) (path string, err error) {
 				Expect(uri).To(Equal(expectedISO))
-				return "", nil
+				return expectedISOFile, nil
 			},
 		}
 
@@ -237,20 +246,20 @@ disks:
 		customizeRunner.Media = &mediaMock{
 			customizeFunc: func(d *deployment.Deployment) error {
 				customizeDeployment = d
-				return nil
+				return fs.WriteFile("/customized.raw", []byte("customized disk"), vfs.FilePerm)
 			},
 		}
 		sideEffects["truncate"] = func(args ...string) ([]byte, error) {
 			// args = [-s 35G customized.raw]
 			Expect(args[1]).To(Equal("35G"))
-			Expect(args[2]).To(Equal("customized.raw"))
+			Expect(args[2]).To(Equal("/customized.raw"))
 			return []byte{}, nil
 		}
 
 		def := &image.Definition{
 			Image: image.Image{
 				ImageType:       "raw",
-				OutputImageName: "customized.raw",
+				OutputImageName: "/customized.raw",
 			},
 			Configuration: &image.Configuration{
 				Installation: install.Installation{
@@ -269,6 +278,96 @@ disks:
 		defaultCustomizeDeploymentValidation(customizeDeployment)
 		Expect(customizeDeployment.Disks[0].Device).To(BeEmpty())
 		Expect(len(customizeDeployment.Disks[0].Partitions)).To(Equal(0))
+		Expect(vfs.Exists(fs, "/customized.raw.provenance.json")).To(BeTrue())
+	})
+
+	It("records the build provenance", func() {
+		customizeRunner.Version = "v1.0.0+gabcdef0"
+		customizeRunner.Media = &mediaMock{
+			customizeFunc: func(d *deployment.Deployment) error {
+				data, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), provenance.EmbeddedPath))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).ToNot(ContainSubstring("subject"))
+				return fs.WriteFile("/customized.iso", []byte("customized iso"), vfs.FilePerm)
+			},
+		}
+
+		extensionsDir := filepath.Join(output.OverlaysDir(), image.ExtensionsPath())
+		Expect(vfs.MkdirAll(fs, extensionsDir, vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile(filepath.Join(extensionsDir, "rke2-1.32_0.0.raw"), []byte("rke2"), vfs.FilePerm)).To(Succeed())
+		helmDir := filepath.Join(output.OverlaysDir(), image.HelmPath())
+		Expect(vfs.MkdirAll(fs, helmDir, vfs.DirPerm)).To(Succeed())
+		crd := helm.NewCRD("default", "foo", "0.0.1", "", "https://foo.github.io/charts")
+		data, err := yaml.Marshal(crd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.WriteFile(filepath.Join(helmDir, "foo.yaml"), data, vfs.FilePerm)).To(Succeed())
+
+		customizeRunner.ConfigManager = &configManagerMock{
+			configFunc: func(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error) {
+				return &resolver.ResolvedManifest{
+					CorePlatform: &core.ReleaseManifest{
+						Components: core.Components{
+							OperatingSystem: &core.OperatingSystem{
+								Image: core.Image{
+									Base: "registry.foo.bar/os-base:0.0.1@sha256:abcd",
+									ISO:  expectedISO,
+								},
+							},
+							Systemd: api.Systemd{
+								Extensions: []api.SystemdExtension{
+									{Name: "rke2", Image: "https://example.com/rke2-1.32_0.0.raw"},
+								},
+							},
+						},
+					},
+					Sources: []resolver.Source{{URI: "file:///config/manifest.yaml", Digest: "sha256:1234"}},
+				}, nil
+			},
+		}
+
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:       "iso",
+				OutputImageName: "/customized.iso",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					ISO: install.ISO{
+						Device: "/dev/sda",
+					},
+				},
+			},
+		}
+
+		Expect(customizeRunner.Run(context.Background(), def, output)).To(Succeed())
+
+		data, err = fs.ReadFile("/customized.iso.provenance.json")
+		Expect(err).ToNot(HaveOccurred())
+		st := &provenance.Statement{}
+		Expect(json.Unmarshal(data, st)).To(Succeed())
+		Expect(st.Subject).To(HaveLen(1))
+		Expect(st.Subject[0].Name).To(Equal("customized.iso"))
+		Expect(st.Predicate.RunDetails.Builder.Version).To(HaveKeyWithValue("elemental", "v1.0.0+gabcdef0"))
+		Expect(st.Predicate.BuildDefinition.ExternalParameters).To(HaveKeyWithValue("mediaType", "iso"))
+
+		deps := st.Predicate.BuildDefinition.ResolvedDependencies
+		Expect(deps).To(HaveLen(6))
+		Expect(deps[0]).To(Equal(provenance.ResourceDescriptor{
+			Name: "release-manifest", URI: "file:///config/manifest.yaml", Digest: map[string]string{"sha256": "1234"},
+		}))
+		Expect(deps[1]).To(Equal(provenance.ResourceDescriptor{
+			Name: "os-image", URI: "oci://registry.foo.bar/os-base:0.0.1@sha256:abcd", Digest: map[string]string{"sha256": "abcd"},
+		}))
+		Expect(deps[2].URI).To(Equal("oci://" + expectedISO))
+		Expect(deps[3].Name).To(Equal(filepath.Base(expectedISOFile)))
+		Expect(deps[3].Digest).To(HaveKey("sha256"))
+		Expect(deps[4].Name).To(Equal("rke2"))
+		Expect(deps[4].URI).To(Equal("https://example.com/rke2-1.32_0.0.raw"))
+		Expect(deps[4].Digest).To(HaveKey("sha256"))
+		Expect(deps[5]).To(Equal(provenance.ResourceDescriptor{
+			Name: "foo", URI: "https://foo.github.io/charts/foo",
+			Annotations: map[string]string{"type": "helm-chart", "version": "0.0.1"},
+		}))
 	})
 
 	It("fails to configure components", func() {
@@ -0,0 +1,187 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customize
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/internal/config"
+	v0 "github.com/suse/elemental/v3/internal/config/v0"
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/helm"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/provenance"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const buildType = "https://github.com/suse/elemental/customize/v1"
+
+// newProvenance returns a provenance statement listing the inputs of the customization
+func (r *Runner) newProvenance(def *image.Definition, rm *resolver.ResolvedManifest, isoImage, iso string, output config.Output) (*provenance.Statement, error) {
+	fs := r.System.FS()
+
+	st, err := provenance.New(buildType, r.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	st.SetParameter("mediaType", def.Image.ImageType)
+	st.SetParameter("platform", def.Image.Platform.String())
+	st.SetParameter("split", output.ConfigPath != "")
+	if def.Configuration.Installation.RAW.Format != "" {
+		st.SetParameter("format", def.Configuration.Installation.RAW.Format)
+	}
+	if r.ConfigDir != "" {
+		digest, err := provenance.DirDigest(fs, r.ConfigDir, v0.Dir(r.ConfigDir).InputPaths()...)
+		if err != nil {
+			return nil, fmt.Errorf("computing configuration directory digest: %w", err)
+		}
+		st.SetParameter("configDir", provenance.ResourceDescriptor{URI: "file://" + r.ConfigDir, Digest: digest})
+	}
+
+	for _, src := range rm.Sources {
+		st.AddDependency(provenance.ResourceDescriptor{
+			Name: "release-manifest", URI: src.URI, Digest: provenance.Digest(src.Digest),
+		})
+	}
+
+	if base := rm.CorePlatform.Components.OperatingSystem.Image.Base; base != "" {
+		st.AddDependency(provenance.ImageDependency("os-image", base))
+	}
+	st.AddDependency(provenance.ImageDependency("installer-iso-image", isoImage))
+
+	isoDigest, err := provenance.FileDigest(fs, iso)
+	if err != nil {
+		return nil, fmt.Errorf("computing installer ISO digest: %w", err)
+	}
+	st.AddDependency(provenance.ResourceDescriptor{
+		Name: filepath.Base(iso), Digest: isoDigest,
+		Annotations: map[string]string{"type": "installer-iso", "source": isoImage},
+	})
+
+	extensions, err := extensionDependencies(fs, rm, filepath.Join(output.OverlaysDir(), image.ExtensionsPath()))
+	if err != nil {
+		return nil, err
+	}
+	st.AddDependency(extensions...)
+
+	charts, err := helmDependencies(fs, filepath.Join(output.OverlaysDir(), image.HelmPath()))
+	if err != nil {
+		return nil, err
+	}
+	st.AddDependency(charts...)
+
+	return st, nil
+}
+
+// extensionDependencies returns the system extensions found in the given directory, matched with
+// the release manifest extensions they were pulled from
+func extensionDependencies(fs vfs.FS, rm *resolver.ResolvedManifest, extensionsDir string) ([]provenance.ResourceDescriptor, error) {
+	if ok, _ := vfs.Exists(fs, extensionsDir); !ok {
+		return nil, nil
+	}
+
+	var extensions []api.SystemdExtension
+	if rm.CorePlatform != nil {
+		extensions = append(extensions, rm.CorePlatform.Components.Systemd.Extensions...)
+	}
+	if rm.ProductExtension != nil {
+		extensions = append(extensions, rm.ProductExtension.Components.Systemd.Extensions...)
+	}
+
+	entries, err := fs.ReadDir(extensionsDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading extensions directory: %w", err)
+	}
+
+	var deps []provenance.ResourceDescriptor
+	for _, entry := range entries {
+		dep := provenance.ResourceDescriptor{Name: entry.Name(), Annotations: map[string]string{"type": "sysext"}}
+
+		if entry.IsDir() {
+			dep.Digest, err = provenance.DirDigest(fs, extensionsDir, entry.Name())
+		} else {
+			dep.Digest, err = provenance.FileDigest(fs, filepath.Join(extensionsDir, entry.Name()))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("computing extension '%s' digest: %w", entry.Name(), err)
+		}
+
+		i := slices.IndexFunc(extensions, func(ext api.SystemdExtension) bool {
+			return entry.Name() == ext.Name || entry.Name() == filepath.Base(ext.Image) ||
+				strings.HasPrefix(entry.Name(), ext.Name+"_") || strings.HasPrefix(entry.Name(), ext.Name+"-")
+		})
+		if i >= 0 {
+			dep.Name = extensions[i].Name
+			dep.URI = extensions[i].Image
+			if !isURL(dep.URI) {
+				dep.URI = "oci://" + dep.URI
+			}
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// helmDependencies returns the Helm charts of the HelmChart resources found in the given directory
+func helmDependencies(fs vfs.FS, helmDir string) ([]provenance.ResourceDescriptor, error) {
+	if ok, _ := vfs.Exists(fs, helmDir); !ok {
+		return nil, nil
+	}
+
+	entries, err := fs.ReadDir(helmDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading Helm charts directory: %w", err)
+	}
+
+	var deps []provenance.ResourceDescriptor
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" {
+			continue
+		}
+
+		data, err := fs.ReadFile(filepath.Join(helmDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading Helm chart resource: %w", err)
+		}
+		crd := &helm.CRD{}
+		if err = yaml.Unmarshal(data, crd); err != nil {
+			return nil, fmt.Errorf("parsing Helm chart resource '%s': %w", entry.Name(), err)
+		}
+
+		uri := crd.Spec.Chart
+		if crd.Spec.Repo != "" {
+			uri = fmt.Sprintf("%s/%s", strings.TrimSuffix(crd.Spec.Repo, "/"), crd.Spec.Chart)
+		}
+		deps = append(deps, provenance.ResourceDescriptor{
+			Name: crd.Metadata.Name, URI: uri,
+			Annotations: map[string]string{"type": "helm-chart", "version": crd.Spec.Version},
+		})
+	}
+	return deps, nil
+}
+
+func isURL(uri string) bool {
+	return strings.Contains(uri, "://")
+}
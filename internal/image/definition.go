@@ -23,6 +23,7 @@ import (
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/internal/image/release"
 
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 )
 
@@ -48,6 +49,8 @@ type Image struct {
 	ImageType       string
 	Platform        *platform.Platform
 	OutputImageName string
+	// SBOMFormats are the formats of the software bill of materials written next to the image
+	SBOMFormats []sbom.Format
 }
 
 type Network struct {
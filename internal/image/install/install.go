@@ -62,6 +62,7 @@ type Installation struct {
 	SchemaVersion string        `yaml:"schema"`
 	Bootloader    string        `yaml:"bootloader" validate:"omitempty,oneof=grub none"`
 	KernelCmdLine string        `yaml:"kernelCmdLine"`
+	BIOS          bool          `yaml:"bios"`
 	RAW           RAW           `yaml:"raw"`
 	ISO           ISO           `yaml:"iso"`
 	CryptoPolicy  crypto.Policy `yaml:"cryptoPolicy" validate:"omitempty,oneof=fips default"`
@@ -69,6 +70,7 @@ type Installation struct {
 
 type RAW struct {
 	DiskSize DiskSize `yaml:"diskSize" validate:"omitempty,disksize"`
+	Format   string   `yaml:"format" validate:"omitempty,oneof=raw qcow2 vmdk vhdx vhd raw.zst raw.xz"`
 }
 
 type ISO struct {
@@ -20,6 +20,7 @@ package bootloader
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/suse/elemental/v3/pkg/sys"
 )
@@ -30,6 +31,26 @@ type Bootloader interface {
 	Prune(rootPath, espDir string, keepEntryIDs []int) error
 }
 
+// CmdlineEditor is implemented by bootloaders able to change the kernel command line of the
+// installed boot entries without reinstalling them
+type CmdlineEditor interface {
+	// Entries lists the IDs of the installed boot entries
+	Entries(espDir string) ([]string, error)
+	// Cmdline returns the kernel command line of the given boot entry
+	Cmdline(espDir, entryID string) (string, error)
+	// SetCmdline sets the kernel command line of the given boot entry
+	SetCmdline(espDir, entryID, cmdline string) error
+	// SetNextBootCmdline boots the given entry with the given kernel command line on next boot only
+	SetNextBootCmdline(espDir, entryID, cmdline string) error
+}
+
+// NetbootInstaller is implemented by bootloaders able to boot the live media over the network
+type NetbootInstaller interface {
+	// InstallNetboot installs the network boot artifacts to the target directory, which is
+	// expected to be served from the given base URL
+	InstallNetboot(rootPath, target, baseURL, kernelCmdline string) error
+}
+
 const (
 	BootNone = "none"
 	BootGrub = "grub"
@@ -53,17 +74,28 @@ func (n *None) InstallLive(_, _, _ string) error {
 	return nil
 }
 
+func (n *None) InstallNetboot(_, _, _, _ string) error {
+	n.s.Logger().Info("Skipping bootloader installation")
+	return nil
+}
+
 func (n *None) Prune(_, _ string, _ []int) error {
 	n.s.Logger().Info("Skipping bootloader pruning")
 	return nil
 }
 
-func New(name string, s *sys.System) (Bootloader, error) {
+// StateFiles returns the ESP files holding the state of the installed boot entries. Restoring
+// them reverts the boot entries to the state they had before an installation.
+func StateFiles(espDir string) []string {
+	return []string{filepath.Join(espDir, grubEnvFile)}
+}
+
+func New(name string, s *sys.System, opts ...Option) (Bootloader, error) {
 	switch name {
 	case BootNone:
 		return NewNone(s), nil
 	case BootGrub:
-		return NewGrub(s), nil
+		return NewGrub(s, opts...), nil
 	}
 
 	return nil, fmt.Errorf("new bootloader '%s': %w", name, errors.ErrUnsupported)
@@ -57,4 +57,16 @@ var _ = Describe("Bootloader tests", Label("bootloader", "grub", "none"), func()
 		Expect(err).To(HaveOccurred())
 		Expect(errors.Is(err, errors.ErrUnsupported)).To(BeTrue(), err.Error())
 	})
+	It("Appends kernel command line arguments", func() {
+		Expect(bootloader.AppendArgs("root=LABEL=SYSTEM quiet", "console=ttyS0 quiet", "splash")).To(
+			Equal("root=LABEL=SYSTEM quiet console=ttyS0 splash"),
+		)
+		Expect(bootloader.AppendArgs("", "quiet")).To(Equal("quiet"))
+	})
+	It("Removes kernel command line arguments", func() {
+		cmdline := "root=LABEL=SYSTEM console=tty0 console=ttyS0 quiet"
+		Expect(bootloader.RemoveArgs(cmdline, "console=ttyS0")).To(Equal("root=LABEL=SYSTEM console=tty0 quiet"))
+		Expect(bootloader.RemoveArgs(cmdline, "console", "quiet")).To(Equal("root=LABEL=SYSTEM"))
+		Expect(bootloader.RemoveArgs(cmdline, "splash")).To(Equal(cmdline))
+	})
 })
@@ -0,0 +1,58 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader
+
+import (
+	"slices"
+	"strings"
+)
+
+// AppendArgs appends the given arguments to the kernel command line, arguments already
+// present are not duplicated
+func AppendArgs(cmdline string, args ...string) string {
+	fields := strings.Fields(cmdline)
+	for _, arg := range args {
+		for _, a := range strings.Fields(arg) {
+			if !slices.Contains(fields, a) {
+				fields = append(fields, a)
+			}
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// RemoveArgs removes the given arguments from the kernel command line. Arguments including a
+// value (e.g. 'console=ttyS0') only remove exact matches, arguments without a value (e.g. 'console')
+// remove any occurrence of the parameter regardless of its value.
+func RemoveArgs(cmdline string, args ...string) string {
+	var remove []string
+	for _, arg := range args {
+		remove = append(remove, strings.Fields(arg)...)
+	}
+
+	fields := slices.DeleteFunc(strings.Fields(cmdline), func(field string) bool {
+		key, _, _ := strings.Cut(field, "=")
+		for _, r := range remove {
+			if r == field || (!strings.Contains(r, "=") && r == key) {
+				return true
+			}
+		}
+		return false
+	})
+	return strings.Join(fields, " ")
+}
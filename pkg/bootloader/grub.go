@@ -21,15 +21,17 @@ import (
 	"crypto/rand"
 	_ "embed"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 
-	"github.com/joho/godotenv"
-
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/grubenv"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
@@ -37,7 +39,26 @@ import (
 )
 
 type Grub struct {
-	s *sys.System
+	s    *sys.System
+	menu grubMenu
+	// bios enables legacy BIOS boot, grub core image is embedded into biosDevice if set
+	bios       bool
+	biosDevice string
+	// bootID is the identifier of live media, a random one is generated if empty
+	bootID string
+}
+
+// grubMenu holds the boot menu settings as they are rendered in grub configuration files
+type grubMenu struct {
+	timeout *int
+	Hidden  bool
+	// Serial holds the serial command arguments, empty if no serial terminal is used
+	Serial  string
+	Inputs  string
+	Outputs string
+	User    string
+	Hash    string
+	Entries []deployment.MenuEntry
 }
 
 type grubBootEntry struct {
@@ -50,8 +71,34 @@ type grubBootEntry struct {
 
 type Option func(*Grub)
 
+// WithMenu sets the boot menu customizations. Graphical and serial terminals are used by default,
+// so headless systems can be reached from a serial console.
+func WithMenu(menu *deployment.BootMenu) Option {
+	return func(g *Grub) {
+		g.menu = newGrubMenu(menu)
+	}
+}
+
+// WithBIOS enables legacy BIOS boot in addition to EFI. The grub core image is installed into
+// the given disk device, an empty device only refreshes the BIOS grub configuration, which is
+// enough for upgrades as the BIOS grub modules are not synced from the OS image.
+func WithBIOS(device string) Option {
+	return func(g *Grub) {
+		g.bios = true
+		g.biosDevice = device
+	}
+}
+
+// WithBootID sets the identifier live media boot entries search the boot device for. A random
+// identifier is generated by default, a fixed one allows reproducible builds.
+func WithBootID(id string) Option {
+	return func(g *Grub) {
+		g.bootID = id
+	}
+}
+
 func NewGrub(s *sys.System, opts ...Option) *Grub {
-	g := &Grub{s}
+	g := &Grub{s: s, menu: newGrubMenu(nil)}
 
 	for _, opt := range opts {
 		opt(g)
@@ -65,11 +112,26 @@ const (
 	Initrd         = "initrd"
 	DefaultBootID  = "active"
 	RecoveryBootID = "recovery"
+	NextBootID     = "next"
+
+	// LiveBIOSImage is the path of the El Torito image booting grub on BIOS systems within the live media
+	LiveBIOSImage = "/boot/grub2/i386-pc/eltorito.img"
+	// NetbootIPXEScript is the name of the iPXE script booting the live media over the network
+	NetbootIPXEScript = "boot.ipxe"
+	// LiveBIOSMBR is the path of the hybrid MBR boot code booting grub from USB sticks on BIOS systems
+	// within the live media
+	LiveBIOSMBR = "/boot/grub2/i386-pc/boot_hybrid.img"
 
 	liveBootPath = "/boot"
 	grubEnvFile  = "grubenv"
+	biosTarget   = "i386-pc"
+
+	serialTerm         = "serial"
+	defaultSerialSpeed = 115200
 )
 
+var defaultTerminals = []string{"gfxterm", serialTerm}
+
 //go:embed grubtemplates/grub.cfg
 var grubCfg []byte
 
@@ -79,6 +141,12 @@ var grubLiveEFICfg []byte
 //go:embed grubtemplates/grub_live.cfg
 var grubLiveCfg []byte
 
+//go:embed grubtemplates/grub_netboot.cfg
+var grubNetbootCfg []byte
+
+//go:embed grubtemplates/netboot.ipxe
+var netbootIPXE []byte
+
 // InstallLive installs the live bootloader to the specified target.
 func (g *Grub) InstallLive(rootPath, target, kernelCmdLine string) error {
 	g.s.Logger().Info("Preparing GRUB bootloader for live media")
@@ -94,14 +162,25 @@ func (g *Grub) InstallLive(rootPath, target, kernelCmdLine string) error {
 	}
 	entry.CmdLine = kernelCmdLine
 
-	err = g.writeGrubConfig(filepath.Join(target, liveBootPath, "grub2"), grubLiveCfg, entry)
+	liveData := struct {
+		grubBootEntry
+		Menu grubMenu
+	}{entry, g.menu}
+	err = g.writeGrubConfig(filepath.Join(target, liveBootPath, "grub2"), grubLiveCfg, liveData)
 	if err != nil {
 		return fmt.Errorf("failed writing grub config file: %w", err)
 	}
 
+	if g.bios {
+		err = g.installLiveBIOS(rootPath, target)
+		if err != nil {
+			return fmt.Errorf("installing BIOS boot image: %w", err)
+		}
+	}
+
 	// update cmdline variable in /boot/grubenv
 	grubEnvPath := filepath.Join(target, liveBootPath, grubEnvFile)
-	_, err = g.s.Runner().Run("grub2-editenv", grubEnvPath, "set", fmt.Sprintf("cmdline=%s", kernelCmdLine))
+	err = grubenv.Set(g.s, grubEnvPath, map[string]string{"cmdline": kernelCmdLine})
 	if err != nil {
 		return fmt.Errorf("failed setting kernel command line for grub: %w", err)
 	}
@@ -121,6 +200,56 @@ func (g *Grub) InstallLive(rootPath, target, kernelCmdLine string) error {
 	return nil
 }
 
+// InstallNetboot installs the live kernel and initrd to the specified target together with an iPXE
+// script and the EFI applications booting them over the network from the given base URL. GRUB
+// can only fetch files over plain http, for https URLs only the iPXE script is written.
+func (g *Grub) InstallNetboot(rootPath, target, baseURL, kernelCmdLine string) error {
+	g.s.Logger().Info("Preparing GRUB bootloader for network boot")
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("parsing netboot URL '%s': %w", baseURL, err)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	entry, err := g.installKernelInitrd(rootPath, target, liveBootPath)
+	if err != nil {
+		return fmt.Errorf("installing kernel+initrd: %w", err)
+	}
+	entry.CmdLine = kernelCmdLine
+
+	ipxeData := struct {
+		grubBootEntry
+		BaseURL    string
+		InitrdName string
+	}{entry, baseURL, Initrd}
+	err = g.writeTemplate(filepath.Join(target, NetbootIPXEScript), netbootIPXE, ipxeData)
+	if err != nil {
+		return fmt.Errorf("failed writing iPXE script: %w", err)
+	}
+
+	if u.Scheme != "http" {
+		g.s.Logger().Warn("GRUB cannot boot from '%s', skipping EFI network boot setup", baseURL)
+		return nil
+	}
+
+	host := u.Hostname()
+	if port := u.Port(); port != "" {
+		host = fmt.Sprintf("%s,%s", host, port)
+	}
+	netData := struct {
+		grubBootEntry
+		Root string
+		Menu grubMenu
+	}{entry, fmt.Sprintf("(http,%s)%s", host, strings.TrimSuffix(u.Path, "/")), g.menu}
+	err = g.installEFIEntry(rootPath, filepath.Join(target, "EFI", "BOOT"), grubNetbootCfg, netData)
+	if err != nil {
+		return fmt.Errorf("installing elemental EFI apps: %w", err)
+	}
+
+	return nil
+}
+
 // Install installs the bootloader to the specified root.
 func (g *Grub) Install(rootPath, espDir, espLabel, entryID, kernelCmdline, recKernelCmdline string) error {
 	err := g.installElementalEFI(rootPath, espDir, espLabel)
@@ -128,11 +257,20 @@ func (g *Grub) Install(rootPath, espDir, espLabel, entryID, kernelCmdline, recKe
 		return fmt.Errorf("installing elemental EFI apps: %w", err)
 	}
 
-	err = g.installGrub(rootPath, espDir)
+	// BIOS grub modules must match the core image embedded in the disk, hence they are
+	// only installed by grub2-install
+	err = g.installGrub(rootPath, espDir, "/"+biosTarget)
 	if err != nil {
 		return fmt.Errorf("installing grub config: %w", err)
 	}
 
+	if g.bios {
+		err = g.installBIOS(rootPath, espDir, espLabel)
+		if err != nil {
+			return fmt.Errorf("installing BIOS boot: %w", err)
+		}
+	}
+
 	entry, err := g.installKernelInitrd(rootPath, espDir, "")
 	if err != nil {
 		return fmt.Errorf("installing kernel+initrd: %w", err)
@@ -230,9 +368,7 @@ func (g Grub) Prune(rootPath, espDir string, keepSnapshotIDs []int) (err error)
 	}
 
 	// update entries variable in /boot/grubenv
-	stdOut, err := g.s.Runner().Run("grub2-editenv", grubEnvPath, "set", fmt.Sprintf("entries=%s", strings.Join(activeEntries, " ")))
-	g.s.Logger().Debug("grub2-editenv stdout: %s", string(stdOut))
-
+	err = grubenv.Set(g.s, grubEnvPath, map[string]string{"entries": strings.Join(activeEntries, " ")})
 	if err != nil {
 		return fmt.Errorf("failed saving %s: %w", grubEnvPath, err)
 	}
@@ -240,6 +376,74 @@ func (g Grub) Prune(rootPath, espDir string, keepSnapshotIDs []int) (err error)
 	return g.pruneOldKernels(rootPath, espDir, activeEntries)
 }
 
+// Entries lists the IDs of the boot entries listed in the grubenv file of the given ESP.
+func (g *Grub) Entries(espDir string) ([]string, error) {
+	grubEnv, err := g.readGrubEnv(filepath.Join(espDir, grubEnvFile))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(grubEnv["entries"]), nil
+}
+
+// Cmdline returns the kernel command line of the given boot entry.
+func (g *Grub) Cmdline(espDir, entryID string) (string, error) {
+	grubEnv, err := g.readBootEntry(espDir, entryID)
+	if err != nil {
+		return "", err
+	}
+	return grubEnv["cmdline"], nil
+}
+
+// SetCmdline sets the kernel command line of the given boot entry.
+func (g *Grub) SetCmdline(espDir, entryID, cmdline string) error {
+	if _, err := g.readBootEntry(espDir, entryID); err != nil {
+		return err
+	}
+
+	entryPath := filepath.Join(espDir, "loader", "entries", entryID)
+	err := grubenv.Set(g.s, entryPath, map[string]string{"cmdline": cmdline})
+	if err != nil {
+		return fmt.Errorf("setting cmdline of boot entry '%s': %w", entryID, err)
+	}
+	return nil
+}
+
+// SetNextBootCmdline writes a one-shot copy of the given boot entry with the given kernel command line
+// and sets it as the entry to boot on next boot only.
+func (g *Grub) SetNextBootCmdline(espDir, entryID, cmdline string) error {
+	grubEnv, err := g.readBootEntry(espDir, entryID)
+	if err != nil {
+		return err
+	}
+
+	err = g.writeBootEntry(espDir, &grubBootEntry{
+		Linux:       grubEnv["linux"],
+		Initrd:      grubEnv["initrd"],
+		DisplayName: fmt.Sprintf("%s (next boot)", grubEnv["display_name"]),
+		CmdLine:     cmdline,
+		ID:          NextBootID,
+	})
+	if err != nil {
+		return fmt.Errorf("writing next boot entry: %w", err)
+	}
+
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	err = grubenv.Set(g.s, grubEnvPath, map[string]string{"next_entry": NextBootID})
+	if err != nil {
+		return fmt.Errorf("setting next boot entry: %w", err)
+	}
+	return nil
+}
+
+// readBootEntry reads the variables of the given boot entry, it fails if the entry is not installed.
+func (g *Grub) readBootEntry(espDir, entryID string) (map[string]string, error) {
+	entryPath := filepath.Join(espDir, "loader", "entries", entryID)
+	if ok, _ := vfs.Exists(g.s.FS(), entryPath); !ok {
+		return nil, fmt.Errorf("boot entry '%s' not found", entryID)
+	}
+	return g.readGrubEnv(entryPath)
+}
+
 func (g Grub) pruneOldKernels(rootPath, espDir string, activeEntries []string) error {
 	activeKernels := map[string]bool{}
 
@@ -295,11 +499,14 @@ func (g Grub) pruneOldKernels(rootPath, espDir string, activeEntries []string) e
 }
 
 func (g Grub) generateIDFile(targetDir string) (string, error) {
-	bytes := make([]byte, 4)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", fmt.Errorf("failed generating random boot identifier: %w", err)
+	randomID := g.bootID
+	if randomID == "" {
+		bytes := make([]byte, 4)
+		if _, err := rand.Read(bytes); err != nil {
+			return "", fmt.Errorf("failed generating random boot identifier: %w", err)
+		}
+		randomID = hex.EncodeToString(bytes)
 	}
-	randomID := hex.EncodeToString(bytes)
 
 	idFile := filepath.Join(targetDir, randomID)
 	err := g.s.FS().WriteFile(idFile, []byte(randomID), vfs.FilePerm)
@@ -315,15 +522,19 @@ func (g Grub) writeGrubConfig(targetDir string, cfgTemplate []byte, data any) er
 		return fmt.Errorf("failed creating grub target directory %s: %w", targetDir, err)
 	}
 
-	gCfg := filepath.Join(targetDir, "grub.cfg")
-	f, err := g.s.FS().Create(gCfg)
+	return g.writeTemplate(filepath.Join(targetDir, "grub.cfg"), cfgTemplate, data)
+}
+
+// writeTemplate renders the given template with the given data into the target file
+func (g Grub) writeTemplate(target string, tmpl []byte, data any) error {
+	f, err := g.s.FS().Create(target)
 	if err != nil {
-		return fmt.Errorf("failed creating bootloader config file %s: %w", gCfg, err)
+		return fmt.Errorf("failed creating bootloader config file %s: %w", target, err)
 	}
 
-	gcfg := template.New("grub")
-	gcfg = template.Must(gcfg.Parse(string(cfgTemplate)))
-	err = gcfg.Execute(f, data)
+	t := template.New(filepath.Base(target)).Funcs(template.FuncMap{"grubQuote": grubQuote})
+	t = template.Must(t.Parse(string(tmpl)))
+	err = t.Execute(f, data)
 	if err != nil {
 		_ = f.Close()
 		return fmt.Errorf("failed rendering bootloader config file: %w", err)
@@ -331,18 +542,28 @@ func (g Grub) writeGrubConfig(targetDir string, cfgTemplate []byte, data any) er
 
 	err = f.Close()
 	if err != nil {
-		return fmt.Errorf("failed closing bootloader config file %s: %w", gCfg, err)
+		return fmt.Errorf("failed closing bootloader config file %s: %w", target, err)
 	}
 	return nil
 }
 
+// grubQuote escapes the given string to be included within double quotes in a grub script,
+// so it is not expanded nor closes the quoted string. Newlines are replaced by spaces.
+func grubQuote(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", " ").Replace(str)
+}
+
 // installElementalEFI installs the efi applications (shim, MokManager, grub.efi) and grub.cfg into the ESP.
 func (g *Grub) installElementalEFI(rootPath, espDir, espLabel string) error {
 	g.s.Logger().Info("Installing EFI applications")
 
 	for _, efiEntry := range []string{"BOOT", "ELEMENTAL"} {
 		targetDir := filepath.Join(espDir, "EFI", efiEntry)
-		err := g.installEFIEntry(rootPath, targetDir, grubCfg, map[string]string{"Label": espLabel})
+		data := struct {
+			Label string
+			Menu  grubMenu
+		}{espLabel, g.menu}
+		err := g.installEFIEntry(rootPath, targetDir, grubCfg, data)
 		if err != nil {
 			return fmt.Errorf("failed setting '%s' EFI entry: %w", efiEntry, err)
 		}
@@ -424,8 +645,66 @@ func defaultEfiBootFileName(p *platform.Platform) (string, string) {
 	}
 }
 
-// installGrub installs grub themes and configs to $ESP/grub2
-func (g *Grub) installGrub(rootPath, espDir string) error {
+// installBIOS writes the grub configuration loaded by the BIOS core image and installs the core image
+// into the BIOS disk, if any. The core image prefix is $ESP/grub2.
+func (g *Grub) installBIOS(rootPath, espDir, espLabel string) error {
+	if g.s.Platform().GolangArch != platform.ArchAmd64 {
+		return fmt.Errorf("legacy BIOS boot on %s: %w", g.s.Platform().Arch, errors.ErrUnsupported)
+	}
+
+	data := struct {
+		Label string
+		Menu  grubMenu
+	}{espLabel, g.menu}
+	err := g.writeGrubConfig(filepath.Join(espDir, "grub2"), grubCfg, data)
+	if err != nil {
+		return fmt.Errorf("failed writing BIOS grub config file: %w", err)
+	}
+
+	if g.biosDevice == "" {
+		return nil
+	}
+
+	g.s.Logger().Info("Installing grub core image into %s", g.biosDevice)
+	stdOut, err := g.s.Runner().Run(
+		"grub2-install", fmt.Sprintf("--target=%s", biosTarget), fmt.Sprintf("--boot-directory=%s", espDir),
+		fmt.Sprintf("--directory=%s", filepath.Join(rootPath, "/usr/share/grub2", biosTarget)), g.biosDevice,
+	)
+	g.s.Logger().Debug("grub2-install stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("running grub2-install on '%s': %w", g.biosDevice, err)
+	}
+	return nil
+}
+
+// installLiveBIOS creates the El Torito boot image for BIOS systems. It uses the BIOS grub modules
+// synced into the live media, so the live grub configuration is loaded from the same prefix on BIOS
+// and EFI systems.
+func (g *Grub) installLiveBIOS(rootPath, target string) error {
+	if g.s.Platform().GolangArch != platform.ArchAmd64 {
+		return fmt.Errorf("legacy BIOS boot on %s: %w", g.s.Platform().Arch, errors.ErrUnsupported)
+	}
+
+	modulesDir := filepath.Join(rootPath, "/usr/share/grub2", biosTarget)
+	if ok, _ := vfs.Exists(g.s.FS(), modulesDir); !ok {
+		return fmt.Errorf("grub modules for BIOS not found at '%s'", modulesDir)
+	}
+
+	stdOut, err := g.s.Runner().Run(
+		"grub2-mkimage", fmt.Sprintf("--format=%s-eltorito", biosTarget),
+		fmt.Sprintf("--output=%s", filepath.Join(target, LiveBIOSImage)),
+		fmt.Sprintf("--prefix=%s", filepath.Join(liveBootPath, "grub2")),
+		fmt.Sprintf("--directory=%s", modulesDir), "biosdisk", "iso9660",
+	)
+	g.s.Logger().Debug("grub2-mkimage stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("creating El Torito image: %w", err)
+	}
+	return nil
+}
+
+// installGrub installs grub themes and configs to $ESP/grub2, excluding the given paths
+func (g *Grub) installGrub(rootPath, espDir string, excludes ...string) error {
 	g.s.Logger().Info("Syncing grub2 directory to ESP...")
 
 	target := filepath.Join(espDir, "grub2")
@@ -437,7 +716,7 @@ func (g *Grub) installGrub(rootPath, espDir string) error {
 	// Since we are copying to a vfat filesystem we have to skip symlinks.
 	r := rsync.NewRsync(g.s, rsync.WithFlags("--archive", "--recursive", "--no-links"))
 
-	err = r.SyncData(filepath.Join(rootPath, "/usr/share/grub2"), target)
+	err = r.SyncData(filepath.Join(rootPath, "/usr/share/grub2"), target, excludes...)
 	if err != nil {
 		return fmt.Errorf("syncing grub files: %w", err)
 	}
@@ -531,17 +810,11 @@ func (g *Grub) installKernelInitrd(rootPath, espDir, subfolder string) (grubBoot
 }
 
 func (g *Grub) readGrubEnv(path string) (map[string]string, error) {
-	stdOut, err := g.s.Runner().Run("grub2-editenv", path, "list")
-	if err != nil {
-		return nil, fmt.Errorf("reading grubenv '%s': %w", path, err)
-	}
-
-	val, err := godotenv.UnmarshalBytes(stdOut)
+	env, err := grubenv.Read(g.s, path)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshalling '%s': %w", path, err)
+		return nil, err
 	}
-
-	return val, nil
+	return env.Vars(), nil
 }
 
 func (g *Grub) updateBootEntries(espDir string, newEntries ...*grubBootEntry) error {
@@ -592,6 +865,8 @@ func (g *Grub) updateBootEntries(espDir string, newEntries ...*grubBootEntry) er
 			hasDefault = true
 			continue
 		}
+		// entries might be rewritten (e.g. A/B slots), keep them listed only once
+		activeEntries = slices.DeleteFunc(activeEntries, func(id string) bool { return id == entry.ID })
 		activeEntries = append(activeEntries, entry.ID)
 	}
 
@@ -604,22 +879,57 @@ func (g *Grub) updateBootEntries(espDir string, newEntries ...*grubBootEntry) er
 	}
 
 	// update entries variable in /boot/grubenv
-	stdOut, err := g.s.Runner().Run("grub2-editenv", grubEnvPath, "set", fmt.Sprintf("entries=%s", strings.Join(activeEntries, " ")))
-	g.s.Logger().Debug("grub2-editenv stdout: %s", string(stdOut))
-
-	return err
+	return grubenv.Set(g.s, grubEnvPath, map[string]string{"entries": strings.Join(activeEntries, " ")})
 }
 
 func (g Grub) writeBootEntry(espDir string, entry *grubBootEntry) error {
-	displayName := fmt.Sprintf("display_name=%s", entry.DisplayName)
-	linux := fmt.Sprintf("linux=%s", entry.Linux)
-	initrd := fmt.Sprintf("initrd=%s", entry.Initrd)
-	cmdline := fmt.Sprintf("cmdline=%s", entry.CmdLine)
+	return grubenv.Set(g.s, filepath.Join(espDir, "loader", "entries", entry.ID), map[string]string{
+		"display_name": entry.DisplayName,
+		"linux":        entry.Linux,
+		"initrd":       entry.Initrd,
+		"cmdline":      entry.CmdLine,
+	})
+}
 
-	stdOut, err := g.s.Runner().Run("grub2-editenv", filepath.Join(espDir, "loader", "entries", entry.ID), "set", displayName, linux, initrd, cmdline)
-	g.s.Logger().Debug("grub2-editenv stdout: %s", string(stdOut))
-	if err != nil {
-		return err
+// newGrubMenu computes the grub menu settings for the given boot menu, defaults are applied for
+// any unset value
+func newGrubMenu(menu *deployment.BootMenu) grubMenu {
+	if menu == nil {
+		menu = &deployment.BootMenu{}
 	}
-	return nil
+	gm := grubMenu{timeout: menu.Timeout, Hidden: menu.Hidden, Entries: menu.Entries}
+
+	terminals := menu.Terminals
+	if len(terminals) == 0 {
+		terminals = defaultTerminals
+	}
+	inputs := []string{"console"}
+	if slices.Contains(terminals, serialTerm) {
+		serial := menu.Serial
+		if serial == nil {
+			serial = &deployment.SerialConsole{}
+		}
+		speed := serial.Speed
+		if speed == 0 {
+			speed = defaultSerialSpeed
+		}
+		gm.Serial = fmt.Sprintf("--unit=%d --speed=%d", serial.Unit, speed)
+		inputs = append(inputs, serialTerm)
+	}
+	gm.Inputs = strings.Join(inputs, " ")
+	gm.Outputs = strings.Join(terminals, " ")
+
+	if menu.Password != nil {
+		gm.User = menu.Password.User
+		gm.Hash = menu.Password.Hash
+	}
+	return gm
+}
+
+// TimeoutOr returns the menu timeout or the given default if unset
+func (m grubMenu) TimeoutOr(def int) int {
+	if m.timeout == nil {
+		return def
+	}
+	return *m.timeout
 }
@@ -27,6 +27,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/grubenv"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
@@ -41,6 +43,13 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 	var runner *sysmock.Runner
 	var syscall *sysmock.Syscall
 	var mounter *sysmock.Mounter
+
+	readGrubEnv := func(path string) map[string]string {
+		env, err := grubenv.Read(s, path)
+		Expect(err).NotTo(HaveOccurred())
+		return env.Vars()
+	}
+
 	BeforeEach(func() {
 		var err error
 		tfs, cleanup, err = sysmock.TestFS(map[string]any{
@@ -65,19 +74,7 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 
 		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
 			switch filepath.Base(command) {
-			case "grub2-editenv":
-				path := args[0]
-				switch args[1] {
-				case "set":
-					// Write args to the file
-					content := strings.Join(args[2:], "\n")
-					err := tfs.WriteFile(path, []byte(content), vfs.FilePerm)
-					Expect(err).NotTo(HaveOccurred())
-				case "list":
-					return tfs.ReadFile(path)
-				}
-				return nil, nil
-			case "rsync":
+			case "rsync", "grub2-install", "grub2-mkimage":
 				return nil, nil
 			}
 
@@ -150,6 +147,146 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(vfs.Exists(tfs, "/iso/dir/EFI/BOOT/grub.cfg")).To(BeTrue())
 		Expect(vfs.Exists(tfs, "/iso/dir/boot/grub2/grub.cfg")).To(BeTrue())
 	})
+	It("Installs grub for LiveOS image with a fixed boot identifier", func() {
+		grub = bootloader.NewGrub(s, bootloader.WithBootID("0a1b2c3d"))
+		Expect(grub.InstallLive("/target/dir", "/iso/dir", "kernel cmdline")).To(Succeed())
+
+		Expect(vfs.Exists(tfs, "/iso/dir/boot/0a1b2c3d")).To(BeTrue())
+		data, err := tfs.ReadFile("/iso/dir/EFI/BOOT/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("/boot/0a1b2c3d"))
+	})
+	It("Installs grub for network boot", func() {
+		err := grub.InstallNetboot("/target/dir", "/net/dir", "http://10.0.0.1:8080/installer/", "root=live:http://10.0.0.1:8080/installer/LiveOS/squashfs.img")
+		Expect(err).ToNot(HaveOccurred())
+
+		// Kernel and initrd exist
+		Expect(vfs.Exists(tfs, "/net/dir/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/net/dir/boot/opensuse-tumbleweed/6.14.4-1-default/initrd")).To(BeTrue())
+
+		ipxe, err := tfs.ReadFile("/net/dir/boot.ipxe")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(ipxe)).To(HavePrefix("#!ipxe\n"))
+		Expect(string(ipxe)).To(ContainSubstring("kernel http://10.0.0.1:8080/installer/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz initrd=initrd root=live:http://10.0.0.1:8080/installer/LiveOS/squashfs.img\n"))
+		Expect(string(ipxe)).To(ContainSubstring("initrd http://10.0.0.1:8080/installer/boot/opensuse-tumbleweed/6.14.4-1-default/initrd\n"))
+
+		// EFI applications fetch kernel and initrd over http
+		Expect(vfs.Exists(tfs, "/net/dir/EFI/BOOT/grub.efi")).To(BeTrue())
+		grubCfg, err := tfs.ReadFile("/net/dir/EFI/BOOT/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(grubCfg)).To(ContainSubstring("linux (http,10.0.0.1,8080)/installer/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz root=live:"))
+		Expect(string(grubCfg)).To(ContainSubstring("initrd (http,10.0.0.1,8080)/installer/boot/opensuse-tumbleweed/6.14.4-1-default/initrd\n"))
+	})
+	It("Only writes the iPXE script for https network boot", func() {
+		Expect(grub.InstallNetboot("/target/dir", "/net/dir", "https://example.com", "cmdline")).To(Succeed())
+
+		Expect(vfs.Exists(tfs, "/net/dir/boot.ipxe")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/net/dir/EFI")).To(BeFalse())
+	})
+	It("Renders a serial console by default", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "kernel cmdline", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		grubCfg, err := tfs.ReadFile("/target/dir/boot/EFI/ELEMENTAL/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(grubCfg)).To(ContainSubstring("serial --unit=0 --speed=115200\nterminal_input console serial\n"))
+		Expect(string(grubCfg)).To(ContainSubstring("for i in gfxterm serial; do"))
+		Expect(string(grubCfg)).To(ContainSubstring("set default_timeout=10\n"))
+		Expect(string(grubCfg)).NotTo(ContainSubstring("superusers"))
+		Expect(string(grubCfg)).To(ContainSubstring("for entry in ${entries} ${next_boot}; do"))
+	})
+	It("Renders the customized boot menu", func() {
+		timeout := 3
+		grub = bootloader.NewGrub(s, bootloader.WithMenu(&deployment.BootMenu{
+			Timeout:   &timeout,
+			Hidden:    true,
+			Terminals: []string{"serial"},
+			Serial:    &deployment.SerialConsole{Unit: 1, Speed: 9600},
+			Password:  &deployment.MenuPassword{User: "root", Hash: "grub.pbkdf2.sha512.10000.ABCD"},
+			Entries:   []deployment.MenuEntry{{Name: "Firmware setup", Content: "  fwsetup"}},
+		}))
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "kernel cmdline", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := tfs.ReadFile("/target/dir/boot/EFI/ELEMENTAL/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		grubCfg := string(data)
+		Expect(grubCfg).To(ContainSubstring("set superusers=\"root\"\npassword_pbkdf2 root grub.pbkdf2.sha512.10000.ABCD\n"))
+		Expect(grubCfg).To(ContainSubstring("serial --unit=1 --speed=9600\nterminal_input console serial\n"))
+		Expect(grubCfg).To(ContainSubstring("for i in serial; do"))
+		Expect(grubCfg).To(ContainSubstring("set default_timeout=3\n"))
+		Expect(grubCfg).To(ContainSubstring("set timeout_style=hidden\n"))
+		Expect(grubCfg).To(ContainSubstring(`menuentry "${display_name}" --id "${entry}" --unrestricted "${linux}"`))
+		Expect(grubCfg).To(ContainSubstring("menuentry \"Firmware setup\" --unrestricted {\n  fwsetup\n}\n"))
+
+		Expect(grub.InstallLive("/target/dir", "/iso/dir", "kernel cmdline")).To(Succeed())
+		data, err = tfs.ReadFile("/iso/dir/boot/grub2/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		grubCfg = string(data)
+		Expect(grubCfg).To(ContainSubstring("set timeout=3\n"))
+		Expect(grubCfg).To(ContainSubstring(`--id "installer" --unrestricted {`))
+		Expect(grubCfg).To(ContainSubstring("menuentry \"Firmware setup\" --unrestricted {\n  fwsetup\n}\n"))
+	})
+	It("Escapes the names of the custom boot menu entries", func() {
+		grub = bootloader.NewGrub(s, bootloader.WithMenu(&deployment.BootMenu{
+			Entries: []deployment.MenuEntry{{Name: `Setup "$firmware" \ UEFI`, Content: "  fwsetup"}},
+		}))
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "kernel cmdline", "")).To(Succeed())
+
+		data, err := tfs.ReadFile("/target/dir/boot/EFI/ELEMENTAL/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`menuentry "Setup \"\$firmware\" \\ UEFI" {`))
+	})
+	It("Installs grub for legacy BIOS boot", func() {
+		grub = bootloader.NewGrub(s, bootloader.WithBIOS("/dev/sda"))
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "kernel cmdline", "")).To(Succeed())
+
+		Expect(runner.IncludesCmds([][]string{
+			{"rsync", "--archive", "--recursive", "--no-links", "--exclude=/i386-pc"},
+			{
+				"grub2-install", "--target=i386-pc", "--boot-directory=/target/dir/boot",
+				"--directory=/target/dir/usr/share/grub2/i386-pc", "/dev/sda",
+			},
+		})).To(Succeed())
+
+		grubCfg, err := tfs.ReadFile("/target/dir/boot/grub2/grub.cfg")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(grubCfg)).To(ContainSubstring("search --no-floppy --label --set=root EFI"))
+	})
+	It("Refreshes the BIOS grub config without a BIOS device", func() {
+		grub = bootloader.NewGrub(s, bootloader.WithBIOS(""))
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "kernel cmdline", "")).To(Succeed())
+
+		Expect(runner.IncludesCmds([][]string{{"grub2-install"}})).NotTo(Succeed())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/grub2/grub.cfg")).To(BeTrue())
+	})
+	It("Installs an El Torito image for legacy BIOS boot of live media", func() {
+		grub = bootloader.NewGrub(s, bootloader.WithBIOS(""))
+		Expect(grub.InstallLive("/target/dir", "/iso/dir", "kernel cmdline")).NotTo(Succeed())
+
+		Expect(vfs.MkdirAll(tfs, "/target/dir/usr/share/grub2/i386-pc", vfs.DirPerm)).To(Succeed())
+		Expect(grub.InstallLive("/target/dir", "/iso/dir", "kernel cmdline")).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{
+			"grub2-mkimage", "--format=i386-pc-eltorito", "--output=/iso/dir/boot/grub2/i386-pc/eltorito.img",
+			"--prefix=/boot/grub2", "--directory=/target/dir/usr/share/grub2/i386-pc", "biosdisk", "iso9660",
+		}})).To(Succeed())
+	})
+	It("Fails to install legacy BIOS boot on non x86_64 platforms", func() {
+		var err error
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(tfs), sys.WithPlatform("linux/arm64"),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.MkdirAll(tfs, "/target/dir/usr/share/efi/aarch64", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/target/dir/usr/share/efi/aarch64/shim.efi", []byte("shim.efi"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile("/target/dir/usr/share/efi/aarch64/MokManager.efi", []byte("MokManager.efi"), vfs.FilePerm)).To(Succeed())
+		Expect(tfs.WriteFile("/target/dir/usr/share/efi/aarch64/grub.efi", []byte("grub.efi"), vfs.FilePerm)).To(Succeed())
+
+		grub = bootloader.NewGrub(s, bootloader.WithBIOS("/dev/sda"))
+		err = grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "kernel cmdline", "")
+		Expect(errors.Is(err, errors.ErrUnsupported)).To(BeTrue())
+	})
 	It("Fails with an error if initrd is not found", func() {
 		// Remove initrd
 		err := tfs.Remove("/target/dir/usr/lib/modules/6.14.4-1-default/initrd")
@@ -187,9 +324,51 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(strings.SplitSeq(string(entry2), "\n")).To(ContainElement("cmdline=snapshot2"))
 
 		// entries should read "active 2 1 recovery"
-		entries, err := tfs.ReadFile("/target/dir/boot/grubenv")
+		Expect(readGrubEnv("/target/dir/boot/grubenv")).To(HaveKeyWithValue("entries", "active 2 1 recovery"))
+	})
+	It("Lists rewritten entries only once", func() {
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "slotA", "recovery cmdline")).To(Succeed())
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "2", "slotB", "recovery cmdline")).To(Succeed())
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "slotA", "recovery cmdline")).To(Succeed())
+
+		Expect(readGrubEnv("/target/dir/boot/grubenv")).To(HaveKeyWithValue("entries", "active 1 2 recovery"))
+	})
+	It("Edits the kernel command line of boot entries", func() {
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1 quiet", "recovery cmdline")).To(Succeed())
+
+		entries, err := grub.Entries("/target/dir/boot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(ConsistOf("active", "1", "recovery"))
+
+		cmdline, err := grub.Cmdline("/target/dir/boot", "1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmdline).To(Equal("snapshot1 quiet"))
+
+		Expect(grub.SetCmdline("/target/dir/boot", "1", "snapshot1")).To(Succeed())
+		cmdline, err = grub.Cmdline("/target/dir/boot", "1")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(string(entries)).To(Equal("entries=active 2 1 recovery"))
+		Expect(cmdline).To(Equal("snapshot1"))
+
+		_, err = grub.Cmdline("/target/dir/boot", "5")
+		Expect(err).To(MatchError("boot entry '5' not found"))
+		Expect(grub.SetCmdline("/target/dir/boot", "5", "snapshot5")).NotTo(Succeed())
+	})
+	It("Sets a kernel command line for next boot only", func() {
+		Expect(grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "recovery cmdline")).To(Succeed())
+
+		Expect(grub.SetNextBootCmdline("/target/dir/boot", "active", "snapshot1 debug")).To(Succeed())
+
+		nextEntry, err := tfs.ReadFile("/target/dir/boot/loader/entries/next")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.SplitSeq(string(nextEntry), "\n")).To(ContainElement("cmdline=snapshot1 debug"))
+		Expect(strings.SplitSeq(string(nextEntry), "\n")).To(ContainElement("display_name=openSUSE Tumbleweed (next boot)"))
+
+		Expect(readGrubEnv("/target/dir/boot/grubenv")).To(HaveKeyWithValue("next_entry", "next"))
+
+		// the active entry is unchanged
+		cmdline, err := grub.Cmdline("/target/dir/boot", "active")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmdline).To(Equal("snapshot1"))
 	})
 	It("Prunes old snapshots", func() {
 		// "Install" older (6.6.99) kernel
@@ -231,17 +410,13 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(strings.SplitSeq(string(recoveryEntry), "\n")).To(ContainElement("cmdline=recoverycmd"))
 
 		// entries should read "active 2 1 recovery"
-		entries, err := tfs.ReadFile("/target/dir/boot/grubenv")
-		Expect(err).ToNot(HaveOccurred())
-		Expect(string(entries)).To(Equal("entries=active 2 1 recovery"))
+		Expect(readGrubEnv("/target/dir/boot/grubenv")).To(HaveKeyWithValue("entries", "active 2 1 recovery"))
 
 		// Prune snapshot 1 (keep 2)
 		err = grub.Prune("/target/dir", "/target/dir/boot", []int{2})
 		Expect(err).ToNot(HaveOccurred())
 
-		entries, err = tfs.ReadFile("/target/dir/boot/grubenv")
-		Expect(err).ToNot(HaveOccurred())
-		Expect(entries).To(Equal([]byte("entries=active 2 recovery")))
+		Expect(readGrubEnv("/target/dir/boot/grubenv")).To(HaveKeyWithValue("entries", "active 2 recovery"))
 
 		// Old boot entries and kernel/initrd are removed
 		Expect(vfs.Exists(tfs, "/target/dir/boot/loader/entries/1")).To(BeFalse())
@@ -93,6 +93,17 @@ func CreateQuotaGroup(s *sys.System, path, qGroup string) error {
 	return nil
 }
 
+// SetQuotaLimit limits the referenced space of the subvolume at the given path to the given size in MiB.
+// It requires quota to be enabled in the btrfs filesystem.
+func SetQuotaLimit(s *sys.System, path string, size uint) error {
+	s.Logger().Debug("Setting quota limit of %dMiB to subvolume: %s", size, path)
+	cmdOut, err := s.Runner().Run("btrfs", "qgroup", "limit", fmt.Sprintf("%dM", size), path)
+	if err != nil {
+		return fmt.Errorf("setting quota limit for %s: %s: %w", path, string(cmdOut), err)
+	}
+	return nil
+}
+
 // SetBtrfsPartition configures toplevel subvolume, enables quota sets the quota group 1/0,
 // and defines the toplevel subvolume as the default subvolume. Path is the mountpoint of the btrfs filesystem.
 func SetBtrfsPartition(s *sys.System, path string) error {
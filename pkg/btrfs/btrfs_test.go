@@ -81,6 +81,12 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 			{"btrfs", "qgroup", "create", "1/0", "/path/to/subvolume"},
 		})).To(Succeed())
 	})
+	It("sets a quota limit", func() {
+		Expect(btrfs.SetQuotaLimit(s, "/path/to/subvolume", 2048)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"btrfs", "qgroup", "limit", "2048M", "/path/to/subvolume"},
+		})).To(Succeed())
+	})
 	It("sets default subvolume", func() {
 		Expect(btrfs.SetDefaultSubvolume(s, "/path/to/subvolume")).To(Succeed())
 		Expect(runner.IncludesCmds([][]string{
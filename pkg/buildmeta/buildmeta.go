@@ -0,0 +1,69 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buildmeta provides helpers shared by the documents describing a build, such as
+// software bills of materials and provenance statements.
+package buildmeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/sys/env"
+)
+
+// Now returns the current time truncated to seconds in UTC. It is pinned to SOURCE_DATE_EPOCH
+// if set, so reproducible builds produce identical documents.
+func Now() (time.Time, error) {
+	t, ok, err := env.LookupSourceDateEpoch()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		t = time.Now()
+	}
+	return t.UTC().Truncate(time.Second), nil
+}
+
+// MarshalJSON encodes the given document as indented JSON without escaping HTML characters,
+// which are common in package URLs and URIs
+func MarshalJSON(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(doc)
+	return buf.Bytes(), err
+}
+
+// AppendUnique appends the given items to the list skipping those whose key is already listed
+func AppendUnique[T any, K comparable](list []T, key func(T) K, items ...T) []T {
+	listed := make(map[K]bool, len(list)+len(items))
+	for _, item := range list {
+		listed[key(item)] = true
+	}
+	for _, item := range items {
+		k := key(item)
+		if listed[k] {
+			continue
+		}
+		listed[k] = true
+		list = append(list, item)
+	}
+	return list
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildmeta_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/buildmeta"
+)
+
+func TestBuildMetaSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Build metadata test suite")
+}
+
+var _ = Describe("Build metadata", Label("buildmeta"), func() {
+	It("pins the current time to SOURCE_DATE_EPOCH", func() {
+		GinkgoT().Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		Expect(buildmeta.Now()).To(Equal(time.Unix(1700000000, 0).UTC()))
+
+		GinkgoT().Setenv("SOURCE_DATE_EPOCH", "yesterday")
+		_, err := buildmeta.Now()
+		Expect(err).To(MatchError(ContainSubstring("invalid SOURCE_DATE_EPOCH value")))
+	})
+	It("encodes JSON without escaping HTML characters", func() {
+		data, err := buildmeta.MarshalJSON(map[string]string{"purl": "pkg:rpm/bash@5.2?arch=x86_64&epoch=1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("{\n  \"purl\": \"pkg:rpm/bash@5.2?arch=x86_64&epoch=1\"\n}\n"))
+	})
+	It("appends items not listed yet", func() {
+		key := func(s string) byte { return s[0] }
+		list := buildmeta.AppendUnique([]string{"apple"}, key, "banana", "avocado", "blueberry", "cherry")
+		Expect(list).To(Equal([]string{"apple", "banana", "cherry"}))
+	})
+})
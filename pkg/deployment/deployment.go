@@ -26,7 +26,9 @@ import (
 	"runtime"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"go.yaml.in/yaml/v3"
@@ -51,6 +53,8 @@ const (
 	EfiMnt       = "/boot"
 	EfiSize  MiB = 1024
 
+	BiosSize MiB = 1
+
 	RecoveryLabel = "RECOVERY"
 	RecoverySize  = 0
 
@@ -58,6 +62,7 @@ const (
 	ResetMark    = "elm.reset"
 
 	SystemLabel          = "SYSTEM"
+	SystemBLabel         = "SYSTEM_B"
 	SystemMnt            = "/"
 	AllAvailableSize MiB = 0
 
@@ -77,6 +82,9 @@ const (
 	Recovery
 	Generic
 	Config
+	LVM
+	SystemB
+	BIOS
 )
 
 type FileSystem int
@@ -156,6 +164,12 @@ func ParseRole(function string) (PartRole, error) {
 		return Generic, nil
 	case "config":
 		return Config, nil
+	case "lvm":
+		return LVM, nil
+	case "system-b":
+		return SystemB, nil
+	case "bios":
+		return BIOS, nil
 	default:
 		return PartRole(0), fmt.Errorf("unknown partition function: %s", function)
 	}
@@ -173,6 +187,12 @@ func (p PartRole) String() string {
 		return "generic"
 	case Config:
 		return "config"
+	case LVM:
+		return "lvm"
+	case SystemB:
+		return "system-b"
+	case BIOS:
+		return "bios"
 	default:
 		return Unknown
 	}
@@ -198,36 +218,129 @@ func (p *PartRole) UnmarshalYAML(data *yaml.Node) (err error) {
 }
 
 type RWVolume struct {
-	Path          string   `yaml:"path" validate:"required,abspath"`
-	Snapshotted   bool     `yaml:"snapshotted,omitempty"`
-	NoCopyOnWrite bool     `yaml:"noCopyOnWrite,omitempty"`
-	MountOpts     []string `yaml:"mountOpts,omitempty"`
+	Path          string      `yaml:"path" validate:"required,abspath"`
+	Snapshotted   bool        `yaml:"snapshotted,omitempty"`
+	NoCopyOnWrite bool        `yaml:"noCopyOnWrite,omitempty"`
+	MountOpts     []string    `yaml:"mountOpts,omitempty"`
+	Quota         MiB         `yaml:"quota,omitempty"`
+	MergeRules    []MergeRule `yaml:"mergeRules,omitempty" validate:"dive"`
+}
+
+// MergePolicy defines how a conflict is solved when merging a snapshotted volume on upgrades.
+// A conflict happens when a file modified by the user was also changed by the new OS image.
+type MergePolicy string
+
+const (
+	// KeepUser keeps the user version of the file, this is the default
+	KeepUser MergePolicy = "keep-user"
+	// TakeImage keeps the version of the file provided by the new OS image
+	TakeImage MergePolicy = "take-image"
+	// KeepBoth keeps the user version and stores the new OS image one next to it
+	// with the '.elemental-new' suffix
+	KeepBoth MergePolicy = "keep-both"
+)
+
+// MergeRule sets the merge policy for the paths matching the given pattern. The pattern is
+// an absolute path glob, matching directories also applies to all their content.
+type MergeRule struct {
+	Path   string      `yaml:"path" validate:"required,abspath"`
+	Policy MergePolicy `yaml:"policy" validate:"oneof=keep-user take-image keep-both"`
+}
+
+// MergePolicy returns the policy of the first merge rule matching the given path. Defaults
+// to KeepUser if no rule matches.
+func (r RWVolume) MergePolicy(path string) MergePolicy {
+	for _, rule := range r.MergeRules {
+		pattern := filepath.Clean(rule.Path)
+		if ok, _ := filepath.Match(pattern, path); ok || strings.HasPrefix(path, pattern+"/") {
+			return rule.Policy
+		}
+	}
+	return KeepUser
 }
 
 type RWVolumes []RWVolume
 
-type Partition struct {
+type LogicalVolume struct {
+	Name       string     `yaml:"name"`
 	Label      string     `yaml:"label,omitempty"`
 	FileSystem FileSystem `yaml:"fileSystem,omitempty"`
 	Size       MiB        `yaml:"size,omitempty"`
-	Role       PartRole   `yaml:"role"`
-	MountPoint string     `yaml:"mountPoint,omitempty" validate:"recovery_mountpoint"`
+	MountPoint string     `yaml:"mountPoint,omitempty"`
 	MountOpts  []string   `yaml:"mountOpts,omitempty"`
-	RWVolumes  RWVolumes  `yaml:"rwVolumes,omitempty" validate:"excluded_unless=FileSystem 1,dive"` // FileSystem 1 = btrfs
-	UUID       string     `yaml:"uuid,omitempty"`
-	Hidden     bool       `yaml:"hidden,omitempty"`
+}
+
+type VolumeGroup struct {
+	Name           string           `yaml:"name"`
+	LogicalVolumes []*LogicalVolume `yaml:"logicalVolumes"`
+}
+
+type Partition struct {
+	Label       string       `yaml:"label,omitempty"`
+	FileSystem  FileSystem   `yaml:"fileSystem,omitempty"`
+	Size        MiB          `yaml:"size,omitempty"`
+	Role        PartRole     `yaml:"role"`
+	MountPoint  string       `yaml:"mountPoint,omitempty" validate:"recovery_mountpoint"`
+	MountOpts   []string     `yaml:"mountOpts,omitempty"`
+	RWVolumes   RWVolumes    `yaml:"rwVolumes,omitempty" validate:"excluded_unless=FileSystem 1,dive"` // FileSystem 1 = btrfs
+	VolumeGroup *VolumeGroup `yaml:"volumeGroup,omitempty"`
+	UUID        string       `yaml:"uuid,omitempty"`
+	Hidden      bool         `yaml:"hidden,omitempty"`
 }
 
 type Partitions []*Partition
 
+// WipePolicy defines how a disk is cleaned up before partitioning it
+type WipePolicy struct {
+	// Signatures wipes the filesystem, LVM and RAID signatures of the disk and its partitions
+	Signatures bool `yaml:"signatures,omitempty"`
+	// Discard discards all the disk blocks, useful to TRIM SSDs and thin provisioned devices
+	Discard bool `yaml:"discard,omitempty"`
+	// Erase overwrites the disk content, either 'zero' to fill it with zeros or 'secure' for a secure discard
+	Erase string `yaml:"erase,omitempty" validate:"omitempty,oneof=zero secure"`
+}
+
 type Disk struct {
-	Device     string     `yaml:"target,omitempty" validate:"disk_device_required,disk_device_exists"`
-	Partitions Partitions `yaml:"partitions" validate:"required,min=1,dive"`
+	Device     string      `yaml:"target,omitempty" validate:"disk_device_required,disk_device_exists"`
+	Partitions Partitions  `yaml:"partitions" validate:"required,min=1,dive"`
+	Wipe       *WipePolicy `yaml:"wipe,omitempty"`
 }
 
 type BootConfig struct {
-	Bootloader    string `yaml:"name"`
-	KernelCmdline string `yaml:"kernelCmdline"`
+	Bootloader    string    `yaml:"name"`
+	KernelCmdline string    `yaml:"kernelCmdline"`
+	Menu          *BootMenu `yaml:"menu,omitempty"`
+}
+
+// BootMenu customizes the boot menu of the installed system and of the live media
+type BootMenu struct {
+	// Timeout in seconds before booting the default entry, the bootloader default is used if unset
+	Timeout *int `yaml:"timeout,omitempty" validate:"omitempty,gte=0"`
+	// Hidden only shows the menu if a key is pressed before the timeout expires
+	Hidden bool `yaml:"hidden,omitempty"`
+	// Terminals used for input and output. Defaults to graphical and serial terminals.
+	Terminals []string       `yaml:"terminals,omitempty" validate:"dive,oneof=console gfxterm serial"`
+	Serial    *SerialConsole `yaml:"serial,omitempty"`
+	// Password protects the edition of boot entries and the bootloader shell
+	Password *MenuPassword `yaml:"password,omitempty"`
+	Entries  []MenuEntry   `yaml:"entries,omitempty" validate:"dive"`
+}
+
+type SerialConsole struct {
+	Unit  int `yaml:"unit" validate:"gte=0"`
+	Speed int `yaml:"speed" validate:"omitempty,gt=0"`
+}
+
+type MenuPassword struct {
+	User string `yaml:"user" validate:"required,alphanum"`
+	// Hash as generated by grub2-mkpasswd-pbkdf2
+	Hash string `yaml:"hash" validate:"required,startswith=grub.pbkdf2."`
+}
+
+// MenuEntry is an additional boot menu entry, Content is included verbatim in the entry body
+type MenuEntry struct {
+	Name    string `yaml:"name" validate:"required"`
+	Content string `yaml:"content" validate:"required"`
 }
 
 type FirmwareConfig struct {
@@ -239,7 +352,103 @@ type SecurityConfig struct {
 }
 
 type SnapshotterConfig struct {
-	Name string `yaml:"name"`
+	Name      string          `yaml:"name"`
+	Retention RetentionPolicy `yaml:"retention,omitempty"`
+}
+
+// RetentionPolicy defines which snapshots are kept on each snapper configuration, including
+// the ones of snapshotted RW volumes. Active, default and pinned snapshots are always kept.
+type RetentionPolicy struct {
+	// MaxSnapshots is the maximum number of snapshots to keep, older ones are removed first
+	MaxSnapshots int `yaml:"maxSnapshots,omitempty"`
+	// MaxAge removes snapshots older than the given duration
+	MaxAge Duration `yaml:"maxAge,omitempty"`
+	// KeepPerVersion keeps at least the latest snapshot of each OS version
+	KeepPerVersion bool `yaml:"keepPerVersion,omitempty"`
+	// MaxUsage removes the oldest snapshots until the space they use is below the given size
+	MaxUsage MiB `yaml:"maxUsage,omitempty"`
+}
+
+// Duration is a time span parsed as a Go duration, e.g. '36h', or as a number of days, e.g. '30d'
+type Duration time.Duration
+
+var (
+	_ yaml.Marshaler   = Duration(0)
+	_ yaml.Unmarshaler = (*Duration)(nil)
+)
+
+// ParseDuration parses the given Go duration or number of days
+func ParseDuration(str string) (Duration, error) {
+	if days, ok := strings.CutSuffix(str, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days '%s': %w", str, err)
+		}
+		return Duration(time.Duration(n) * 24 * time.Hour), nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, err
+	}
+	return Duration(d), nil
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(data *yaml.Node) (err error) {
+	var str string
+	if err = data.Decode(&str); err != nil {
+		return err
+	}
+	*d, err = ParseDuration(str)
+	return err
+}
+
+// HookPhase is the upgrade phase at which a hook is executed
+type HookPhase string
+
+const (
+	// PreSync runs before syncing the OS image into the transaction
+	PreSync HookPhase = "pre-sync"
+	// PostSync runs once the OS image is synced into the transaction
+	PostSync HookPhase = "post-sync"
+	// PostMerge runs after merging snapshotted volumes and before locking the transaction
+	PostMerge HookPhase = "post-merge"
+	// PreBootloader runs before installing the bootloader
+	PreBootloader HookPhase = "pre-bootloader"
+	// PostCommit runs once the transaction is committed
+	PostCommit HookPhase = "post-commit"
+	// OnRollback runs before rolling back a failed transaction
+	OnRollback HookPhase = "on-rollback"
+)
+
+// Hook is a script executed at the given upgrade phase. Hooks are executed on the host
+// unless Chroot is set, in that case they are executed within the transaction root. There
+// is no populated transaction root to chroot into in the 'pre-sync' and 'on-rollback' phases.
+type Hook struct {
+	Phase   HookPhase `yaml:"phase" validate:"oneof=pre-sync post-sync post-merge pre-bootloader post-commit on-rollback"`
+	Script  string    `yaml:"script" validate:"required,abspath"`
+	Chroot  bool      `yaml:"chroot,omitempty" validate:"hook_chroot"`
+	Timeout Duration  `yaml:"timeout,omitempty"`
+}
+
+// PreflightChecks defines the host requirements verified before installing. The target disks are always
+// checked to fit the configured partitions and other disks are always checked for partition labels
+// conflicting with the deployment ones.
+type PreflightChecks struct {
+	// MinMemory is the minimum amount of RAM in MiB
+	MinMemory MiB `yaml:"minMemory,omitempty"`
+	// UEFI requires the host to be booted in UEFI mode
+	UEFI bool `yaml:"uefi,omitempty"`
+	// SecureBoot requires the Secure Boot state of the host to be 'enabled' or 'disabled'
+	SecureBoot string `yaml:"secureBoot,omitempty" validate:"omitempty,oneof=enabled disabled"`
+	// Arch overrides the architecture detected from the OS image, the host CPU is required to match it
+	Arch string `yaml:"arch,omitempty"`
+	// LabelConflicts sets whether partitions of other disks using the same labels of the deployment
+	// partitions only 'warn' or 'abort' the installation, defaults to 'warn'
+	LabelConflicts string `yaml:"labelConflicts,omitempty" validate:"omitempty,oneof=warn abort"`
 }
 
 type LiveInstaller struct {
@@ -250,14 +459,16 @@ type LiveInstaller struct {
 
 type Deployment struct {
 	SourceOS    *ImageSource       `yaml:"sourceOS" validate:"required,not_empty_source"`
-	Disks       []*Disk            `yaml:"disks" validate:"required,min=1,dive,system_partition,multiple_system_partitions,efi_partition,multiple_efi_partitions,recovery_partition,last_partition_size,rw_volumes"`
+	Disks       []*Disk            `yaml:"disks" validate:"required,min=1,dive,system_partition,multiple_system_partitions,efi_partition,multiple_efi_partitions,multiple_bios_partitions,recovery_partition,last_partition_size,rw_volume_quotas,rw_volumes,lvm_volumes,ab_partitions"`
 	Firmware    *FirmwareConfig    `yaml:"firmware"`
 	BootConfig  *BootConfig        `yaml:"bootloader"`
 	Security    *SecurityConfig    `yaml:"security" validate:"required"`
 	Snapshotter *SnapshotterConfig `yaml:"snapshotter"`
 	OverlayTree *ImageSource       `yaml:"overlayTree,omitempty"`
 	CfgScript   string             `yaml:"configScript,omitempty"`
+	Hooks       []Hook             `yaml:"hooks,omitempty" validate:"dive"`
 	Installer   LiveInstaller      `yaml:"installer,omitempty"`
+	Checks      *PreflightChecks   `yaml:"checks,omitempty"`
 }
 
 var validate = validator.New()
@@ -268,11 +479,16 @@ func init() {
 	_ = validate.RegisterValidation("multiple_system_partitions", validateMultipleSystemPartitions)
 	_ = validate.RegisterValidation("efi_partition", validateEFIPartition)
 	_ = validate.RegisterValidation("multiple_efi_partitions", validateMultipleEFIPartitions)
+	_ = validate.RegisterValidation("multiple_bios_partitions", validateMultipleBIOSPartitions)
 	_ = validate.RegisterValidation("recovery_partition", validateRecoveryPartition)
 	_ = validate.RegisterValidation("last_partition_size", validateLastPartitionSize)
+	_ = validate.RegisterValidation("rw_volume_quotas", validateRWVolumeQuotas)
 	_ = validate.RegisterValidation("rw_volumes", validateRWVolumes)
+	_ = validate.RegisterValidation("lvm_volumes", validateLVMVolumes)
+	_ = validate.RegisterValidation("ab_partitions", validateABPartitions)
 	_ = validate.RegisterValidation("crypto_policy", validateCryptoPolicy)
 	_ = validate.RegisterValidation("abspath", validateAbsPath)
+	_ = validate.RegisterValidation("hook_chroot", validateHookChroot)
 	_ = validate.RegisterValidationCtx("disk_device_exists", validateDiskDeviceExists)
 	_ = validate.RegisterValidationCtx("disk_device_required", validateDiskDeviceRequired)
 	_ = validate.RegisterValidationCtx("recovery_mountpoint", validateRecoveryMountPoint)
@@ -385,6 +601,29 @@ func validateMultipleEFIPartitions(fl validator.FieldLevel) bool {
 	return count <= 1
 }
 
+func validateMultipleBIOSPartitions(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	var count int
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == BIOS {
+				count++
+			}
+		}
+	}
+	return count <= 1
+}
+
 func validateRecoveryPartition(fl validator.FieldLevel) bool {
 	disks, ok := fl.Field().Interface().([]*Disk)
 	if !ok {
@@ -479,6 +718,42 @@ func validateRWVolumes(fl validator.FieldLevel) bool {
 	return true
 }
 
+func validateRWVolumeQuotas(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	return checkRWVolumeQuotas(disks) == nil
+}
+
+func validateLVMVolumes(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	return checkLVMVolumes(disks) == nil
+}
+
+func validateABPartitions(fl validator.FieldLevel) bool {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		disk, ok := fl.Field().Interface().(Disk)
+		if !ok {
+			return false
+		}
+		disks = []*Disk{&disk}
+	}
+	return checkABPartitions(disks) == nil
+}
+
 func validateCryptoPolicy(fl validator.FieldLevel) bool {
 	policy, ok := fl.Field().Interface().(crypto.Policy)
 	if !ok {
@@ -491,6 +766,14 @@ func validateAbsPath(fl validator.FieldLevel) bool {
 	return filepath.IsAbs(fl.Field().String())
 }
 
+func validateHookChroot(fl validator.FieldLevel) bool {
+	hook, ok := fl.Parent().Interface().(Hook)
+	if !ok {
+		return false
+	}
+	return !hook.Chroot || (hook.Phase != PreSync && hook.Phase != OnRollback)
+}
+
 func validateDiskDeviceExists(ctx context.Context, fl validator.FieldLevel) bool {
 	if skip, ok := ctx.Value(contextKeySkipDiskDeviceExists).(bool); ok && skip {
 		return true
@@ -553,6 +836,18 @@ func (p Partitions) GetSnapshottedVolumes() RWVolumes {
 	return volumes
 }
 
+// GetVolumeGroups returns a list of the LVM volume groups defined in the
+// given partitions list.
+func (p Partitions) GetVolumeGroups() []*VolumeGroup {
+	var groups []*VolumeGroup
+	for _, part := range p {
+		if part.Role == LVM && part.VolumeGroup != nil {
+			groups = append(groups, part.VolumeGroup)
+		}
+	}
+	return groups
+}
+
 type SanitizeDeployment func(*sys.System, *Deployment) error
 
 // name returns the sanitizer method name using reflection. This can
@@ -595,6 +890,22 @@ func (d Deployment) GetSystemPartition() *Partition {
 	return nil
 }
 
+// GetSystemBPartition returns the alternate system partition of an A/B setup.
+// returns nil if not found.
+func (d Deployment) GetSystemBPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == SystemB {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
 // GetSystemLabel returns the label of the system partition, returns
 // empty string if no label or system partition defined
 func (d Deployment) GetSystemLabel() string {
@@ -653,6 +964,38 @@ func (d Deployment) GetRecoveryPartition() *Partition {
 	return nil
 }
 
+// GetBIOSPartition gets the data of the BIOS boot partition.
+// returns nil if not found
+func (d Deployment) GetBIOSPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == BIOS {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// GetBIOSDisk gets the disk data including the BIOS boot partition, legacy BIOS boot
+// is only enabled for deployments including it. Returns nil if not found.
+func (d Deployment) GetBIOSDisk() *Disk {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == BIOS {
+				return disk
+			}
+		}
+	}
+	return nil
+}
+
 // GetSystemDisk gets the disk data including the system partition.
 // returns nil if not found
 func (d Deployment) GetEfiDisk() *Disk {
@@ -669,8 +1012,12 @@ func (d Deployment) GetEfiDisk() *Disk {
 	return nil
 }
 
-// BaseKernelCmdline returns the base kernel command line for the current deployment
+// BaseKernelCmdline returns the base kernel command line for the current deployment.
+// A/B deployments do not set the root device, as it depends on the slot of each transaction.
 func (d Deployment) BaseKernelCmdline() string {
+	if d.GetSystemBPartition() != nil {
+		return ""
+	}
 	return fmt.Sprintf("root=LABEL=%s", d.GetSystemLabel())
 }
 
@@ -704,6 +1051,18 @@ func (d *Deployment) setDefaults(s *sys.System) {
 					part.Label = SystemLabel
 				}
 			}
+			if part.Role == SystemB {
+				if part.MountPoint != "" {
+					s.Logger().Warn("the system-b partition is only mounted as root when booted")
+					part.MountPoint = ""
+				}
+				if part.Label == "" {
+					part.Label = SystemBLabel
+				}
+				if sysPart := d.GetSystemPartition(); sysPart != nil && part.FileSystem.String() == Unknown {
+					part.FileSystem = sysPart.FileSystem
+				}
+			}
 			if part.Role == EFI {
 				if part.FileSystem != VFat {
 					s.Logger().Warn("filesystem types different to vfat are not supported for the efi partition")
@@ -742,6 +1101,38 @@ func (d *Deployment) setDefaults(s *sys.System) {
 					part.Label = RecoveryLabel
 				}
 			}
+			if part.Role == BIOS {
+				// the BIOS boot partition holds the grub core image, it is not formatted nor mounted
+				if part.FileSystem.String() != Unknown || part.MountPoint != "" {
+					s.Logger().Warn("bios partitions are neither formatted nor mounted")
+					part.FileSystem = FileSystem(0)
+					part.MountPoint = ""
+				}
+				if part.Size < BiosSize {
+					s.Logger().Info("bios partition size set to %dMiB", BiosSize)
+					part.Size = BiosSize
+				}
+				if len(part.RWVolumes) > 0 {
+					s.Logger().Warn("bios partition does not support volumes")
+					s.Logger().Info("cleared read-write volumes for bios")
+					part.RWVolumes = nil
+				}
+				continue
+			}
+			if part.Role == LVM {
+				if part.FileSystem.String() != Unknown {
+					s.Logger().Warn("lvm partitions are not formatted, filesystems are defined per logical volume")
+					part.FileSystem = FileSystem(0)
+				}
+				if part.VolumeGroup != nil {
+					for _, lv := range part.VolumeGroup.LogicalVolumes {
+						if lv != nil && lv.FileSystem.String() == Unknown {
+							lv.FileSystem = Ext4
+						}
+					}
+				}
+				continue
+			}
 			if part.FileSystem.String() == Unknown {
 				part.FileSystem = Btrfs
 			}
@@ -786,16 +1177,30 @@ func (d *Deployment) formatValidationErrors(s *sys.System, errs validator.Valida
 			return fmt.Errorf("no 'efi' partition defined")
 		case "multiple_efi_partitions":
 			return fmt.Errorf("multiple 'efi' partitions defined, there must be only one")
+		case "multiple_bios_partitions":
+			return fmt.Errorf("multiple 'bios' partitions defined, there can be only one")
 		case "recovery_partition":
 			return fmt.Errorf("multiple 'recovery' partitions defined, there can be only one")
 		case "recovery_mountpoint":
 			return fmt.Errorf("custom mountpoints for the recovery partition are not supported")
 		case "last_partition_size":
 			return fmt.Errorf("only last partition can be defined to be as big as available size in disk")
+		case "rw_volume_quotas":
+			return checkRWVolumeQuotas(d.Disks)
 		case "rw_volumes":
 			return d.checkRWVolumes()
+		case "lvm_volumes":
+			return checkLVMVolumes(d.Disks)
+		case "ab_partitions":
+			return checkABPartitions(d.Disks)
 		case "crypto_policy":
 			return fmt.Errorf("invalid crypto policy: %s", d.Security.CryptoPolicy)
+		case "hook_chroot":
+			for _, hook := range d.Hooks {
+				if hook.Chroot && (hook.Phase == PreSync || hook.Phase == OnRollback) {
+					return fmt.Errorf("hook '%s' can't run chrooted in the '%s' phase", hook.Script, hook.Phase)
+				}
+			}
 		case "not_empty_source":
 			return fmt.Errorf("no OS image defined in deployment")
 		case "disk_device_required":
@@ -845,11 +1250,146 @@ func (d *Deployment) checkRWVolumes() error {
 	return nil
 }
 
+// checkRWVolumeQuotas verifies quota limits are only set for RW volumes of btrfs partitions, as
+// they are applied as btrfs quota groups, and that they do not exceed the size of the partition.
+func checkRWVolumeQuotas(disks []*Disk) error {
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil {
+				continue
+			}
+			for _, rwVol := range part.RWVolumes {
+				if rwVol.Quota == 0 {
+					continue
+				}
+				if part.FileSystem != Btrfs {
+					return fmt.Errorf("quota of rw volume '%s' requires a partition formatted with btrfs", rwVol.Path)
+				}
+				if part.Size > 0 && rwVol.Quota > part.Size {
+					return fmt.Errorf("quota of rw volume '%s' exceeds the size of its partition", rwVol.Path)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkLVMVolumes verifies LVM volume groups are only defined for 'lvm' partitions and
+// the logical volumes within them are consistent.
+func checkLVMVolumes(disks []*Disk) error {
+	vgNames := map[string]bool{}
+	mountPoints := map[string]bool{}
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil {
+				continue
+			}
+			if part.Role != LVM {
+				if part.VolumeGroup != nil {
+					return fmt.Errorf("volume groups are only supported in 'lvm' partitions")
+				}
+				continue
+			}
+			vg := part.VolumeGroup
+			if vg == nil || vg.Name == "" {
+				return fmt.Errorf("'lvm' partitions require a named volume group")
+			}
+			if part.MountPoint != "" || len(part.RWVolumes) > 0 {
+				return fmt.Errorf("'lvm' partitions can't be mounted, define logical volumes instead")
+			}
+			if vgNames[vg.Name] {
+				return fmt.Errorf("volume group names must be unique. Duplicated '%s'", vg.Name)
+			}
+			vgNames[vg.Name] = true
+			if len(vg.LogicalVolumes) == 0 {
+				return fmt.Errorf("no logical volumes defined for volume group '%s'", vg.Name)
+			}
+			lvNames := map[string]bool{}
+			lvNum := len(vg.LogicalVolumes)
+			for i, lv := range vg.LogicalVolumes {
+				if lv == nil || lv.Name == "" {
+					return fmt.Errorf("unnamed logical volume in volume group '%s'", vg.Name)
+				}
+				if lvNames[lv.Name] {
+					return fmt.Errorf("logical volume names must be unique. Duplicated '%s/%s'", vg.Name, lv.Name)
+				}
+				lvNames[lv.Name] = true
+				if i < lvNum-1 && lv.Size == 0 {
+					return fmt.Errorf("only last logical volume of '%s' can be defined to be as big as available size", vg.Name)
+				}
+				if lv.MountPoint == "" {
+					continue
+				}
+				if !filepath.IsAbs(lv.MountPoint) {
+					return fmt.Errorf("logical volume mount point '%s' is not an absolute path", lv.MountPoint)
+				}
+				if mountPoints[lv.MountPoint] {
+					return fmt.Errorf("logical volume mount points must be unique. Duplicated '%s'", lv.MountPoint)
+				}
+				mountPoints[lv.MountPoint] = true
+			}
+		}
+	}
+	return nil
+}
+
+// checkABPartitions verifies a 'system-b' partition, if any, is a valid alternate slot
+// of the 'system' partition.
+func checkABPartitions(disks []*Disk) error {
+	var sysPart, sysBPart *Partition
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil {
+				continue
+			}
+			switch part.Role {
+			case System:
+				sysPart = part
+			case SystemB:
+				if sysBPart != nil {
+					return fmt.Errorf("multiple 'system-b' partitions defined, there can be only one")
+				}
+				sysBPart = part
+			}
+		}
+	}
+	if sysBPart == nil || sysPart == nil {
+		return nil
+	}
+	if sysPart.FileSystem != sysBPart.FileSystem {
+		return fmt.Errorf("'system' and 'system-b' partitions must be formatted with the same filesystem")
+	}
+	if len(sysPart.RWVolumes) > 0 || len(sysBPart.RWVolumes) > 0 {
+		return fmt.Errorf("RW volumes are not supported in A/B system partitions")
+	}
+	return nil
+}
+
 // Dummy function to keep compatibility with existing code using these variables
 var (
 	CheckDiskDevice SanitizeDeployment = func(*sys.System, *Deployment) error { return nil }
 )
 
+// GetHooks returns the hooks of the given phase in the order they are defined
+func (d Deployment) GetHooks(phase HookPhase) []Hook {
+	var hooks []Hook
+	for _, hook := range d.Hooks {
+		if hook.Phase == phase {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
 // IsFipsEnabled returns true if FIPS is enabled for the deployment, otherwise false.
 func (d *Deployment) IsFipsEnabled() bool {
 	return d.Security.CryptoPolicy == crypto.FIPSPolicy
@@ -895,16 +1435,18 @@ func (d *Deployment) WriteDeploymentFile(s *sys.System, root string) error {
 		return fmt.Errorf("failed creating a deployment deep copy: %w", err)
 	}
 
-	// omit the device name as this is a runtime information which might
+	// omit the device name and the wipe policy as this is a runtime information which might
 	// not be consistent across reboots, there is no need to store it.
 	for _, disk := range dep.Disks {
 		disk.Device = ""
+		disk.Wipe = nil
 	}
-	// omit the OverlayTree, CfgScript and Installer as this is a runtime information which might
+	// omit the OverlayTree, CfgScript, Installer and Checks as this is a runtime information which might
 	// not be consistent across reboots, there is no need to store it.
 	dep.OverlayTree = nil
 	dep.CfgScript = ""
 	dep.Installer = LiveInstaller{}
+	dep.Checks = nil
 
 	data, err := yaml.Marshal(dep)
 	if err != nil {
@@ -1022,6 +1564,12 @@ func WithConfigPartition(size MiB) Opt {
 	return WithPartitions(1, part)
 }
 
+// WithBIOSPartition inserts a BIOS boot partition as the first partition to the
+// system disk, it enables legacy BIOS boot of the installed system.
+func WithBIOSPartition() Opt {
+	return WithPartitions(0, &Partition{Role: BIOS, Size: BiosSize})
+}
+
 // WithRecoveryPartition inserts a recovery partition as the second partition
 // to the systemd disk. The given size is the amount of data expected to store in
 // the partition, then the partition is sized to be aligned with 128MiB and to ensure
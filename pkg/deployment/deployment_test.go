@@ -20,6 +20,7 @@ package deployment_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -167,18 +168,231 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(len(d.Disks[0].Partitions[1].RWVolumes)).To(Equal(0))
 			Expect(d.Disks[0].Partitions[2].FileSystem).To(Equal(deployment.Btrfs))
 		})
+		It("sets LVM partitions and their logical volumes", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks[0].Partitions[1].Size = 4096
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+				Role:       deployment.LVM,
+				FileSystem: deployment.XFS,
+				VolumeGroup: &deployment.VolumeGroup{
+					Name: "data",
+					LogicalVolumes: []*deployment.LogicalVolume{
+						{Name: "logs", Size: 1024, MountPoint: "/var/log/data"},
+						{Name: "db", FileSystem: deployment.XFS, MountPoint: "/srv/db"},
+					},
+				},
+			})
+			Expect(d.Sanitize(s)).To(Succeed())
+			lvmPart := d.Disks[0].Partitions[2]
+			Expect(lvmPart.FileSystem.String()).To(Equal(deployment.Unknown))
+			Expect(lvmPart.VolumeGroup.LogicalVolumes[0].FileSystem).To(Equal(deployment.Ext4))
+			Expect(lvmPart.VolumeGroup.LogicalVolumes[1].FileSystem).To(Equal(deployment.XFS))
+			Expect(d.Disks[0].Partitions.GetVolumeGroups()).To(ConsistOf(lvmPart.VolumeGroup))
+
+			lvmPart.VolumeGroup.LogicalVolumes[0].Size = 0
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("only last logical volume")))
+
+			lvmPart.VolumeGroup.LogicalVolumes[0].Size = 1024
+			lvmPart.VolumeGroup.LogicalVolumes[1].Name = "logs"
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("Duplicated 'data/logs'")))
+
+			lvmPart.VolumeGroup.LogicalVolumes[1].Name = "db"
+			lvmPart.VolumeGroup.LogicalVolumes[1].MountPoint = "/var/log/data"
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("Duplicated '/var/log/data'")))
+
+			lvmPart.VolumeGroup = nil
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("require a named volume group")))
+		})
+		It("fails if a volume group is set for a non LVM partition", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks[0].Partitions[1].VolumeGroup = &deployment.VolumeGroup{Name: "data"}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("only supported in 'lvm' partitions")))
+		})
+		It("sets an alternate system partition for A/B deployments", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			sysPart := d.GetSystemPartition()
+			sysPart.FileSystem = deployment.XFS
+			sysPart.RWVolumes = nil
+			sysPart.Size = 2048
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+				Role: deployment.SystemB, MountPoint: "/some/path",
+			})
+			Expect(d.Sanitize(s)).To(Succeed())
+			sysBPart := d.GetSystemBPartition()
+			Expect(sysBPart).NotTo(BeNil())
+			Expect(sysBPart.Label).To(Equal(deployment.SystemBLabel))
+			Expect(sysBPart.FileSystem).To(Equal(deployment.XFS))
+			Expect(sysBPart.MountPoint).To(BeEmpty())
+			Expect(d.BaseKernelCmdline()).To(BeEmpty())
+
+			sysBPart.FileSystem = deployment.Ext4
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("same filesystem")))
+
+			sysBPart.FileSystem = deployment.XFS
+			sysBPart.Size = 2048
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{Role: deployment.SystemB})
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("multiple 'system-b' partitions")))
+		})
+		It("fails if A/B system partitions include RW volumes", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.GetSystemPartition().Size = 2048
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{Role: deployment.SystemB})
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("RW volumes are not supported in A/B")))
+		})
+		It("validates quota limits of rw volumes", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.GetSystemPartition().Size = 4096
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+				Role: deployment.Generic, Size: 2048,
+				RWVolumes: []deployment.RWVolume{{Path: "/data", Quota: 1024}},
+			})
+			Expect(d.Sanitize(s)).To(Succeed())
+			dataPart := d.Disks[0].Partitions[2]
+			Expect(dataPart.FileSystem).To(Equal(deployment.Btrfs))
+
+			dataPart.RWVolumes[0].Quota = 4096
+			Expect(d.Sanitize(s)).To(MatchError("quota of rw volume '/data' exceeds the size of its partition"))
+
+			dataPart.RWVolumes[0].Quota = 1024
+			dataPart.FileSystem = deployment.XFS
+			Expect(d.Sanitize(s)).To(MatchError("quota of rw volume '/data' requires a partition formatted with btrfs"))
+		})
+		It("fails if a hook runs chrooted in a phase without a transaction root", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Hooks = []deployment.Hook{{Phase: deployment.PostMerge, Script: "/opt/migrate.sh", Chroot: true}}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Hooks = append(d.Hooks, deployment.Hook{Phase: deployment.OnRollback, Script: "/opt/notify.sh", Chroot: true})
+			Expect(d.Sanitize(s)).To(MatchError("hook '/opt/notify.sh' can't run chrooted in the 'on-rollback' phase"))
+
+			d.Hooks[1].Phase = deployment.PreSync
+			Expect(d.Sanitize(s)).To(MatchError("hook '/opt/notify.sh' can't run chrooted in the 'pre-sync' phase"))
+		})
+		It("parses hook timeouts as durations", func() {
+			var hook deployment.Hook
+			Expect(yaml.Unmarshal([]byte("phase: post-sync\nscript: /opt/check.sh\ntimeout: 1d"), &hook)).To(Succeed())
+			Expect(time.Duration(hook.Timeout)).To(Equal(24 * time.Hour))
+			Expect(yaml.Unmarshal([]byte("timeout: 90s"), &hook)).To(Succeed())
+			Expect(time.Duration(hook.Timeout)).To(Equal(90 * time.Second))
+		})
+		It("resolves merge policies of rw volumes", func() {
+			rwVol := deployment.RWVolume{Path: "/etc", MergeRules: []deployment.MergeRule{
+				{Path: "/etc/ssh", Policy: deployment.TakeImage},
+				{Path: "/etc/*.conf", Policy: deployment.KeepBoth},
+			}}
+			Expect(rwVol.MergePolicy("/etc/ssh/sshd_config")).To(Equal(deployment.TakeImage))
+			Expect(rwVol.MergePolicy("/etc/sshd")).To(Equal(deployment.KeepUser))
+			Expect(rwVol.MergePolicy("/etc/resolv.conf")).To(Equal(deployment.KeepBoth))
+			Expect(rwVol.MergePolicy("/etc/hosts")).To(Equal(deployment.KeepUser))
+		})
+		It("fails if a merge rule has an unknown policy", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.GetSystemPartition().RWVolumes[2].MergeRules = []deployment.MergeRule{
+				{Path: "/etc/hosts", Policy: "overwrite"},
+			}
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+		})
+		It("fails if the boot menu is invalid", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.BootConfig.Menu = &deployment.BootMenu{Terminals: []string{"serial"}}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.BootConfig.Menu.Terminals = []string{"vga"}
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+
+			d.BootConfig.Menu.Terminals = nil
+			d.BootConfig.Menu.Password = &deployment.MenuPassword{User: "root", Hash: "plaintext"}
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+		})
+		It("fails if the wipe policy or the preflight checks are invalid", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks[0].Wipe = &deployment.WipePolicy{Signatures: true, Erase: "secure"}
+			d.Checks = &deployment.PreflightChecks{SecureBoot: "enabled", LabelConflicts: "abort"}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Disks[0].Wipe.Erase = "shred"
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+
+			d.Disks[0].Wipe.Erase = "zero"
+			d.Checks.SecureBoot = "on"
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+
+			d.Checks.SecureBoot = ""
+			d.Checks.LabelConflicts = "ignore"
+			Expect(d.Sanitize(s)).NotTo(Succeed())
+		})
+		It("sets BIOS boot partitions", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks[0].Partitions = append(deployment.Partitions{{
+				Role: deployment.BIOS, FileSystem: deployment.Ext4, MountPoint: "/bios",
+			}}, d.Disks[0].Partitions...)
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			bios := d.GetBIOSPartition()
+			Expect(bios).NotTo(BeNil())
+			Expect(bios.FileSystem.String()).To(Equal(deployment.Unknown))
+			Expect(bios.MountPoint).To(BeEmpty())
+			Expect(bios.Size).To(Equal(deployment.BiosSize))
+			Expect(d.GetBIOSDisk()).To(Equal(d.Disks[0]))
+
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{Role: deployment.BIOS})
+			Expect(d.Sanitize(s)).To(MatchError(ContainSubstring("multiple 'bios' partitions")))
+		})
 		It("writes and reads deployment files", func() {
 			d := deployment.DefaultDeployment()
 			d.Disks[0].Device = "/dev/device"
+			d.Disks[0].Wipe = &deployment.WipePolicy{Signatures: true}
 			d.SourceOS = deployment.NewDirSrc("/some/image")
 			Expect(d.WriteDeploymentFile(s, "/some/dir")).To(Succeed())
+			Expect(d.Disks[0].Wipe).NotTo(BeNil())
 			rD, err := deployment.Parse(s, "/some/dir")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(len(rD.Disks)).To(Equal(1))
 			Expect(rD.Disks[0].Device).To(BeEmpty())
+			Expect(rD.Disks[0].Wipe).To(BeNil())
 			Expect(len(rD.Disks[0].Partitions)).To(Equal(2))
 			Expect(rD.Sanitize(s, deployment.CheckDiskDevice)).To(Succeed())
 		})
+		It("unmarshals retention ages in days or as durations", func() {
+			var policy deployment.RetentionPolicy
+			Expect(yaml.Unmarshal([]byte("maxAge: 30d"), &policy)).To(Succeed())
+			Expect(time.Duration(policy.MaxAge)).To(Equal(30 * 24 * time.Hour))
+
+			Expect(yaml.Unmarshal([]byte("maxAge: 36h"), &policy)).To(Succeed())
+			Expect(time.Duration(policy.MaxAge)).To(Equal(36 * time.Hour))
+
+			data, err := yaml.Marshal(policy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("maxAge: 36h0m0s\n"))
+
+			Expect(yaml.Unmarshal([]byte("maxAge: 1w"), &policy)).NotTo(Succeed())
+			Expect(yaml.Unmarshal([]byte("maxAge: -1d"), &policy)).NotTo(Succeed())
+		})
 		It("unmarshals Disk.Device", func() {
 			disk := "target: /dev/sometarget"
 
@@ -243,7 +457,7 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(err).To(HaveOccurred())
 		})
 		It("Un/marshals PartRole", func() {
-			roles := []string{"efi", "system", "recovery", "config", "generic"}
+			roles := []string{"efi", "system", "recovery", "config", "generic", "lvm", "system-b"}
 			var r deployment.PartRole
 
 			for _, role := range roles {
@@ -0,0 +1,153 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diskimage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// Format is the file format of a disk image
+type Format string
+
+const (
+	Raw     Format = "raw"
+	QCOW2   Format = "qcow2"
+	VMDK    Format = "vmdk"
+	VHDX    Format = "vhdx"
+	VHD     Format = "vhd"
+	RawZstd Format = "raw.zst"
+	RawXz   Format = "raw.xz"
+)
+
+// vhdAlignment is the alignment of the virtual size of fixed VHD images required by Azure
+const vhdAlignment = 1024 * 1024
+
+// Formats returns all the supported disk image formats
+func Formats() []Format {
+	return []Format{Raw, QCOW2, VMDK, VHDX, VHD, RawZstd, RawXz}
+}
+
+// ParseFormat parses the given disk image format, an empty string defaults to Raw
+func ParseFormat(format string) (Format, error) {
+	if format == "" {
+		return Raw, nil
+	}
+	for _, f := range Formats() {
+		if string(f) == format {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported disk image format '%s': %w", format, errors.ErrUnsupported)
+}
+
+// OutputPath returns the path of the given raw image once converted to the given format
+func OutputPath(rawImage string, format Format) string {
+	if format == Raw {
+		return rawImage
+	}
+	return fmt.Sprintf("%s.%s", strings.TrimSuffix(rawImage, "."+string(Raw)), format)
+}
+
+// Convert converts the given raw image to the given format and returns the path of the
+// converted image. The raw image is replaced by the converted one; compressed formats
+// are streamed, so there is no intermediate full-size copy of the image.
+func Convert(ctx context.Context, s *sys.System, rawImage string, format Format) (string, error) {
+	output := OutputPath(rawImage, format)
+
+	var err error
+	switch format {
+	case Raw:
+		return rawImage, nil
+	case RawZstd:
+		_, err = s.Runner().RunContext(ctx, "zstd", "-T0", "-q", "-f", "--rm", rawImage, "-o", output)
+	case RawXz:
+		err = compressXz(ctx, s, rawImage, output)
+	case QCOW2:
+		err = qemuImgConvert(ctx, s, rawImage, output, "qcow2")
+	case VMDK:
+		err = qemuImgConvert(ctx, s, rawImage, output, "vmdk", "-o", "subformat=streamOptimized")
+	case VHDX:
+		err = qemuImgConvert(ctx, s, rawImage, output, "vhdx", "-o", "subformat=dynamic")
+	case VHD:
+		err = alignImage(ctx, s, rawImage, vhdAlignment)
+		if err == nil {
+			err = qemuImgConvert(ctx, s, rawImage, output, "vpc", "-o", "subformat=fixed,force_size=on")
+		}
+	default:
+		return "", fmt.Errorf("converting to '%s': %w", format, errors.ErrUnsupported)
+	}
+	if err != nil {
+		return "", fmt.Errorf("converting '%s' to %s: %w", rawImage, format, err)
+	}
+
+	s.Logger().Info("Disk image converted to %s at '%s'", format, output)
+	return output, nil
+}
+
+// compressXz compresses the raw image with xz, which always appends the '.xz' suffix to its output
+func compressXz(ctx context.Context, s *sys.System, rawImage, output string) error {
+	_, err := s.Runner().RunContext(ctx, "xz", "-T0", "-q", "-f", rawImage)
+	if err != nil {
+		return err
+	}
+	if xzFile := rawImage + ".xz"; xzFile != output {
+		return s.FS().Rename(xzFile, output)
+	}
+	return nil
+}
+
+// qemuImgConvert converts the raw image with qemu-img and removes the source image afterwards
+func qemuImgConvert(ctx context.Context, s *sys.System, rawImage, output, format string, args ...string) error {
+	cmdArgs := append([]string{"convert", "-f", string(Raw), "-O", format}, args...)
+	cmdArgs = append(cmdArgs, rawImage, output)
+	_, err := s.Runner().RunContext(ctx, "qemu-img", cmdArgs...)
+	if err != nil {
+		return err
+	}
+	return s.FS().Remove(rawImage)
+}
+
+// alignImage grows the image up to the next multiple of the given alignment, if needed. The
+// backup GPT header is moved to the new end of the image, so the partition table stays valid.
+func alignImage(ctx context.Context, s *sys.System, image string, alignment int64) error {
+	info, err := s.FS().Stat(image)
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size%alignment == 0 {
+		return nil
+	}
+	aligned := (size/alignment + 1) * alignment
+	s.Logger().Debug("Aligning '%s' size from %d to %d bytes", image, size, aligned)
+	_, err = s.Runner().RunContext(ctx, "truncate", "-s", strconv.FormatInt(aligned, 10), image)
+	if err != nil {
+		return err
+	}
+	_, err = s.Runner().RunContext(ctx, "sgdisk", "-e", image)
+	if err != nil {
+		return fmt.Errorf("relocating backup GPT header: %w", err)
+	}
+	return nil
+}
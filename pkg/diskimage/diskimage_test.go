@@ -0,0 +1,102 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diskimage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/diskimage"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestDiskImageSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Disk image test suite")
+}
+
+var _ = Describe("Disk image", Label("diskimage"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var runner *sysmock.Runner
+	var cleanup func()
+	var err error
+	BeforeEach(func() {
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{"/build/image.raw": "raw disk"})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("parses disk image formats", func() {
+		Expect(diskimage.ParseFormat("")).To(Equal(diskimage.Raw))
+		Expect(diskimage.ParseFormat("raw.zst")).To(Equal(diskimage.RawZstd))
+		_, err := diskimage.ParseFormat("iso")
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+	})
+	It("computes the output path of converted images", func() {
+		Expect(diskimage.OutputPath("/build/image.raw", diskimage.Raw)).To(Equal("/build/image.raw"))
+		Expect(diskimage.OutputPath("/build/image.raw", diskimage.QCOW2)).To(Equal("/build/image.qcow2"))
+		Expect(diskimage.OutputPath("/build/image.raw", diskimage.RawXz)).To(Equal("/build/image.raw.xz"))
+		Expect(diskimage.OutputPath("/build/disk.img", diskimage.VHD)).To(Equal("/build/disk.img.vhd"))
+	})
+	It("keeps raw images as they are", func() {
+		Expect(diskimage.Convert(context.Background(), s, "/build/image.raw", diskimage.Raw)).To(Equal("/build/image.raw"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("converts raw images to qcow2 removing the raw image", func() {
+		Expect(diskimage.Convert(context.Background(), s, "/build/image.raw", diskimage.QCOW2)).To(Equal("/build/image.qcow2"))
+		Expect(runner.CmdsMatch([][]string{
+			{"qemu-img", "convert", "-f", "raw", "-O", "qcow2", "/build/image.raw", "/build/image.qcow2"},
+		})).To(Succeed())
+		Expect(vfs.Exists(tfs, "/build/image.raw")).To(BeFalse())
+	})
+	It("aligns fixed VHD images to 1MiB", func() {
+		Expect(diskimage.Convert(context.Background(), s, "/build/image.raw", diskimage.VHD)).To(Equal("/build/image.vhd"))
+		Expect(runner.CmdsMatch([][]string{
+			{"truncate", "-s", "1048576", "/build/image.raw"},
+			{"sgdisk", "-e", "/build/image.raw"},
+			{"qemu-img", "convert", "-f", "raw", "-O", "vpc", "-o", "subformat=fixed,force_size=on", "/build/image.raw", "/build/image.vhd"},
+		})).To(Succeed())
+	})
+	It("compresses raw images with zstd", func() {
+		Expect(diskimage.Convert(context.Background(), s, "/build/image.raw", diskimage.RawZstd)).To(Equal("/build/image.raw.zst"))
+		Expect(runner.CmdsMatch([][]string{
+			{"zstd", "-T0", "-q", "-f", "--rm", "/build/image.raw", "-o", "/build/image.raw.zst"},
+		})).To(Succeed())
+	})
+	It("fails if the conversion fails", func() {
+		runner.ReturnError = errors.New("qemu-img failed")
+		_, err := diskimage.Convert(context.Background(), s, "/build/image.raw", diskimage.VMDK)
+		Expect(err).To(MatchError(ContainSubstring("qemu-img failed")))
+		Expect(vfs.Exists(tfs, "/build/image.raw")).To(BeTrue())
+	})
+})
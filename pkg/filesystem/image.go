@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -62,10 +64,39 @@ func CreateEmptyFile(fs vfs.FS, filename string, size int64, noSparse bool) erro
 	return nil
 }
 
+type PreloadOption func(*preloadOptions)
+
+type preloadOptions struct {
+	uuid    string
+	modTime *time.Time
+}
+
+// WithUUID sets the UUID of the preloaded filesystem, for VFAT filesystems only the first
+// UUID block is used as the volume ID
+func WithUUID(uuid string) PreloadOption {
+	return func(o *preloadOptions) {
+		o.uuid = uuid
+	}
+}
+
+// WithModTime pins the times of the preloaded files, files newer than the given time are set
+// to it. For VFAT filesystems it also creates the filesystem in invariant mode, so the image
+// does not depend on the time it was created at.
+func WithModTime(t time.Time) PreloadOption {
+	return func(o *preloadOptions) {
+		o.modTime = &t
+	}
+}
+
 // CreatePreloadedFileSystemImage creates a new raw image with the given filesystem. The size of the image
 // is computed form the provided root tree size plus the given overhead. The resulting image size is aligned
 // with the given overhead and has a minimum of a full overhead of free space.
-func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string, overheadM int64, fs deployment.FileSystem) error {
+func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string, overheadM int64, fs deployment.FileSystem, opts ...PreloadOption) error {
+	o := &preloadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	size, err := vfs.DirSize(s.FS(), root)
 	if err != nil {
 		return fmt.Errorf("could not compute required image size: %w", err)
@@ -80,6 +111,13 @@ func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string,
 		return fmt.Errorf("could not create filesystem image file %s: %w", filename, err)
 	}
 
+	if o.modTime != nil {
+		err = vfs.ClampModTimes(s.FS(), root, *o.modTime)
+		if err != nil {
+			return fmt.Errorf("could not set the times of files in %s: %w", root, err)
+		}
+	}
+
 	flags := []string{}
 	switch fs {
 	case deployment.Ext2, deployment.Ext4:
@@ -87,11 +125,14 @@ func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string,
 	case deployment.Btrfs:
 		flags = append(flags, "--root-dir", root)
 	case deployment.VFat:
+		if o.modTime != nil {
+			flags = append(flags, "--invariant")
+		}
 	default:
 		return fmt.Errorf("preloaded image is not supported for %s: %w", fs.String(), errors.ErrUnsupported)
 	}
 
-	mkfsCall := NewMkfsCall(s, filename, fs.String(), label, "", flags...)
+	mkfsCall := NewMkfsCall(s, filename, fs.String(), label, o.uuid, flags...)
 	err = mkfsCall.Apply()
 	if err != nil {
 		return fmt.Errorf("failed formatting preloaded filesystem image %s: %w", filename, err)
@@ -103,8 +144,14 @@ func CreatePreloadedFileSystemImage(s *sys.System, root, filename, label string,
 			return fmt.Errorf("failed reading files from root tree: %w", err)
 		}
 
+		mcopyFlags := []string{"-s"}
+		if o.modTime != nil {
+			// preserve the clamped modification times instead of using the current time
+			mcopyFlags = append(mcopyFlags, "-m")
+		}
 		for _, f := range files {
-			_, err = s.Runner().Run("mcopy", "-s", "-i", filename, filepath.Join(root, f.Name()), "::")
+			args := append(slices.Clone(mcopyFlags), "-i", filename, filepath.Join(root, f.Name()), "::")
+			_, err = s.Runner().Run("mcopy", args...)
 			if err != nil {
 				return fmt.Errorf("failed copying file %s to the vfat image %s: %w", f.Name(), filename, err)
 			}
@@ -18,6 +18,8 @@ limitations under the License.
 package filesystem_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -97,6 +99,20 @@ var _ = Describe("Image", Label("image"), func() {
 		size, _ := vfs.DirSizeMB(fs, "/test")
 		Expect(size).To(Equal(uint(33)))
 	})
+	It("Creates a reproducible vfat image with preloaded content", func() {
+		epoch := time.Unix(1700000000, 0)
+		Expect(filesystem.CreatePreloadedFileSystemImage(
+			s, "/some/root", "/test/raw.img", "ROOT", 16, deployment.VFat,
+			filesystem.WithUUID("1a2b3c4d-0000-0000-0000-000000000000"), filesystem.WithModTime(epoch),
+		)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"mkfs.vfat", "-n", "ROOT", "-i", "1a2b3c4d", "--invariant", "/test/raw.img"},
+			{"mcopy", "-s", "-m", "-i", "/test/raw.img", "/some/root/file", "::"},
+		})).To(Succeed())
+		info, err := fs.Stat("/some/root/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ModTime().Equal(epoch)).To(BeTrue())
+	})
 	It("Fails to create a preloaded image with a not supported filesystem", func() {
 		Expect(filesystem.CreatePreloadedFileSystemImage(s, "/some/root", "/test/raw.img", "ROOT", 16, deployment.XFS)).NotTo(Succeed())
 	})
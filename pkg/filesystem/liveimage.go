@@ -0,0 +1,250 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/go-units"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// LiveImageType is the filesystem type of a live root image
+type LiveImageType string
+
+const (
+	SquashfsImage LiveImageType = "squashfs"
+	EROFSImage    LiveImageType = "erofs"
+)
+
+// Compression is the compression algorithm of a live root image
+type Compression string
+
+const (
+	// DefaultCompression is the compression of the image type, gzip for squashfs and lz4hc for EROFS
+	DefaultCompression Compression = ""
+	NoCompression      Compression = "none"
+	XZ                 Compression = "xz"
+	Zstd               Compression = "zstd"
+	LZ4                Compression = "lz4"
+)
+
+const (
+	squashfsMagic    = "hsqs"
+	erofsMagic       = 0xE0F5E1E2
+	erofsMagicOffset = 1024
+
+	erofsBlockSize = 4096
+)
+
+// LiveImage describes the format of a live root image
+type LiveImage struct {
+	Type        LiveImageType
+	Compression Compression
+	// Level is the compression level, 0 sets the compressor default. For squashfs lz4 images any
+	// level enables the high compression mode.
+	Level int
+	// BlockSize is the size in bytes of the compressed blocks, 0 sets the default. It is the block
+	// size of squashfs images and the maximum physical cluster size of EROFS images.
+	BlockSize int64
+}
+
+// ParseLiveImageType parses the given live root image type, an empty value defaults to squashfs
+func ParseLiveImageType(value string) (LiveImageType, error) {
+	switch LiveImageType(value) {
+	case "", SquashfsImage:
+		return SquashfsImage, nil
+	case EROFSImage:
+		return EROFSImage, nil
+	default:
+		return "", fmt.Errorf("unsupported live image type '%s': %w", value, errors.ErrUnsupported)
+	}
+}
+
+// ParseCompression parses the given live root image compression algorithm
+func ParseCompression(value string) (Compression, error) {
+	switch c := Compression(value); c {
+	case DefaultCompression, NoCompression, XZ, Zstd, LZ4:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unsupported live image compression '%s': %w", value, errors.ErrUnsupported)
+	}
+}
+
+// ParseBlockSize parses a block size in bytes with an optional binary unit suffix, e.g. '128k' or '1M'
+func ParseBlockSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	size, err := units.RAMInBytes(value)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid block size '%s'", value)
+	}
+	return size, nil
+}
+
+// Validate checks the compression level and block size are supported by the image type
+func (l LiveImage) Validate() error {
+	var maxLevel int
+	switch l.Compression {
+	case XZ:
+		if l.Type != EROFSImage && l.Level != 0 {
+			return fmt.Errorf("compression level is not supported by squashfs xz compression")
+		}
+		maxLevel = 9
+	case Zstd:
+		maxLevel = 22
+	case LZ4:
+		maxLevel = 12
+	default:
+		if l.Level != 0 {
+			return fmt.Errorf("compression level requires an explicit compression algorithm")
+		}
+	}
+	if l.Level < 0 || l.Level > maxLevel {
+		return fmt.Errorf("invalid %s compression level %d, must be between 1 and %d", l.Compression, l.Level, maxLevel)
+	}
+
+	if l.BlockSize == 0 {
+		return nil
+	}
+	switch l.Type {
+	case EROFSImage:
+		if l.BlockSize%erofsBlockSize != 0 {
+			return fmt.Errorf("invalid EROFS cluster size %d, must be a multiple of %d", l.BlockSize, erofsBlockSize)
+		}
+	default:
+		if l.BlockSize < 4*units.KiB || l.BlockSize > units.MiB || l.BlockSize&(l.BlockSize-1) != 0 {
+			return fmt.Errorf("invalid squashfs block size %d, must be a power of two between 4KiB and 1MiB", l.BlockSize)
+		}
+	}
+	return nil
+}
+
+// Options returns the mksquashfs or mkfs.erofs options to create the image
+func (l LiveImage) Options() []string {
+	if l.Type == EROFSImage {
+		return l.erofsOptions()
+	}
+	return l.squashfsOptions()
+}
+
+func (l LiveImage) squashfsOptions() []string {
+	var options []string
+
+	switch l.Compression {
+	case NoCompression:
+		options = SquashfsNoCompressionOptions()
+	case XZ:
+		options = []string{"-comp", "xz"}
+	case Zstd:
+		options = []string{"-comp", "zstd"}
+		if l.Level > 0 {
+			options = append(options, "-Xcompression-level", strconv.Itoa(l.Level))
+		}
+	case LZ4:
+		options = []string{"-comp", "lz4"}
+		if l.Level > 0 {
+			options = append(options, "-Xhc")
+		}
+	}
+
+	if l.BlockSize > 0 {
+		return append(options, "-b", strconv.FormatInt(l.BlockSize, 10))
+	}
+	return append(options, DefaultSquashfsCompressionOptions()...)
+}
+
+func (l LiveImage) erofsOptions() []string {
+	var algorithm string
+
+	switch l.Compression {
+	case NoCompression:
+	case XZ:
+		algorithm = "lzma"
+	case Zstd:
+		algorithm = "zstd"
+	case LZ4:
+		algorithm = "lz4"
+		if l.Level > 0 {
+			algorithm = "lz4hc"
+		}
+	default:
+		algorithm = "lz4hc"
+	}
+
+	var options []string
+	if algorithm != "" {
+		if l.Level > 0 {
+			algorithm = fmt.Sprintf("%s,%d", algorithm, l.Level)
+		}
+		options = append(options, "-z", algorithm)
+	}
+	if l.BlockSize > 0 {
+		options = append(options, "-C", strconv.FormatInt(l.BlockSize, 10))
+	}
+	return options
+}
+
+// CreateLiveImage creates a live root image of the given format at destination from a source,
+// the extra options are appended to the ones of the format
+func CreateLiveImage(ctx context.Context, s *sys.System, l LiveImage, source, destination string, extraOpts ...string) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	options := append(l.Options(), extraOpts...)
+	if l.Type == EROFSImage {
+		return CreateEROFS(ctx, s, source, destination, options)
+	}
+	return CreateSquashFS(ctx, s, source, destination, options)
+}
+
+// DetectLiveImageType returns the filesystem type of the given live root image based on its
+// superblock magic
+func DetectLiveImageType(fs vfs.FS, image string) (LiveImageType, error) {
+	f, err := fs.Open(image)
+	if err != nil {
+		return "", fmt.Errorf("opening live image '%s': %w", image, err)
+	}
+	defer f.Close()
+
+	header := make([]byte, erofsMagicOffset+4)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("reading live image '%s': %w", image, err)
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte(squashfsMagic)):
+		return SquashfsImage, nil
+	case n == erofsMagicOffset+4 && binary.LittleEndian.Uint32(header[erofsMagicOffset:]) == erofsMagic:
+		return EROFSImage, nil
+	default:
+		return "", fmt.Errorf("unknown live image type of '%s': %w", image, errors.ErrUnsupported)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Live image", Label("liveimage"), func() {
+	var fs vfs.FS
+	var s *sys.System
+	var runner *sysmock.Runner
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.MkdirAll(fs, "/some/root", vfs.DirPerm)).To(Succeed())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("parses live image types, compressions and block sizes", func() {
+		Expect(filesystem.ParseLiveImageType("")).To(Equal(filesystem.SquashfsImage))
+		Expect(filesystem.ParseLiveImageType("erofs")).To(Equal(filesystem.EROFSImage))
+		_, err := filesystem.ParseLiveImageType("ext4")
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+
+		Expect(filesystem.ParseCompression("zstd")).To(Equal(filesystem.Zstd))
+		_, err = filesystem.ParseCompression("brotli")
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+
+		Expect(filesystem.ParseBlockSize("128k")).To(Equal(int64(128 * 1024)))
+		Expect(filesystem.ParseBlockSize("1M")).To(Equal(int64(1024 * 1024)))
+		Expect(filesystem.ParseBlockSize("")).To(BeZero())
+		_, err = filesystem.ParseBlockSize("big")
+		Expect(err).To(HaveOccurred())
+	})
+	It("validates compression levels and block sizes", func() {
+		Expect(filesystem.LiveImage{Compression: filesystem.Zstd, Level: 19}.Validate()).To(Succeed())
+		Expect(filesystem.LiveImage{Compression: filesystem.Zstd, Level: 23}.Validate()).NotTo(Succeed())
+		Expect(filesystem.LiveImage{Compression: filesystem.XZ, Level: 6}.Validate()).NotTo(Succeed())
+		Expect(filesystem.LiveImage{Type: filesystem.EROFSImage, Compression: filesystem.XZ, Level: 6}.Validate()).To(Succeed())
+		Expect(filesystem.LiveImage{Level: 6}.Validate()).NotTo(Succeed())
+
+		Expect(filesystem.LiveImage{BlockSize: 128 * 1024}.Validate()).To(Succeed())
+		Expect(filesystem.LiveImage{BlockSize: 96 * 1024}.Validate()).NotTo(Succeed())
+		Expect(filesystem.LiveImage{BlockSize: 2 * 1024 * 1024}.Validate()).NotTo(Succeed())
+		Expect(filesystem.LiveImage{Type: filesystem.EROFSImage, BlockSize: 256 * 1024}.Validate()).To(Succeed())
+		Expect(filesystem.LiveImage{Type: filesystem.EROFSImage, BlockSize: 1000}.Validate()).NotTo(Succeed())
+	})
+	It("returns squashfs options", func() {
+		Expect(filesystem.LiveImage{}.Options()).To(Equal([]string{"-b", "1024k"}))
+		Expect(filesystem.LiveImage{Compression: filesystem.NoCompression}.Options()).To(Equal([]string{"-no-compression", "-b", "1024k"}))
+		Expect(filesystem.LiveImage{Compression: filesystem.XZ}.Options()).To(Equal([]string{"-comp", "xz", "-b", "1024k"}))
+		Expect(filesystem.LiveImage{Compression: filesystem.Zstd, Level: 15, BlockSize: 131072}.Options()).To(Equal(
+			[]string{"-comp", "zstd", "-Xcompression-level", "15", "-b", "131072"},
+		))
+		Expect(filesystem.LiveImage{Compression: filesystem.LZ4, Level: 9}.Options()).To(Equal([]string{"-comp", "lz4", "-Xhc", "-b", "1024k"}))
+	})
+	It("returns EROFS options", func() {
+		erofs := filesystem.LiveImage{Type: filesystem.EROFSImage}
+		Expect(erofs.Options()).To(Equal([]string{"-z", "lz4hc"}))
+
+		erofs.Compression = filesystem.NoCompression
+		Expect(erofs.Options()).To(BeEmpty())
+
+		erofs.Compression, erofs.Level = filesystem.XZ, 6
+		Expect(erofs.Options()).To(Equal([]string{"-z", "lzma,6"}))
+
+		erofs.Compression, erofs.Level, erofs.BlockSize = filesystem.Zstd, 0, 1048576
+		Expect(erofs.Options()).To(Equal([]string{"-z", "zstd", "-C", "1048576"}))
+
+		erofs.Compression, erofs.Level, erofs.BlockSize = filesystem.LZ4, 12, 0
+		Expect(erofs.Options()).To(Equal([]string{"-z", "lz4hc,12"}))
+	})
+	It("creates live images", func() {
+		erofs := filesystem.LiveImage{Type: filesystem.EROFSImage, Compression: filesystem.Zstd, Level: 3}
+		Expect(filesystem.CreateLiveImage(
+			context.Background(), s, erofs, "/some/root", "/some/root.img",
+			filesystem.EROFSReproducibleOptions(time.Unix(1700000000, 0), "6b1f3b3c-0d0e-4a8b-9c1d-2e3f4a5b6c7d")...,
+		)).To(Succeed())
+		Expect(filesystem.CreateLiveImage(
+			context.Background(), s, filesystem.LiveImage{Compression: filesystem.Zstd}, "/some/root", "/some/root.img",
+		)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{
+				"mkfs.erofs", "-z", "zstd,3", "-T", "1700000000", "--all-time",
+				"-U", "6b1f3b3c-0d0e-4a8b-9c1d-2e3f4a5b6c7d", "/some/root.img", "/some/root",
+			},
+			{"mksquashfs", "/some/root", "/some/root.img", "-comp", "zstd", "-b", "1024k"},
+		})).To(Succeed())
+
+		By("refusing invalid formats")
+		runner.ClearCmds()
+		Expect(filesystem.CreateLiveImage(
+			context.Background(), s, filesystem.LiveImage{Compression: filesystem.LZ4, Level: 13}, "/some/root", "/some/root.img",
+		)).NotTo(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+
+		By("reporting mkfs.erofs failures")
+		runner.ReturnError = errors.New("mkfs.erofs failed")
+		Expect(filesystem.CreateLiveImage(context.Background(), s, erofs, "/some/root", "/some/root.img")).NotTo(Succeed())
+	})
+	It("detects the live image type", func() {
+		Expect(fs.WriteFile("/squashfs.img", append([]byte("hsqs"), make([]byte, 4096)...), vfs.FilePerm)).To(Succeed())
+		erofs := make([]byte, 4096)
+		copy(erofs[1024:], []byte{0xE2, 0xE1, 0xF5, 0xE0})
+		Expect(fs.WriteFile("/erofs.img", erofs, vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/unknown.img", []byte("short"), vfs.FilePerm)).To(Succeed())
+
+		Expect(filesystem.DetectLiveImageType(fs, "/squashfs.img")).To(Equal(filesystem.SquashfsImage))
+		Expect(filesystem.DetectLiveImageType(fs, "/erofs.img")).To(Equal(filesystem.EROFSImage))
+		_, err := filesystem.DetectLiveImageType(fs, "/unknown.img")
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+		_, err = filesystem.DetectLiveImageType(fs, "/missing.img")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,46 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// CreateEROFS creates an EROFS image at destination from a source, with options
+func CreateEROFS(ctx context.Context, s *sys.System, source string, destination string, options []string) error {
+	args := append([]string{}, options...)
+	args = append(args, destination, source)
+
+	out, err := s.Runner().RunContext(ctx, "mkfs.erofs", args...)
+	if err != nil {
+		s.Logger().Error("Error running mkfs.erofs, stdout and stderr output: %s", out)
+		return fmt.Errorf("error creating EROFS from %s to %s: %w", source, destination, err)
+	}
+	return nil
+}
+
+// EROFSReproducibleOptions returns the options pinning the filesystem UUID, the build time and
+// the times of all files to the given values
+func EROFSReproducibleOptions(t time.Time, uuid string) []string {
+	return []string{"-T", strconv.FormatInt(t.Unix(), 10), "--all-time", "-U", uuid}
+}
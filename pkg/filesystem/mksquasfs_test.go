@@ -19,6 +19,7 @@ package filesystem_test
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -71,6 +72,15 @@ var _ = Describe("Mksquashfs", Label("mksquashfs"), func() {
 			{"mksquashfs", "/some/root", "/some/rootfs.squashfs", "-wildcards", "-e", "subdir*"},
 		})).To(Succeed())
 	})
+	It("Creates a squashfs image with pinned times", func() {
+		Expect(filesystem.CreateSquashFS(
+			context.Background(), s, "/some/root", "/some/rootfs.squashfs",
+			filesystem.SquashfsReproducibleOptions(time.Unix(1700000000, 0)),
+		)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"mksquashfs", "/some/root", "/some/rootfs.squashfs", "-mkfs-time", "1700000000", "-all-time", "1700000000"},
+		})).To(Succeed())
+	})
 	It("Creates a squashfs image with default parameters", func() {
 		Expect(filesystem.CreateSquashFS(
 			context.Background(), s, "/some/root", "/some/rootfs.squashfs",
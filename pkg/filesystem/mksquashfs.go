@@ -20,6 +20,8 @@ package filesystem
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/suse/elemental/v3/pkg/sys"
 )
@@ -45,6 +47,13 @@ func DefaultSquashfsCompressionOptions() []string {
 	return []string{"-b", "1024k"}
 }
 
+// SquashfsReproducibleOptions returns the options pinning the filesystem creation time and the
+// times of all files to the given time
+func SquashfsReproducibleOptions(t time.Time) []string {
+	epoch := strconv.FormatInt(t.Unix(), 10)
+	return []string{"-mkfs-time", epoch, "-all-time", epoch}
+}
+
 func SquashfsExcludeOptions(excludes ...string) []string {
 	opts := []string{}
 	if len(excludes) == 0 {
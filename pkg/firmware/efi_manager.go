@@ -18,10 +18,18 @@ limitations under the License.
 package firmware
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
 const (
@@ -32,6 +40,14 @@ const (
 	EfiImgX86        = "bootx64.efi"
 	EfiImgArm64      = "bootaa64.efi"
 	EfiImgRiscv64    = "bootriscv64.efi"
+
+	partUUIDPath = "/dev/disk/by-partuuid"
+)
+
+var (
+	bootVarRegexp  = regexp.MustCompile(`^Boot([0-9A-Fa-f]{4})(\*?)\s+(.*)$`)
+	hdPathRegexp   = regexp.MustCompile(`HD\([^,]*,GPT,([0-9A-Fa-f-]+),`)
+	filePathRegexp = regexp.MustCompile(`(?:^|/)File\(([^)]*)\)`)
 )
 
 // EfiBootManager contains logic to update the EFI variables and boot-entries for a system.
@@ -46,26 +62,275 @@ type EfiBootEntry struct {
 	Disk   string
 }
 
+// BootVariable contains information about a boot variable stored in the EFI NVRAM.
+type BootVariable struct {
+	// Num is the hexadecimal boot number of the variable (e.g. 0001)
+	Num    string
+	Label  string
+	Active bool
+	// Loader is the path of the EFI application within its partition using forward slashes,
+	// it is empty for variables not pointing to a file (e.g. firmware applications)
+	Loader string
+	// PartUUID is the GPT partition UUID holding the loader, if any
+	PartUUID string
+}
+
+// BootState contains the boot variables and boot order stored in the EFI NVRAM.
+type BootState struct {
+	Current   string
+	Order     []string
+	Variables []*BootVariable
+}
+
 // NewEfiBootManager creates a new EfiBootManager.
 func NewEfiBootManager(s *sys.System) *EfiBootManager {
 	return &EfiBootManager{s}
 }
 
-// CreateBootEntries creates the EFI boot entries using efibootmgr.
+// CreateBootEntries creates the EFI boot entries using efibootmgr. Entries already present
+// in NVRAM are reused and their duplicates removed, so repeated calls do not pile up boot
+// variables. The given entries are set first in the boot order, in the given order.
 func (b *EfiBootManager) CreateBootEntries(entries []*EfiBootEntry) error {
 	b.s.Logger().Info("Creating %d boot entries...", len(entries))
 
+	state, err := b.ReadBootState()
+	if err != nil {
+		return err
+	}
+
+	var order []string
 	for _, entry := range entries {
-		cmdOut, err := b.s.Runner().Run("efibootmgr", "--create", "--disk", entry.Disk, "--label", entry.Label, "--loader", entry.Loader)
+		matches := b.findBootVariables(state, entry)
+		if len(matches) == 0 {
+			cmdOut, err := b.s.Runner().Run("efibootmgr", "--create", "--disk", entry.Disk, "--label", entry.Label, "--loader", entry.Loader)
+			if err != nil {
+				b.s.Logger().Error("failed creating boot entry (%s): %s", err.Error(), string(cmdOut))
+				return err
+			}
+
+			state, err = b.ReadBootState()
+			if err != nil {
+				return err
+			}
+			matches = b.findBootVariables(state, entry)
+			if len(matches) == 0 {
+				b.s.Logger().Warn("created boot entry '%s' not found in NVRAM", entry.Label)
+				continue
+			}
+		} else {
+			b.s.Logger().Info("Boot entry '%s' already exists as Boot%s", entry.Label, matches[0].Num)
+		}
+
+		for _, duplicate := range matches[1:] {
+			err = b.deleteBootVariable(duplicate)
+			if err != nil {
+				return err
+			}
+			state.Order = slices.DeleteFunc(state.Order, func(num string) bool { return num == duplicate.Num })
+		}
+		order = append(order, matches[0].Num)
+	}
+
+	return b.setBootOrder(state, order)
+}
+
+// ReadBootState reads the boot variables and boot order from the EFI NVRAM using efibootmgr.
+func (b *EfiBootManager) ReadBootState() (*BootState, error) {
+	out, err := b.s.Runner().Run("efibootmgr", "--verbose")
+	if err != nil {
+		return nil, fmt.Errorf("reading EFI boot variables: %w", err)
+	}
+	return parseBootState(out), nil
+}
+
+// PruneBootEntries removes stale elemental boot variables. These are duplicates of a boot variable
+// pointing to the same loader and partition, and variables pointing to a partition which is not
+// present in the system. With dryRun set stale variables are only reported. Returns the stale
+// variables found.
+func (b *EfiBootManager) PruneBootEntries(dryRun bool) ([]*BootVariable, error) {
+	state, err := b.ReadBootState()
+	if err != nil {
+		return nil, err
+	}
+
+	// check partitions presence only if partition links are available
+	checkParts, _ := vfs.Exists(b.s.FS(), partUUIDPath)
+
+	var stale []*BootVariable
+	seen := map[string]bool{}
+	for _, v := range state.sortedByOrder() {
+		if !isElementalVariable(v) {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s:%s", v.Label, strings.ToLower(v.Loader), strings.ToLower(v.PartUUID))
+		if seen[key] {
+			stale = append(stale, v)
+			continue
+		}
+		seen[key] = true
+
+		if checkParts && v.PartUUID != "" {
+			if ok, _ := vfs.Exists(b.s.FS(), filepath.Join(partUUIDPath, strings.ToLower(v.PartUUID))); !ok {
+				stale = append(stale, v)
+			}
+		}
+	}
+
+	if dryRun {
+		return stale, nil
+	}
+	for _, v := range stale {
+		err = b.deleteBootVariable(v)
 		if err != nil {
-			b.s.Logger().Error("failed creating boot entry (%s): %s", err.Error(), string(cmdOut))
-			return err
+			return nil, err
 		}
 	}
+	return stale, nil
+}
 
+// findBootVariables returns the boot variables matching the given entry label, loader and disk. Variables
+// listed first in boot order go first.
+func (b *EfiBootManager) findBootVariables(state *BootState, entry *EfiBootEntry) []*BootVariable {
+	loader := normalizeLoader(entry.Loader)
+	diskParts := b.diskPartUUIDs(entry.Disk)
+
+	var matches []*BootVariable
+	for _, v := range state.sortedByOrder() {
+		if v.Label != entry.Label || !strings.EqualFold(v.Loader, loader) {
+			continue
+		}
+		if diskParts != nil && v.PartUUID != "" && !diskParts[strings.ToLower(v.PartUUID)] {
+			continue
+		}
+		matches = append(matches, v)
+	}
+	return matches
+}
+
+// diskPartUUIDs returns the partition UUIDs of the given disk, disk links such as the /dev/disk/by-id ones
+// are resolved first. Returns nil if the partitions of the disk can't be listed, variables are then assumed
+// to be in the given disk.
+func (b *EfiBootManager) diskPartUUIDs(disk string) map[string]bool {
+	if disk == "" {
+		return nil
+	}
+	if resolved, err := vfs.ResolveLink(b.s.FS(), disk, "/", vfs.MaxLinkDepth); err == nil {
+		disk = resolved
+	}
+
+	parts, err := lsblk.NewLsDevice(b.s).GetDevicePartitions(disk)
+	if err != nil {
+		b.s.Logger().Debug("could not list partitions of '%s', not checking the disk of boot entries: %v", disk, err)
+		return nil
+	}
+
+	uuids := map[string]bool{}
+	for _, part := range parts {
+		if part.Disk == disk && part.UUID != "" {
+			uuids[strings.ToLower(part.UUID)] = true
+		}
+	}
+	return uuids
+}
+
+func (b *EfiBootManager) deleteBootVariable(v *BootVariable) error {
+	b.s.Logger().Info("Removing boot entry Boot%s (%s)", v.Num, v.Label)
+	cmdOut, err := b.s.Runner().Run("efibootmgr", "--bootnum", v.Num, "--delete-bootnum")
+	if err != nil {
+		b.s.Logger().Error("failed removing boot entry (%s): %s", err.Error(), string(cmdOut))
+		return fmt.Errorf("removing boot entry Boot%s: %w", v.Num, err)
+	}
 	return nil
 }
 
+// setBootOrder sets the given boot numbers first in the boot order, other boot numbers keep their relative order
+func (b *EfiBootManager) setBootOrder(state *BootState, first []string) error {
+	order := slices.Clone(first)
+	for _, num := range state.Order {
+		if !slices.Contains(order, num) {
+			order = append(order, num)
+		}
+	}
+	if len(first) == 0 || slices.Equal(order, state.Order) {
+		return nil
+	}
+
+	cmdOut, err := b.s.Runner().Run("efibootmgr", "--bootorder", strings.Join(order, ","))
+	if err != nil {
+		b.s.Logger().Error("failed setting boot order (%s): %s", err.Error(), string(cmdOut))
+		return fmt.Errorf("setting boot order: %w", err)
+	}
+	return nil
+}
+
+// sortedByOrder returns the boot variables listed in boot order first
+func (bs BootState) sortedByOrder() []*BootVariable {
+	vars := slices.Clone(bs.Variables)
+	index := func(v *BootVariable) int {
+		if i := slices.Index(bs.Order, v.Num); i >= 0 {
+			return i
+		}
+		return len(bs.Order)
+	}
+	slices.SortStableFunc(vars, func(a, b *BootVariable) int {
+		return index(a) - index(b)
+	})
+	return vars
+}
+
+// isElementalVariable checks if the given boot variable was created by elemental
+func isElementalVariable(v *BootVariable) bool {
+	return v.Label == EfiBootEntryName || strings.HasPrefix(strings.ToUpper(v.Loader), EfiEntryPath+"/")
+}
+
+// parseBootState parses the output of 'efibootmgr --verbose'. Device paths are printed as
+// 'HD(...)/File(\EFI\...)' by efibootmgr up to v17 and as 'HD(...)/\EFI\...' since v18.
+func parseBootState(out []byte) *BootState {
+	state := &BootState{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "BootCurrent:"):
+			state.Current = strings.TrimSpace(strings.TrimPrefix(line, "BootCurrent:"))
+		case strings.HasPrefix(line, "BootOrder:"):
+			for num := range strings.SplitSeq(strings.TrimPrefix(line, "BootOrder:"), ",") {
+				if num = strings.TrimSpace(num); num != "" {
+					state.Order = append(state.Order, num)
+				}
+			}
+		default:
+			match := bootVarRegexp.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			label, path, _ := strings.Cut(match[3], "\t")
+			v := &BootVariable{
+				Num:    match[1],
+				Active: match[2] == "*",
+				Label:  strings.TrimSpace(label),
+			}
+			if hd := hdPathRegexp.FindStringSubmatch(path); hd != nil {
+				v.PartUUID = hd[1]
+			}
+			if file := filePathRegexp.FindStringSubmatch(path); file != nil {
+				v.Loader = normalizeLoader(file[1])
+			} else if _, file, ok := strings.Cut(path, ")/\\"); ok && strings.TrimSpace(file) != "" {
+				v.Loader = normalizeLoader("\\" + strings.Fields(file)[0])
+			}
+			state.Variables = append(state.Variables, v)
+		}
+	}
+	return state
+}
+
+// normalizeLoader returns the given EFI loader path as an absolute path using forward slashes
+func normalizeLoader(loader string) string {
+	return filepath.Join("/", strings.ReplaceAll(loader, "\\", "/"))
+}
+
 // DefaultBootEntry generates the default EFI boot entry for the platform.
 func DefaultBootEntry(p *platform.Platform, disk string) *EfiBootEntry {
 	efiImgName := ""
@@ -0,0 +1,213 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmware_test
+
+import (
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestFirmwareSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Firmware test suite")
+}
+
+// efibootmgr v18 output format
+const bootState = "BootCurrent: 0001\n" +
+	"Timeout: 0 seconds\n" +
+	"BootOrder: 0000,0001,0003\n" +
+	"Boot0000* UiApp\tFvVol(7cb8bdc9-f8eb-4f34-aaea-3ee4af6516a1)/FvFile(462caa21-7614-4503-836e-8ab6f4662331)\n" +
+	"Boot0001* elemental-shim\tHD(1,GPT,3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60,0x800,0x200000)/\\EFI\\ELEMENTAL\\bootx64.efi\n" +
+	"Boot0003* elemental-shim\tHD(1,GPT,3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60,0x800,0x200000)/\\EFI\\ELEMENTAL\\bootx64.efi\n" +
+	"Boot0004  elemental-shim\tHD(1,GPT,aaaaaaaa-0c3f-4a5e-9a0e-1c2b3d4e5f60,0x800,0x200000)/\\EFI\\ELEMENTAL\\bootx64.efi\n"
+
+const lsblkSda = `{"blockdevices": [
+	{"partuuid": "3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60", "path": "/dev/sda1", "pkname": "/dev/sda", "type": "part"}
+]}`
+
+const lsblkSdb = `{"blockdevices": [
+	{"partuuid": "aaaaaaaa-0c3f-4a5e-9a0e-1c2b3d4e5f60", "path": "/dev/sdb1", "pkname": "/dev/sdb", "type": "part"}
+]}`
+
+// efibootmgr v17 output format
+const legacyBootState = "BootCurrent: 0002\n" +
+	"BootOrder: 0002\n" +
+	"Boot0002* elemental-shim\tHD(1,GPT,3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60,0x800,0x200000)/File(\\EFI\\ELEMENTAL\\bootx64.efi)\n"
+
+var _ = Describe("EfiBootManager", Label("firmware"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var runner *sysmock.Runner
+	var state string
+	var bm *firmware.EfiBootManager
+
+	entry := &firmware.EfiBootEntry{
+		Label: firmware.EfiBootEntryName, Loader: "/EFI/ELEMENTAL/bootx64.efi", Disk: "/dev/sda",
+	}
+
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{"/dev/sda1": []byte{}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.MkdirAll(tfs, "/dev/disk/by-partuuid", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.Symlink("../../sda1", "/dev/disk/by-partuuid/3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60")).To(Succeed())
+
+		state = bootState
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "efibootmgr" && slices.Contains(args, "--verbose") {
+				return []byte(state), nil
+			}
+			if cmd == "lsblk" {
+				return lsblkOutput(args...), nil
+			}
+			return nil, nil
+		}
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		bm = firmware.NewEfiBootManager(s)
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("reads the boot variables", func() {
+		bs, err := bm.ReadBootState()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bs.Current).To(Equal("0001"))
+		Expect(bs.Order).To(Equal([]string{"0000", "0001", "0003"}))
+		Expect(bs.Variables).To(HaveLen(4))
+		Expect(*bs.Variables[0]).To(Equal(firmware.BootVariable{Num: "0000", Label: "UiApp", Active: true}))
+		Expect(*bs.Variables[3]).To(Equal(firmware.BootVariable{
+			Num: "0004", Label: "elemental-shim", Loader: "/EFI/ELEMENTAL/bootx64.efi",
+			PartUUID: "aaaaaaaa-0c3f-4a5e-9a0e-1c2b3d4e5f60",
+		}))
+
+		state = legacyBootState
+		bs, err = bm.ReadBootState()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*bs.Variables[0]).To(Equal(firmware.BootVariable{
+			Num: "0002", Label: "elemental-shim", Active: true, Loader: "/EFI/ELEMENTAL/bootx64.efi",
+			PartUUID: "3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60",
+		}))
+	})
+	It("reuses existing boot entries and removes their duplicates", func() {
+		Expect(bm.CreateBootEntries([]*firmware.EfiBootEntry{entry})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"efibootmgr", "--create"}})).NotTo(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"efibootmgr", "--verbose"},
+			{"lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE", "/dev/sda"},
+			{"efibootmgr", "--bootnum", "0003", "--delete-bootnum"},
+			{"efibootmgr", "--bootorder", "0001,0000"},
+		})).To(Succeed())
+	})
+	It("creates missing boot entries and sets them first in boot order", func() {
+		state = "BootOrder: 0000\nBoot0000* UiApp\tFvVol(7cb8bdc9)/FvFile(462caa21)\n"
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if slices.Contains(args, "--create") {
+				state = "BootOrder: 0005,0000\nBoot0000* UiApp\tFvVol(7cb8bdc9)/FvFile(462caa21)\n" +
+					"Boot0005* elemental-shim\tHD(1,GPT,3a9a3c5e-0c3f-4a5e-9a0e-1c2b3d4e5f60,0x800,0x200000)/\\EFI\\ELEMENTAL\\bootx64.efi\n"
+			}
+			if slices.Contains(args, "--verbose") {
+				return []byte(state), nil
+			}
+			if cmd == "lsblk" {
+				return lsblkOutput(args...), nil
+			}
+			return nil, nil
+		}
+		Expect(bm.CreateBootEntries([]*firmware.EfiBootEntry{entry})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"efibootmgr", "--verbose"},
+			{"lsblk", "-p", "-b", "-n", "-J", "--output"},
+			{"efibootmgr", "--create", "--disk", "/dev/sda", "--label", "elemental-shim", "--loader", "/EFI/ELEMENTAL/bootx64.efi"},
+			{"efibootmgr", "--verbose"},
+			{"lsblk", "-p", "-b", "-n", "-J", "--output"},
+		})).To(Succeed())
+	})
+	It("ignores boot entries in other disks", func() {
+		Expect(tfs.Symlink("../../sdb1", "/dev/disk/by-partuuid/aaaaaaaa-0c3f-4a5e-9a0e-1c2b3d4e5f60")).To(Succeed())
+		other := *entry
+		other.Disk = "/dev/sdb"
+		Expect(bm.CreateBootEntries([]*firmware.EfiBootEntry{&other})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"efibootmgr", "--verbose"},
+			{"lsblk", "-p", "-b", "-n", "-J", "--output", "LABEL,PARTLABEL,PARTUUID,SIZE,FSTYPE,MOUNTPOINTS,PATH,PKNAME,TYPE", "/dev/sdb"},
+			{"efibootmgr", "--bootorder", "0004,0000,0001,0003"},
+		})).To(Succeed())
+	})
+	It("resolves disk links and does not match disks by name prefix", func() {
+		Expect(vfs.MkdirAll(tfs, "/dev/disk/by-id", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/dev/sdb", []byte{}, vfs.FilePerm)).To(Succeed())
+		Expect(tfs.Symlink("../../sdb", "/dev/disk/by-id/nvme-some-disk")).To(Succeed())
+		other := *entry
+		other.Disk = "/dev/disk/by-id/nvme-some-disk"
+		Expect(bm.CreateBootEntries([]*firmware.EfiBootEntry{&other})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"lsblk", "-p", "-b", "-n", "-J", "--output"}})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"efibootmgr", "--bootorder", "0004,0000,0001,0003"},
+		})).To(Succeed())
+
+		// sda partitions are not in sdaa
+		runner.ClearCmds()
+		other.Disk = "/dev/sdaa"
+		Expect(bm.CreateBootEntries([]*firmware.EfiBootEntry{&other})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"efibootmgr", "--create", "--disk", "/dev/sdaa"},
+		})).To(Succeed())
+	})
+	It("prunes stale elemental boot entries", func() {
+		stale, err := bm.PruneBootEntries(true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stale).To(HaveLen(2))
+		Expect(stale[0].Num).To(Equal("0003"))
+		Expect(stale[1].Num).To(Equal("0004"))
+		Expect(runner.IncludesCmds([][]string{{"efibootmgr", "--bootnum"}})).NotTo(Succeed())
+
+		_, err = bm.PruneBootEntries(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.IncludesCmds([][]string{
+			{"efibootmgr", "--bootnum", "0003", "--delete-bootnum"},
+			{"efibootmgr", "--bootnum", "0004", "--delete-bootnum"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"efibootmgr", "--bootnum", "0001"}})).NotTo(Succeed())
+	})
+})
+
+// lsblkOutput returns the partitions of the disk given in the lsblk arguments
+func lsblkOutput(args ...string) []byte {
+	switch args[len(args)-1] {
+	case "/dev/sda":
+		return []byte(lsblkSda)
+	case "/dev/sdb":
+		return []byte(lsblkSdb)
+	}
+	return []byte(`{"blockdevices": []}`)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grubenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// BlockSize is the size of the environment block created by GRUB
+	BlockSize = 1024
+
+	signature = "# GRUB Environment Block\n"
+	padding   = '#'
+)
+
+// ErrTooLarge is returned when the variables do not fit in the environment block
+var ErrTooLarge = errors.New("variables exceed the grubenv block size")
+
+// Env is a GRUB environment block. It keeps the order of the variables as found in the
+// parsed block, new variables are appended at the end.
+type Env struct {
+	keys []string
+	vars map[string]string
+}
+
+// New returns an empty environment block
+func New() *Env {
+	return &Env{vars: map[string]string{}}
+}
+
+// Parse parses the given GRUB environment block. Backslash escaped characters are unescaped
+// and padding lines are ignored.
+func Parse(data []byte) (*Env, error) {
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, fmt.Errorf("invalid grubenv block: missing signature")
+	}
+
+	env := New()
+	data = data[len(signature):]
+	for len(data) > 0 {
+		line, rest, err := nextLine(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		if line == "" || line[0] == padding {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			continue
+		}
+		env.Set(key, value)
+	}
+	return env, nil
+}
+
+// nextLine returns the unescaped content up to the first unescaped new line and the remaining data
+func nextLine(data []byte) (string, []byte, error) {
+	var line strings.Builder
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+			if i == len(data) {
+				return "", nil, fmt.Errorf("invalid grubenv block: unterminated escape sequence")
+			}
+			line.WriteByte(data[i])
+		case '\n':
+			return line.String(), data[i+1:], nil
+		default:
+			line.WriteByte(data[i])
+		}
+	}
+	return line.String(), nil, nil
+}
+
+// Get returns the value of the given variable and whether it is defined
+func (e *Env) Get(key string) (string, bool) {
+	value, ok := e.vars[key]
+	return value, ok
+}
+
+// Set sets the value of the given variable
+func (e *Env) Set(key, value string) {
+	if _, ok := e.vars[key]; !ok {
+		e.keys = append(e.keys, key)
+	}
+	e.vars[key] = value
+}
+
+// Unset removes the given variable
+func (e *Env) Unset(key string) {
+	if _, ok := e.vars[key]; !ok {
+		return
+	}
+	delete(e.vars, key)
+	e.keys = slices.DeleteFunc(e.keys, func(k string) bool { return k == key })
+}
+
+// Vars returns a copy of all the variables in the environment block
+func (e *Env) Vars() map[string]string {
+	return maps.Clone(e.vars)
+}
+
+// Bytes renders the environment block padded to BlockSize. It fails if any variable
+// name is invalid or if the variables do not fit in the block.
+func (e *Env) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(signature)
+	for _, key := range e.keys {
+		if key == "" || strings.ContainsAny(key, "=\n\\") {
+			return nil, fmt.Errorf("invalid grubenv variable name '%s'", key)
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		for _, c := range []byte(e.vars[key]) {
+			if c == '\\' || c == '\n' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(c)
+		}
+		buf.WriteByte('\n')
+	}
+	if buf.Len() > BlockSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, buf.Len())
+	}
+	buf.Write(bytes.Repeat([]byte{padding}, BlockSize-buf.Len()))
+	return buf.Bytes(), nil
+}
+
+// Read reads and parses the GRUB environment block at the given path
+func Read(s *sys.System, path string) (*Env, error) {
+	data, err := s.FS().ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading grubenv '%s': %w", path, err)
+	}
+	env, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing grubenv '%s': %w", path, err)
+	}
+	return env, nil
+}
+
+// Write writes the environment block to the given path. The block is written to a
+// temporary file first and then renamed over the target, so readers never see a partial write.
+func Write(s *sys.System, path string, env *Env) (err error) {
+	data, err := env.Bytes()
+	if err != nil {
+		return fmt.Errorf("rendering grubenv '%s': %w", path, err)
+	}
+
+	tmpFile := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.new", filepath.Base(path)))
+	err = s.FS().WriteFile(tmpFile, data, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing grubenv '%s': %w", tmpFile, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = s.FS().Remove(tmpFile)
+		}
+	}()
+
+	err = s.FS().Rename(tmpFile, path)
+	if err != nil {
+		return fmt.Errorf("replacing grubenv '%s': %w", path, err)
+	}
+	return nil
+}
+
+// Set sets the given variables in the environment block at the given path, keeping any other
+// variable already defined. The block is created if it does not exist.
+func Set(s *sys.System, path string, vars map[string]string) error {
+	env := New()
+	if ok, _ := vfs.Exists(s.FS(), path); ok {
+		var err error
+		env, err = Read(s, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(vars)) {
+		env.Set(key, vars[key])
+	}
+	return Write(s, path, env)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grubenv_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/grubenv"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestGrubenvSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Grubenv test suite")
+}
+
+const grubEnvBlock = "# GRUB Environment Block\n" +
+	"entries=active 2 recovery\n" +
+	"cmdline=console=tty0 \\\\d\\\n\n" +
+	"next_entry=next\n"
+
+var _ = Describe("Grubenv", Label("grubenv"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var err error
+	BeforeEach(func() {
+		block := grubEnvBlock + strings.Repeat("#", grubenv.BlockSize-len(grubEnvBlock))
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{"/boot/grubenv": block})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("parses escaped values and ignores padding", func() {
+		env, err := grubenv.Read(s, "/boot/grubenv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env.Vars()).To(Equal(map[string]string{
+			"entries":    "active 2 recovery",
+			"cmdline":    "console=tty0 \\d\n",
+			"next_entry": "next",
+		}))
+	})
+	It("fails to parse a block without signature", func() {
+		_, err := grubenv.Parse([]byte("entries=active\n"))
+		Expect(err).To(MatchError(ContainSubstring("missing signature")))
+	})
+	It("renders a padded block keeping the order of the variables", func() {
+		env, err := grubenv.Read(s, "/boot/grubenv")
+		Expect(err).NotTo(HaveOccurred())
+		env.Unset("next_entry")
+		env.Set("entries", "active 3 2 recovery")
+		env.Set("timeout", "5")
+
+		data, err := env.Bytes()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveLen(grubenv.BlockSize))
+		Expect(string(data)).To(HavePrefix("# GRUB Environment Block\n" +
+			"entries=active 3 2 recovery\n" +
+			"cmdline=console=tty0 \\\\d\\\n\n" +
+			"timeout=5\n#"))
+	})
+	It("fails to render variables exceeding the block size", func() {
+		env := grubenv.New()
+		env.Set("cmdline", strings.Repeat("a", grubenv.BlockSize))
+		_, err := env.Bytes()
+		Expect(err).To(MatchError(grubenv.ErrTooLarge))
+
+		env = grubenv.New()
+		env.Set("invalid=name", "value")
+		_, err = env.Bytes()
+		Expect(err).To(MatchError(ContainSubstring("invalid grubenv variable name")))
+	})
+	It("sets variables keeping the existing ones", func() {
+		Expect(grubenv.Set(s, "/boot/grubenv", map[string]string{"next_entry": "", "timeout": "5"})).To(Succeed())
+
+		env, err := grubenv.Read(s, "/boot/grubenv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env.Vars()).To(HaveKeyWithValue("entries", "active 2 recovery"))
+		Expect(env.Vars()).To(HaveKeyWithValue("next_entry", ""))
+		Expect(env.Vars()).To(HaveKeyWithValue("timeout", "5"))
+		Expect(vfs.Exists(tfs, "/boot/.grubenv.new")).To(BeFalse())
+	})
+	It("creates the block if it does not exist", func() {
+		Expect(vfs.MkdirAll(tfs, "/boot/loader/entries", vfs.DirPerm)).To(Succeed())
+		Expect(grubenv.Set(s, "/boot/loader/entries/1", map[string]string{"cmdline": "quiet"})).To(Succeed())
+
+		data, err := tfs.ReadFile("/boot/loader/entries/1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveLen(grubenv.BlockSize))
+		Expect(string(data)).To(HavePrefix("# GRUB Environment Block\ncmdline=quiet\n#"))
+	})
+})
@@ -0,0 +1,316 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+const (
+	efiFirmwarePath = "/sys/firmware/efi"
+	secureBootVar   = firmware.EfivarsMountPath + "/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+	memInfoPath     = "/proc/meminfo"
+
+	// partitionTableSize is the space reserved for the GPT headers and the partitions alignment
+	partitionTableSize deployment.MiB = 2
+)
+
+// ErrPreflightChecks is returned when the host does not meet the installation requirements
+var ErrPreflightChecks = errors.New("preflight checks failed")
+
+type checkResult struct {
+	name   string
+	detail string
+	err    error
+	// warn reports a failure without failing the checks
+	warn bool
+}
+
+// runPreflightChecks verifies the host meets the requirements of the given deployment and logs a report
+// of the results. Failed checks only issue a warning if the installer is set to skip checks.
+func (i Installer) runPreflightChecks(d *deployment.Deployment) error {
+	var results []checkResult
+
+	for _, disk := range d.Disks {
+		results = append(results, i.checkDiskSize(disk))
+	}
+	results = append(results, i.checkLabelConflicts(d))
+
+	if c := d.Checks; c != nil {
+		if c.MinMemory > 0 {
+			results = append(results, i.checkMemory(c.MinMemory))
+		}
+		if c.UEFI {
+			results = append(results, i.checkUEFI())
+		}
+		if c.SecureBoot != "" {
+			results = append(results, i.checkSecureBoot(c.SecureBoot))
+		}
+	}
+	results = append(results, i.checkArch(d))
+
+	var errs []error
+	i.s.Logger().Info("Preflight checks report:")
+	for _, r := range results {
+		if r.err != nil && r.warn {
+			i.s.Logger().Warn("  WARN %s: %v", r.name, r.err)
+			continue
+		}
+		if r.err != nil {
+			i.s.Logger().Warn("  FAIL %s: %v", r.name, r.err)
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, r.err))
+			continue
+		}
+		i.s.Logger().Info("  PASS %s: %s", r.name, r.detail)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if i.skipChecks {
+		i.s.Logger().Warn("Ignoring %d failed preflight checks", len(errs))
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrPreflightChecks, errors.Join(errs...))
+}
+
+// checkDiskSize checks the given disk is big enough for all its partitions
+func (i Installer) checkDiskSize(disk *deployment.Disk) checkResult {
+	r := checkResult{name: fmt.Sprintf("disk size of '%s'", disk.Device)}
+
+	required := partitionTableSize
+	for _, part := range disk.Partitions {
+		required += part.Size
+	}
+
+	out, err := i.s.Runner().Run("lsblk", "-b", "-d", "-n", "-o", "SIZE", disk.Device)
+	if err != nil {
+		r.err = fmt.Errorf("could not determine the disk size: %w", err)
+		return r
+	}
+	bytes, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		r.err = fmt.Errorf("could not parse the disk size '%s': %w", strings.TrimSpace(string(out)), err)
+		return r
+	}
+	size := deployment.MiB(bytes / (1024 * 1024))
+
+	if size < required {
+		r.err = fmt.Errorf("%dMiB available, at least %dMiB required", size, required)
+		return r
+	}
+	r.detail = fmt.Sprintf("%dMiB available, %dMiB required", size, required)
+	return r
+}
+
+// checkMemory checks the host has at least the given amount of RAM
+func (i Installer) checkMemory(minimum deployment.MiB) checkResult {
+	r := checkResult{name: "memory"}
+
+	data, err := i.s.FS().ReadFile(memInfoPath)
+	if err != nil {
+		r.err = fmt.Errorf("could not read '%s': %w", memInfoPath, err)
+		return r
+	}
+
+	var total deployment.MiB
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				r.err = fmt.Errorf("could not parse total memory '%s': %w", fields[1], err)
+				return r
+			}
+			total = deployment.MiB(kb / 1024)
+			break
+		}
+	}
+
+	if total < minimum {
+		r.err = fmt.Errorf("%dMiB available, at least %dMiB required", total, minimum)
+		return r
+	}
+	r.detail = fmt.Sprintf("%dMiB available, %dMiB required", total, minimum)
+	return r
+}
+
+// checkUEFI checks the host is booted in UEFI mode
+func (i Installer) checkUEFI() checkResult {
+	r := checkResult{name: "boot mode"}
+	if ok, _ := vfs.Exists(i.s.FS(), efiFirmwarePath); !ok {
+		r.err = fmt.Errorf("host is not booted in UEFI mode")
+		return r
+	}
+	r.detail = "UEFI"
+	return r
+}
+
+// checkSecureBoot checks the Secure Boot state of the host matches the given one
+func (i Installer) checkSecureBoot(state string) checkResult {
+	r := checkResult{name: "secure boot"}
+
+	current := "disabled"
+	// EFI variables are prefixed by 4 bytes of attributes
+	if data, err := i.s.FS().ReadFile(secureBootVar); err == nil && len(data) > 4 && data[4] == 1 {
+		current = "enabled"
+	}
+
+	if current != state {
+		r.err = fmt.Errorf("secure boot is %s, expected %s", current, state)
+		return r
+	}
+	r.detail = current
+	return r
+}
+
+// checkArch checks the host CPU matches the architecture of the OS image. The architecture is detected from
+// the OS image unless it is explicitly set in the checks, the check is only a warning if it can't be detected.
+func (i Installer) checkArch(d *deployment.Deployment) checkResult {
+	r := checkResult{name: "architecture"}
+
+	var arch string
+	if d.Checks != nil {
+		arch = d.Checks.Arch
+	}
+	if arch == "" {
+		detected, err := i.imageArch(d.SourceOS)
+		if err != nil {
+			r.err = fmt.Errorf("could not detect the OS image architecture: %w", err)
+			r.warn = true
+			return r
+		}
+		arch = detected
+	}
+
+	out, err := i.s.Runner().Run("uname", "-m")
+	if err != nil {
+		r.err = fmt.Errorf("could not determine the host architecture: %w", err)
+		return r
+	}
+	host, err := platform.NewFromArch(strings.TrimSpace(string(out)))
+	if err != nil {
+		r.err = fmt.Errorf("unsupported host architecture: %w", err)
+		return r
+	}
+	expected, err := platform.NewFromArch(arch)
+	if err != nil {
+		r.err = fmt.Errorf("unsupported OS image architecture: %w", err)
+		return r
+	}
+
+	if host.Arch != expected.Arch {
+		r.err = fmt.Errorf("host is %s, the OS image is built for %s", host.Arch, expected.Arch)
+		return r
+	}
+	r.detail = host.Arch
+	return r
+}
+
+// imageArch returns the architecture of the given OS image source without unpacking it
+func (i Installer) imageArch(src *deployment.ImageSource) (string, error) {
+	if src == nil || src.IsEmpty() {
+		return "", fmt.Errorf("no OS image source defined")
+	}
+	u, err := unpack.NewUnpacker(i.s, src, i.unpackOpts...)
+	if err != nil {
+		return "", err
+	}
+	detector, ok := u.(unpack.ArchDetector)
+	if !ok {
+		return "", fmt.Errorf("not supported for '%s'", src.String())
+	}
+	return detector.Arch(i.ctx)
+}
+
+// checkLabelConflicts checks no partition outside the target disks uses any of the deployment partition labels.
+// Lookups by label might pick the wrong partition otherwise. The installer media is not considered, as it
+// is expected to include partitions like EFI or RECOVERY and it is not present once installed.
+func (i Installer) checkLabelConflicts(d *deployment.Deployment) checkResult {
+	r := checkResult{name: "partition labels", warn: d.Checks == nil || d.Checks.LabelConflicts != "abort"}
+
+	var labels []string
+	for _, disk := range d.Disks {
+		for _, part := range disk.Partitions {
+			if part.Label != "" {
+				labels = append(labels, part.Label)
+			}
+		}
+	}
+
+	bDev := lsblk.NewLsDevice(i.s)
+	excluded := map[string]bool{}
+	for _, disk := range d.Disks {
+		excluded[disk.Device] = true
+		parts, err := bDev.GetDevicePartitions(disk.Device)
+		if err != nil {
+			r.err = fmt.Errorf("could not list partitions of '%s': %w", disk.Device, err)
+			return r
+		}
+		for _, part := range parts {
+			excluded[part.Path] = true
+		}
+	}
+
+	parts, err := bDev.GetAllPartitions()
+	if err != nil {
+		r.err = fmt.Errorf("could not list host partitions: %w", err)
+		return r
+	}
+
+	for _, part := range parts {
+		if slices.Contains(part.MountPoints, installer.LiveMountPoint) {
+			excluded[part.Path] = true
+			if part.Disk != "" {
+				excluded[part.Disk] = true
+			}
+		}
+	}
+
+	var conflicts []string
+	for _, part := range parts {
+		if excluded[part.Path] || excluded[part.Disk] {
+			continue
+		}
+		if slices.Contains(labels, part.Label) {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", part.Path, part.Label))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		r.err = fmt.Errorf("partitions of other disks use the same labels: %s", strings.Join(conflicts, ", "))
+		return r
+	}
+	r.detail = "no conflicts"
+	return r
+}
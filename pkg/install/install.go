@@ -29,7 +29,9 @@ import (
 	"github.com/suse/elemental/v3/pkg/btrfs"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/lvm"
 	"github.com/suse/elemental/v3/pkg/repart"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -45,6 +47,7 @@ type Installer struct {
 	u          upgrade.Interface
 	unpackOpts []unpack.Opt
 	b          bootloader.Bootloader
+	skipChecks bool
 }
 
 func WithUnpackOpts(opts ...unpack.Opt) Option {
@@ -65,6 +68,13 @@ func WithBootloader(b bootloader.Bootloader) Option {
 	}
 }
 
+// WithSkipChecks sets the installer to only warn about failed preflight checks
+func WithSkipChecks(skip bool) Option {
+	return func(i *Installer) {
+		i.skipChecks = skip
+	}
+}
+
 func New(ctx context.Context, s *sys.System, opts ...Option) *Installer {
 	installer := &Installer{
 		s:   s,
@@ -115,7 +125,16 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 		return err
 	}
 
+	err = i.runPreflightChecks(d)
+	if err != nil {
+		return err
+	}
+
 	for _, disk := range d.Disks {
+		err = i.wipeDisk(disk)
+		if err != nil {
+			return fmt.Errorf("wiping disk '%s': %w", disk.Device, err)
+		}
 		err = repart.PartitionAndFormatDevice(i.s, disk)
 		if err != nil {
 			return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
@@ -126,6 +145,10 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 			if err != nil {
 				return fmt.Errorf("creating partition volumes: %w", err)
 			}
+			err = createVolumeGroup(i.s, part, false)
+			if err != nil {
+				return fmt.Errorf("creating LVM volumes: %w", err)
+			}
 		}
 	}
 
@@ -157,6 +180,10 @@ func (i Installer) Reset(d *deployment.Deployment) (err error) {
 			if err != nil {
 				return fmt.Errorf("creating partition volumes: %w", err)
 			}
+			err = createVolumeGroup(i.s, part, true)
+			if err != nil {
+				return fmt.Errorf("creating LVM volumes: %w", err)
+			}
 		}
 	}
 
@@ -268,3 +295,51 @@ func createPartitionVolumes(s *sys.System, cleanStack *cleanstack.CleanStack, pa
 
 	return nil
 }
+
+// createVolumeGroup sets the LVM physical volume, volume group and the formatted logical volumes of
+// the given 'lvm' partition. If keepExisting is set and the volume group is already present it is
+// activated and only the missing logical volumes are created, existing data is preserved.
+func createVolumeGroup(s *sys.System, part *deployment.Partition, keepExisting bool) error {
+	vg := part.VolumeGroup
+	if part.Role != deployment.LVM || vg == nil {
+		return nil
+	}
+
+	if keepExisting && lvm.VolumeGroupExists(s, vg.Name) {
+		s.Logger().Info("Keeping existing volume group '%s'", vg.Name)
+		err := lvm.ActivateVolumeGroup(s, vg.Name)
+		if err != nil {
+			return err
+		}
+	} else {
+		bPart, err := block.GetPartitionByUUID(s, lsblk.NewLsDevice(s), part.UUID, 4)
+		if err != nil {
+			return fmt.Errorf("finding partition '%s': %w", part.UUID, err)
+		}
+		err = lvm.CreatePhysicalVolume(s, bPart.Path)
+		if err != nil {
+			return err
+		}
+		err = lvm.CreateVolumeGroup(s, vg.Name, bPart.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, lv := range vg.LogicalVolumes {
+		device := lvm.DevicePath(vg.Name, lv.Name)
+		if ok, _ := vfs.Exists(s.FS(), device); ok && keepExisting {
+			s.Logger().Debug("Logical volume '%s' already exists", device)
+			continue
+		}
+		err := lvm.CreateLogicalVolume(s, vg.Name, lv.Name, uint(lv.Size))
+		if err != nil {
+			return err
+		}
+		err = filesystem.NewMkfsCall(s, device, lv.FileSystem.String(), lv.Label, "").Apply()
+		if err != nil {
+			return fmt.Errorf("formatting logical volume '%s': %w", device, err)
+		}
+	}
+	return nil
+}
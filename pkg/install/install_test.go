@@ -18,9 +18,14 @@ limitations under the License.
 package install_test
 
 import (
+	"bytes"
 	"context"
+	"debug/elf"
+	"encoding/binary"
 	"fmt"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -31,6 +36,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
@@ -145,7 +151,13 @@ var _ = Describe("Install", Label("install"), func() {
 		sideEffects["systemd-repart"] = func(args ...string) ([]byte, error) {
 			return []byte(systemdRepartJson), runner.ReturnError
 		}
+		sideEffects["uname"] = func(args ...string) ([]byte, error) {
+			return []byte(s.Platform().Arch + "\n"), runner.ReturnError
+		}
 		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "SIZE") {
+				return []byte("34359738368\n"), runner.ReturnError
+			}
 			if slices.Contains(args, "NAME,PHY-SEC") {
 				return []byte(sectorSizeJson), runner.ReturnError
 			}
@@ -220,4 +232,276 @@ var _ = Describe("Install", Label("install"), func() {
 			{"btrfs", "subvolume", "create"},
 		}))
 	})
+	Describe("preflight checks", func() {
+		BeforeEach(func() {
+			Expect(vfs.MkdirAll(fs, "/proc", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/proc/meminfo", []byte("MemTotal:        4030184 kB\nMemFree:         1217980 kB\n"), vfs.FilePerm)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/sys/firmware/efi/efivars", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile(
+				"/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c",
+				[]byte{0x06, 0x00, 0x00, 0x00, 0x01}, vfs.FilePerm,
+			)).To(Succeed())
+			d.Checks = &deployment.PreflightChecks{
+				MinMemory:  2048,
+				UEFI:       true,
+				SecureBoot: "enabled",
+			}
+		})
+		It("installs if the host meets the requirements", func() {
+			deployment.WithRecoveryPartition(0)(d)
+			Expect(i.Install(d)).To(Succeed())
+			// the architecture is not detected from an OS image without binaries
+			Expect(runner.IncludesCmds([][]string{{"uname", "-m"}})).NotTo(Succeed())
+		})
+		It("installs if the host architecture matches the detected OS image architecture", func() {
+			deployment.WithRecoveryPartition(0)(d)
+			writeELF(fs, "/some/dir/usr/lib/systemd/systemd", s.Platform().Arch)
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"uname", "-m"}})).To(Succeed())
+		})
+		It("fails if the host architecture does not match the detected OS image architecture", func() {
+			arch := platform.ArchAarch64
+			if s.Platform().Arch == platform.ArchAarch64 {
+				arch = platform.Archx86
+			}
+			writeELF(fs, "/some/dir/usr/lib/systemd/systemd", arch)
+			d.Checks = nil
+			expected, err := platform.NewFromArch(arch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(i.Install(d)).To(MatchError(ContainSubstring("the OS image is built for " + expected.Arch)))
+			Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+		})
+		It("installs if the host architecture matches the OS image", func() {
+			deployment.WithRecoveryPartition(0)(d)
+			d.Checks.Arch = s.Platform().Arch
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"uname", "-m"}})).To(Succeed())
+		})
+		It("fails before partitioning if the host does not meet the requirements", func() {
+			d.Checks.MinMemory = 8192
+			d.Checks.SecureBoot = "disabled"
+			d.Disks[0].Partitions[1].Size = 65536
+			err := i.Install(d)
+			Expect(err).To(MatchError(install.ErrPreflightChecks))
+			Expect(err).To(MatchError(ContainSubstring("memory: 3935MiB available, at least 8192MiB required")))
+			Expect(err).To(MatchError(ContainSubstring("secure boot: secure boot is enabled, expected disabled")))
+			Expect(err).To(MatchError(ContainSubstring("disk size of '/dev/device': 32768MiB available")))
+			Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+		})
+		It("fails if the host is not booted in UEFI mode", func() {
+			Expect(fs.RemoveAll("/sys/firmware/efi")).To(Succeed())
+			d.Checks.SecureBoot = ""
+			Expect(i.Install(d)).To(MatchError(ContainSubstring("boot mode: host is not booted in UEFI mode")))
+		})
+		It("fails if the host architecture does not match the OS image", func() {
+			d.Checks.Arch = "riscv64"
+			Expect(i.Install(d)).To(MatchError(ContainSubstring("the OS image is built for riscv64")))
+		})
+		It("checks other disks for conflicting partition labels", func() {
+			otherDisk := strings.Replace(lsblkJson, `"blockdevices": [`,
+				`"blockdevices": [{"label": "SYSTEM", "size": 1048576, "path": "/dev/sdb2", "pkname": "/dev/sdb", "type": "part"},`, 1,
+			)
+			sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+				switch {
+				case slices.Contains(args, "SIZE"):
+					return []byte("34359738368\n"), nil
+				case slices.Contains(args, "NAME,PHY-SEC"):
+					return []byte(sectorSizeJson), nil
+				case slices.Contains(args, "/dev/device"):
+					return []byte(`{"blockdevices": []}`), nil
+				}
+				return []byte(otherDisk), nil
+			}
+
+			By("only warning by default")
+			deployment.WithRecoveryPartition(0)(d)
+			Expect(i.Install(d)).To(Succeed())
+
+			By("aborting if requested")
+			runner.ClearCmds()
+			d.Checks.LabelConflicts = "abort"
+			err := i.Install(d)
+			Expect(err).To(MatchError(install.ErrPreflightChecks))
+			Expect(err).To(MatchError(ContainSubstring("partitions of other disks use the same labels: /dev/sdb2 (SYSTEM)")))
+			Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+		})
+		It("does not consider the installer media for conflicting partition labels", func() {
+			liveMedia := strings.Replace(lsblkJson, `"blockdevices": [`,
+				`"blockdevices": [{"label": "EFI", "size": 1048576, "path": "/dev/sdc1", "pkname": "/dev/sdc", "type": "part"},`+
+					`{"label": "RECOVERY", "size": 1048576, "mountpoints": ["/run/initramfs/live"], "path": "/dev/sdc2", "pkname": "/dev/sdc", "type": "part"},`, 1,
+			)
+			sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+				switch {
+				case slices.Contains(args, "SIZE"):
+					return []byte("34359738368\n"), nil
+				case slices.Contains(args, "NAME,PHY-SEC"):
+					return []byte(sectorSizeJson), nil
+				case slices.Contains(args, "/dev/device"):
+					return []byte(`{"blockdevices": []}`), nil
+				}
+				return []byte(liveMedia), nil
+			}
+
+			deployment.WithRecoveryPartition(0)(d)
+			d.Checks.LabelConflicts = "abort"
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).To(Succeed())
+		})
+		It("ignores failed checks if requested", func() {
+			deployment.WithRecoveryPartition(0)(d)
+			d.Checks.MinMemory = 8192
+			i = install.New(context.Background(), s, install.WithUpgrader(upgrader), install.WithSkipChecks(true))
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).To(Succeed())
+		})
+	})
+	Describe("disk wipe", func() {
+		BeforeEach(func() {
+			sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
+				switch {
+				case slices.Contains(args, "SIZE"):
+					return []byte("34359738368\n"), nil
+				case slices.Contains(args, "NAME,PHY-SEC"):
+					return []byte(sectorSizeJson), nil
+				case slices.Contains(args, "/dev/device"):
+					return []byte(`{"blockdevices": [{"label": "OLD", "path": "/dev/device1", "pkname": "/dev/device", "type": "part"}]}`), nil
+				}
+				return []byte(lsblkJson), nil
+			}
+			deployment.WithRecoveryPartition(0)(d)
+		})
+		It("wipes signatures, discards and zero fills the disk before partitioning", func() {
+			d.Disks[0].Wipe = &deployment.WipePolicy{Signatures: true, Discard: true, Erase: "zero"}
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"wipefs", "--all", "/dev/device1"},
+				{"wipefs", "--all", "/dev/device"},
+				{"blkdiscard", "--force", "/dev/device"},
+				{"blkdiscard", "--force", "--zeroout", "/dev/device"},
+				{"systemd-repart"},
+			})).To(Succeed())
+		})
+		It("deactivates LVM volume groups and RAID arrays before wiping", func() {
+			Expect(vfs.MkdirAll(fs, "/sys/class/block/device1/holders/md127", vfs.DirPerm)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/sys/class/block/md127/holders/dm-0", vfs.DirPerm)).To(Succeed())
+			sideEffects["pvs"] = func(args ...string) ([]byte, error) {
+				return []byte("  data\n"), nil
+			}
+			d.Disks[0].Wipe = &deployment.WipePolicy{Signatures: true}
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"pvs", "--noheadings", "-o", "vg_name", "/dev/md127"},
+				{"vgchange", "-an", "data"},
+				{"mdadm", "--stop", "/dev/md127"},
+				{"wipefs", "--all", "/dev/device1"},
+				{"wipefs", "--all", "/dev/device"},
+				{"systemd-repart"},
+			})).To(Succeed())
+		})
+		It("securely erases the disk", func() {
+			d.Disks[0].Wipe = &deployment.WipePolicy{Erase: "secure"}
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"blkdiscard", "--force", "--secure", "/dev/device"},
+				{"systemd-repart"},
+			})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"wipefs"}})).NotTo(Succeed())
+		})
+		It("does not wipe disks by default", func() {
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"wipefs"}})).NotTo(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"blkdiscard"}})).NotTo(Succeed())
+		})
+		It("fails if the disk can't be wiped", func() {
+			sideEffects["blkdiscard"] = func(args ...string) ([]byte, error) {
+				return nil, fmt.Errorf("operation not supported")
+			}
+			d.Disks[0].Wipe = &deployment.WipePolicy{Discard: true}
+			Expect(i.Install(d)).To(MatchError("wiping disk '/dev/device': discarding blocks of '/dev/device': operation not supported"))
+			Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+		})
+	})
+	Describe("LVM volumes", func() {
+		BeforeEach(func() {
+			d.Disks[0].Partitions[1].Size = 4096
+			d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+				Role: deployment.LVM,
+				VolumeGroup: &deployment.VolumeGroup{
+					Name: "data",
+					LogicalVolumes: []*deployment.LogicalVolume{
+						{Name: "logs", Label: "LOGS", Size: 1024, MountPoint: "/var/log/data"},
+						{Name: "db", MountPoint: "/srv/db"},
+					},
+				},
+			})
+			Expect(d.Sanitize(s)).To(Succeed())
+			sideEffects["systemd-repart"] = func(args ...string) ([]byte, error) {
+				return []byte(`[
+					{"uuid" : "c60d1845-7b04-4fc4-8639-8c49eb7277d5", "file" : "/tmp/elemental-repart.d/0-efi.conf"},
+					{"uuid" : "34a8abb8-ddb3-48a2-8ecc-2443e92c7510", "file" : "/tmp/elemental-repart.d/1-system.conf"},
+					{"uuid" : "ddb334a8-48a2-c4de-ddb3-849eb2443e92", "file" : "/tmp/elemental-repart.d/2-lvm.conf"}
+				]`), nil
+			}
+		})
+		It("creates the volume group and the logical volumes", func() {
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"systemd-repart"},
+				{"pvcreate", "--force", "--yes", "/dev/device2"},
+				{"vgcreate", "--yes", "data", "/dev/device2"},
+				{"lvcreate", "--yes", "--wipesignatures", "y", "--name", "logs", "--size", "1024M", "data"},
+				{"mkfs.ext4", "-L", "LOGS", "-F", "/dev/data/logs"},
+				{"lvcreate", "--yes", "--wipesignatures", "y", "--name", "db", "--extents", "100%FREE", "data"},
+				{"mkfs.ext4", "-F", "/dev/data/db"},
+			})).To(Succeed())
+		})
+		It("recreates logical volumes left over by a previous installation", func() {
+			Expect(vfs.MkdirAll(fs, "/dev/data", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/dev/data/logs", []byte{}, vfs.FilePerm)).To(Succeed())
+			Expect(i.Install(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"vgcreate", "--yes", "data", "/dev/device2"},
+				{"lvcreate", "--yes", "--wipesignatures", "y", "--name", "logs", "--size", "1024M", "data"},
+				{"mkfs.ext4", "-L", "LOGS", "-F", "/dev/data/logs"},
+			})).To(Succeed())
+		})
+		It("keeps an existing volume group on reset", func() {
+			Expect(vfs.MkdirAll(fs, "/dev/data", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/dev/data/logs", []byte{}, vfs.FilePerm)).To(Succeed())
+			Expect(i.Reset(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"systemd-repart"},
+				{"vgs", "--noheadings", "data"},
+				{"vgchange", "--activate", "y", "data"},
+				{"lvcreate", "--yes", "--wipesignatures", "y", "--name", "db", "--extents", "100%FREE", "data"},
+				{"mkfs.ext4", "-F", "/dev/data/db"},
+			})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"pvcreate"}})).NotTo(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"mkfs.ext4", "-L", "LOGS"}})).NotTo(Succeed())
+		})
+		It("fails creating the logical volumes", func() {
+			sideEffects["lvcreate"] = func(args ...string) ([]byte, error) {
+				return nil, fmt.Errorf("insufficient free space")
+			}
+			Expect(i.Install(d)).To(MatchError(ContainSubstring("insufficient free space")))
+		})
+	})
 })
+
+// writeELF writes a minimal ELF header for the given architecture to the given path
+func writeELF(fs vfs.FS, path, arch string) {
+	machines := map[string]elf.Machine{
+		platform.Archx86:     elf.EM_X86_64,
+		platform.ArchAarch64: elf.EM_AARCH64,
+		platform.ArchRiscv64: elf.EM_RISCV,
+	}
+	h := elf.Header64{Machine: uint16(machines[arch]), Version: uint32(elf.EV_CURRENT), Ehsize: 64}
+	copy(h.Ident[:], elf.ELFMAG)
+	h.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	h.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	h.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	var bin bytes.Buffer
+	Expect(binary.Write(&bin, binary.LittleEndian, h)).To(Succeed())
+	Expect(vfs.MkdirAll(fs, filepath.Dir(path), vfs.DirPerm)).To(Succeed())
+	Expect(fs.WriteFile(path, bin.Bytes(), vfs.FilePerm)).To(Succeed())
+}
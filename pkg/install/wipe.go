@@ -0,0 +1,142 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/deployment"
+)
+
+const sysBlockPath = "/sys/class/block"
+
+// wipeDisk cleans up the given disk according to its wipe policy
+func (i Installer) wipeDisk(disk *deployment.Disk) error {
+	w := disk.Wipe
+	if w == nil {
+		return nil
+	}
+
+	parts, err := lsblk.NewLsDevice(i.s).GetDevicePartitions(disk.Device)
+	if err != nil {
+		return fmt.Errorf("listing partitions of '%s': %w", disk.Device, err)
+	}
+	// Active LVM volume groups or RAID arrays keep their members busy, they can't be wiped
+	for _, part := range parts {
+		err = i.releaseDevice(part.Path)
+		if err != nil {
+			return err
+		}
+	}
+	err = i.releaseDevice(disk.Device)
+	if err != nil {
+		return err
+	}
+
+	if w.Signatures {
+		// Partitions are wiped first, they are no longer listed once the partition table is gone
+		for _, part := range parts {
+			i.s.Logger().Info("Wiping signatures of '%s'", part.Path)
+			_, err = i.s.Runner().Run("wipefs", "--all", part.Path)
+			if err != nil {
+				return fmt.Errorf("wiping signatures of '%s': %w", part.Path, err)
+			}
+		}
+		i.s.Logger().Info("Wiping signatures of '%s'", disk.Device)
+		_, err = i.s.Runner().Run("wipefs", "--all", disk.Device)
+		if err != nil {
+			return fmt.Errorf("wiping signatures of '%s': %w", disk.Device, err)
+		}
+	}
+
+	if w.Discard {
+		i.s.Logger().Info("Discarding blocks of '%s'", disk.Device)
+		_, err = i.s.Runner().Run("blkdiscard", "--force", disk.Device)
+		if err != nil {
+			return fmt.Errorf("discarding blocks of '%s': %w", disk.Device, err)
+		}
+	}
+
+	switch w.Erase {
+	case "zero":
+		i.s.Logger().Info("Filling '%s' with zeros, this might take a while", disk.Device)
+		_, err = i.s.Runner().Run("blkdiscard", "--force", "--zeroout", disk.Device)
+		if err != nil {
+			return fmt.Errorf("zero filling '%s': %w", disk.Device, err)
+		}
+	case "secure":
+		i.s.Logger().Info("Securely erasing '%s'", disk.Device)
+		_, err = i.s.Runner().Run("blkdiscard", "--force", "--secure", disk.Device)
+		if err != nil {
+			return fmt.Errorf("securely erasing '%s': %w", disk.Device, err)
+		}
+	}
+
+	_, _ = i.s.Runner().Run("udevadm", "settle")
+	return nil
+}
+
+// releaseDevice deactivates the LVM volume groups and stops the RAID arrays built on top of the given device
+func (i Installer) releaseDevice(device string) error {
+	holders, err := i.s.FS().ReadDir(filepath.Join(sysBlockPath, filepath.Base(device), "holders"))
+	if err != nil {
+		// no holders directory, nothing is built on top of this device
+		return nil
+	}
+
+	var lvm bool
+	for _, holder := range holders {
+		name := holder.Name()
+		switch {
+		case strings.HasPrefix(name, "md"):
+			array := filepath.Join("/dev", name)
+			err = i.releaseDevice(array)
+			if err != nil {
+				return err
+			}
+			i.s.Logger().Info("Stopping RAID array '%s'", array)
+			_, err = i.s.Runner().Run("mdadm", "--stop", array)
+			if err != nil {
+				return fmt.Errorf("stopping RAID array '%s': %w", array, err)
+			}
+		case strings.HasPrefix(name, "dm-"):
+			lvm = true
+		}
+	}
+
+	if !lvm {
+		return nil
+	}
+	out, err := i.s.Runner().Run("pvs", "--noheadings", "-o", "vg_name", device)
+	if err != nil {
+		return fmt.Errorf("'%s' is in use and it is not an LVM physical volume: %w", device, err)
+	}
+	vg := strings.TrimSpace(string(out))
+	if vg == "" {
+		return fmt.Errorf("'%s' is in use and it does not belong to any LVM volume group", device)
+	}
+	i.s.Logger().Info("Deactivating LVM volume group '%s'", vg)
+	_, err = i.s.Runner().Run("vgchange", "-an", vg)
+	if err != nil {
+		return fmt.Errorf("deactivating LVM volume group '%s': %w", vg, err)
+	}
+	return nil
+}
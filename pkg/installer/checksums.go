@@ -0,0 +1,163 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	mediaChecksums = "SHA256SUMS"
+
+	// ChecksumsRelPath is the path of the media checksums file relative to the media root
+	ChecksumsRelPath = liveDir + "/" + mediaChecksums
+)
+
+// ErrCorruptedMedia is returned when the content of an installer media does not match its checksums
+var ErrCorruptedMedia = errors.New("installer media is corrupted")
+
+// writeMediaChecksums writes the checksums file of the live image and the installation assets
+// found in the given media root. Checksums in base are kept for files missing in root, this is
+// useful to update the checksums of a media only partially available in root.
+func (i Media) writeMediaChecksums(root string, base map[string]string) error {
+	sums := maps.Clone(base)
+	if sums == nil {
+		sums = map[string]string{}
+	}
+
+	for _, path := range []string{SquashfsRelPath, installDir} {
+		if ok, _ := vfs.Exists(i.s.FS(), filepath.Join(root, path)); !ok {
+			continue
+		}
+		err := vfs.WalkDirFs(i.s.FS(), filepath.Join(root, path), func(file string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			checksum, err := calcFileChecksum(i.s.FS(), file)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			sums[rel] = checksum
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not compute media checksums: %w", err)
+		}
+	}
+
+	var data []byte
+	for _, file := range slices.Sorted(maps.Keys(sums)) {
+		data = fmt.Appendf(data, "%s  %s\n", sums[file], file)
+	}
+
+	checksumsFile := filepath.Join(root, ChecksumsRelPath)
+	err := vfs.MkdirAll(i.s.FS(), filepath.Dir(checksumsFile), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating media checksums directory: %w", err)
+	}
+	err = i.s.FS().WriteFile(checksumsFile, data, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed writing media checksums file %s: %w", checksumsFile, err)
+	}
+	return nil
+}
+
+// updateISOChecksums writes the media checksums of the customized ISO into the given assets directory.
+// Checksums of the files not replaced by the assets are kept from the original ISO.
+func (i Media) updateISOChecksums(tempDir, assetsPath string) error {
+	checksumsFile := filepath.Join(tempDir, mediaChecksums)
+	err := extractISO(i.s, i.InputFile, ChecksumsRelPath, checksumsFile)
+	if ok, _ := vfs.Exists(i.s.FS(), checksumsFile); err != nil || !ok {
+		i.s.Logger().Warn("No checksums found in '%s', the customized media will not be verified", i.InputFile)
+		return nil
+	}
+
+	base, err := readMediaChecksums(i.s, checksumsFile)
+	if err != nil {
+		return err
+	}
+	return i.writeMediaChecksums(assetsPath, base)
+}
+
+// readMediaChecksums parses the given checksums file into a map of relative paths to checksums
+func readMediaChecksums(s *sys.System, checksumsFile string) (map[string]string, error) {
+	data, err := s.FS().ReadFile(checksumsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading media checksums file %s: %w", checksumsFile, err)
+	}
+
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		checksum, file, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed media checksums line '%s'", line)
+		}
+		sums[file] = checksum
+	}
+	return sums, scanner.Err()
+}
+
+// VerifyMedia checks the live image and installation assets of the installer media mounted at
+// the given root match the checksums embedded at build time. Media without embedded checksums,
+// built by older versions, are not verified.
+func VerifyMedia(s *sys.System, root string) error {
+	checksumsFile := filepath.Join(root, ChecksumsRelPath)
+	if ok, _ := vfs.Exists(s.FS(), checksumsFile); !ok {
+		s.Logger().Warn("No checksums found in installer media, skipping media integrity check")
+		return nil
+	}
+
+	sums, err := readMediaChecksums(s, checksumsFile)
+	if err != nil {
+		return err
+	}
+
+	s.Logger().Info("Verifying installer media integrity")
+	for _, file := range slices.Sorted(maps.Keys(sums)) {
+		checksum, err := calcFileChecksum(s.FS(), filepath.Join(root, file))
+		if err != nil {
+			return fmt.Errorf("%w: could not read '%s', the media copy might be damaged: %w", ErrCorruptedMedia, file, err)
+		}
+		if checksum != sums[file] {
+			return fmt.Errorf("%w: checksum mismatch for '%s', the media copy is damaged, please re-create it", ErrCorruptedMedia, file)
+		}
+		s.Logger().Debug("Verified %s", file)
+	}
+	s.Logger().Info("Installer media integrity verified")
+	return nil
+}
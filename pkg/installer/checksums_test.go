@@ -0,0 +1,152 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func sha256sum(data string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+}
+
+var _ = Describe("Media checksums", Label("installermedia", "checksums"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var d *deployment.Deployment
+
+	var sideEffects map[string]func(...string) ([]byte, error)
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		sideEffects = map[string]func(...string) ([]byte, error){}
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/run/initramfs/live/LiveOS/squashfs.img":    "live image",
+			"/run/initramfs/live/Install/install.yaml":   "disks: []",
+			"/run/initramfs/live/Install/Overlay/a.conf": "overlay",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		d = deployment.DefaultDeployment()
+		d.Installer = deployment.LiveInstaller{}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if f := sideEffects[cmd]; f != nil {
+				return f(args...)
+			}
+			return runner.ReturnValue, runner.ReturnError
+		}
+		Expect(vfs.MkdirAll(fs, "/some/dir/build", vfs.DirPerm)).To(Succeed())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("embeds the checksums of the live image and installation assets", func() {
+		sideEffects["mksquashfs"] = func(args ...string) ([]byte, error) {
+			return nil, fs.WriteFile(args[1], []byte("live image"), vfs.FilePerm)
+		}
+		var checksums string
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			isoDir := args[slices.Index(args, "-map")+1]
+			data, err := fs.ReadFile(filepath.Join(isoDir, installer.ChecksumsRelPath))
+			Expect(err).NotTo(HaveOccurred())
+			checksums = string(data)
+			return nil, fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso.OutputDir = "/some/dir/build"
+		Expect(iso.Build(d)).To(Succeed())
+
+		Expect(checksums).To(MatchRegexp(`^[0-9a-f]{64}  Install/install\.yaml\n%s  LiveOS/squashfs\.img\n$`, sha256sum("live image")))
+	})
+	It("keeps the checksums of the original ISO on customize", func() {
+		var checksums string
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			if i := slices.Index(args, "-extract"); i >= 0 {
+				data := []byte("disks: []")
+				if args[i+1] == installer.ChecksumsRelPath {
+					data = fmt.Appendf(nil, "%s  LiveOS/squashfs.img\n%s  Install/setup.sh\n", sha256sum("live image"), sha256sum("script"))
+				}
+				return nil, fs.WriteFile(args[i+2], data, vfs.FilePerm)
+			}
+			for i, arg := range args {
+				if arg == "-map" && args[i+2] == "/" && filepath.Base(args[i+1]) == "assets" {
+					data, err := fs.ReadFile(filepath.Join(args[i+1], installer.ChecksumsRelPath))
+					Expect(err).NotTo(HaveOccurred())
+					checksums = string(data)
+				}
+			}
+			return nil, fs.WriteFile("/some/dir/build/installer2.iso", []byte("data"), vfs.FilePerm)
+		}
+		Expect(fs.WriteFile("/some/dir/installer.iso", []byte("iso"), vfs.FilePerm)).To(Succeed())
+
+		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso.InputFile = "/some/dir/installer.iso"
+		iso.OutputDir = "/some/dir/build"
+		iso.Name = "installer2"
+		Expect(iso.Customize(d)).To(Succeed())
+
+		Expect(checksums).To(MatchRegexp(
+			`^[0-9a-f]{64}  Install/install\.yaml\n%s  Install/setup\.sh\n%s  LiveOS/squashfs\.img\n$`,
+			sha256sum("script"), sha256sum("live image"),
+		))
+	})
+	It("verifies the installer media", func() {
+		By("skipping media without checksums")
+		Expect(installer.VerifyMedia(s, installer.LiveMountPoint)).To(Succeed())
+
+		checksums := fmt.Sprintf(
+			"%s  Install/Overlay/a.conf\n%s  Install/install.yaml\n%s  LiveOS/squashfs.img\n",
+			sha256sum("overlay"), sha256sum("disks: []"), sha256sum("live image"),
+		)
+		Expect(fs.WriteFile(filepath.Join(installer.LiveMountPoint, installer.ChecksumsRelPath), []byte(checksums), vfs.FilePerm)).To(Succeed())
+		Expect(installer.VerifyMedia(s, installer.LiveMountPoint)).To(Succeed())
+
+		By("detecting damaged files")
+		Expect(fs.WriteFile(installer.SquashfsPath, []byte("live imagf"), vfs.FilePerm)).To(Succeed())
+		err := installer.VerifyMedia(s, installer.LiveMountPoint)
+		Expect(err).To(MatchError(installer.ErrCorruptedMedia))
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch for 'LiveOS/squashfs.img'")))
+
+		By("detecting missing files")
+		Expect(fs.Remove(installer.InstallDesc)).To(Succeed())
+		Expect(installer.VerifyMedia(s, installer.LiveMountPoint)).To(MatchError(ContainSubstring("could not read 'Install/install.yaml'")))
+	})
+})
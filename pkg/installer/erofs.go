@@ -0,0 +1,117 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// erofsKernelCmdline makes dracut load the erofs driver before mounting the live root image
+	erofsKernelCmdline = "rd.driver.pre=erofs"
+	erofsModule        = "erofs.ko"
+	modulesBuiltin     = "modules.builtin"
+)
+
+// liveImageKernelCmdline returns the additional kernel arguments required to boot the live root image
+// in the given live root. EROFS images can only be booted if the initrd of the given OS root includes
+// the erofs module or if it is built into the kernel, the build fails otherwise.
+func (i Media) liveImageKernelCmdline(liveRoot, osRoot string) (string, error) {
+	if !isEROFSImage(i.s.FS(), filepath.Join(liveRoot, SquashfsRelPath)) {
+		return "", nil
+	}
+
+	kernel, _, err := vfs.FindKernel(i.s.FS(), osRoot)
+	if err != nil {
+		return "", fmt.Errorf("finding kernel: %w", err)
+	}
+
+	builtin, err := i.s.FS().ReadFile(filepath.Join(filepath.Dir(kernel), modulesBuiltin))
+	if err == nil && containsModule(builtin) {
+		return erofsKernelCmdline, nil
+	}
+
+	initrd := filepath.Join(filepath.Dir(kernel), bootloader.Initrd)
+	out, err := i.s.Runner().RunContext(i.ctx, "lsinitrd", initrd)
+	if err != nil {
+		return "", fmt.Errorf("listing the content of the initrd '%s': %w", initrd, err)
+	}
+	if !containsModule(out) {
+		return "", fmt.Errorf("the initrd '%s' does not include the erofs module, it can't boot an EROFS live root image", initrd)
+	}
+	return erofsKernelCmdline, nil
+}
+
+// withLiveCmdline appends the kernel arguments required to boot the live root image to the given ones,
+// unless they are already included
+func (i Media) withLiveCmdline(cmdline string) string {
+	return appendKernelArgs(cmdline, i.liveCmdline)
+}
+
+// appendKernelArgs appends the given arguments to the kernel command line, skipping those already included
+func appendKernelArgs(cmdline, args string) string {
+	fields := strings.Fields(cmdline)
+	for _, arg := range strings.Fields(args) {
+		if !slices.Contains(fields, arg) {
+			fields = append(fields, arg)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// liveCmdlineFromDesc returns the kernel arguments required to boot the live root image of the media
+// the given install description belongs to. Install descriptions of media with an EROFS live root image
+// keep the argument loading the erofs driver in the installer kernel command line.
+func liveCmdlineFromDesc(d *deployment.Deployment) string {
+	if slices.Contains(strings.Fields(d.Installer.KernelCmdline), erofsKernelCmdline) {
+		return erofsKernelCmdline
+	}
+	return ""
+}
+
+// isEROFSImage checks whether the live root image at the given path is an EROFS image
+func isEROFSImage(fs vfs.FS, path string) bool {
+	imgType, _ := filesystem.DetectLiveImageType(fs, path)
+	return imgType == filesystem.EROFSImage
+}
+
+// containsModule checks whether the given modules listing includes the erofs module, compressed or not
+func containsModule(listing []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(listing))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		name := filepath.Base(fields[len(fields)-1])
+		if name == erofsModule || strings.HasPrefix(name, erofsModule+".") {
+			return true
+		}
+	}
+	return false
+}
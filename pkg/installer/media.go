@@ -20,19 +20,28 @@ package installer
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/url"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"go.yaml.in/yaml/v3"
 
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/grubenv"
 	"github.com/suse/elemental/v3/pkg/repart"
 	"github.com/suse/elemental/v3/pkg/rsync"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/selinux"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -47,6 +56,7 @@ const (
 	installCfg     = "install.yaml"
 	isoBootCatalog = "boot.catalog"
 	cfgScript      = "setup.sh"
+	overlayTarball = "overlay.tar"
 	xorriso        = "xorriso"
 
 	LiveMountPoint  = "/run/initramfs/live"
@@ -61,6 +71,7 @@ type MediaType int
 const (
 	ISO MediaType = iota + 1
 	Disk
+	Netboot
 )
 
 func (m MediaType) String() string {
@@ -69,6 +80,8 @@ func (m MediaType) String() string {
 		return "iso"
 	case Disk:
 		return "raw"
+	case Netboot:
+		return "netboot"
 	default:
 		return "unknown"
 	}
@@ -80,6 +93,8 @@ func StringToMediaType(mType string) (MediaType, error) {
 		return Disk, nil
 	case "iso":
 		return ISO, nil
+	case "netboot":
+		return Netboot, nil
 	default:
 		return 0, fmt.Errorf("unsupported media type %s: %w", mType, errors.ErrUnsupported)
 	}
@@ -92,6 +107,8 @@ type Media struct {
 	OutputDir string
 	Label     string
 	InputFile string
+	// NetbootURL is the base URL the netboot artifacts are served from
+	NetbootURL string
 
 	mType       MediaType
 	s           *sys.System
@@ -100,6 +117,18 @@ type Media struct {
 	bl          bootloader.Bootloader
 	outputFile  string
 	rawDiskSize deployment.MiB
+	diskFormat  diskimage.Format
+	liveImage   filesystem.LiveImage
+	// sourceDateEpoch enables reproducible builds, all timestamps are pinned to it
+	sourceDateEpoch *time.Time
+	// bom is the software bill of materials completed with the OS packages and written next to
+	// the output file in each of the bomFormats, no bill of materials is written if nil
+	bom        *sbom.Document
+	bomFormats []sbom.Format
+	// buildID is derived from the build inputs on reproducible builds, it replaces random identifiers
+	buildID uuid.UUID
+	// liveCmdline holds the kernel arguments required to boot the live root image of the current build
+	liveCmdline string
 }
 
 // WithBootloader allows to create an ISO object with the given bootloader interface instance
@@ -122,6 +151,37 @@ func WithRawDiskSize(size deployment.MiB) Option {
 	}
 }
 
+// WithDiskFormat sets the format the raw disk image is converted to once built
+func WithDiskFormat(format diskimage.Format) Option {
+	return func(i *Media) {
+		i.diskFormat = format
+	}
+}
+
+// WithLiveImage sets the filesystem type and compression of the live root image
+func WithLiveImage(l filesystem.LiveImage) Option {
+	return func(i *Media) {
+		i.liveImage = l
+	}
+}
+
+// WithSourceDateEpoch enables reproducible builds. Timestamps are pinned to the given time and
+// identifiers such as UUIDs are derived from the build inputs instead of being random.
+func WithSourceDateEpoch(t time.Time) Option {
+	return func(i *Media) {
+		i.sourceDateEpoch = &t
+	}
+}
+
+// WithSBOM enables writing a software bill of materials in the given formats next to the output
+// file. The OS packages are added to the given document, which may already list other components.
+func WithSBOM(doc *sbom.Document, formats ...sbom.Format) Option {
+	return func(i *Media) {
+		i.bom = doc
+		i.bomFormats = formats
+	}
+}
+
 func WithOutputFile(outputFile string) Option {
 	return func(i *Media) {
 		i.outputFile = outputFile
@@ -136,13 +196,11 @@ func NewMedia(ctx context.Context, s *sys.System, mType MediaType, opts ...Optio
 		ctx:        ctx,
 		unpackOpts: []unpack.Opt{},
 		mType:      mType,
+		diskFormat: diskimage.Raw,
 	}
 	for _, o := range opts {
 		o(media)
 	}
-	if media.bl == nil {
-		media.bl, _ = bootloader.New(bootloader.BootGrub, media.s)
-	}
 	if media.mType == ISO {
 		media.Label = "LIVE"
 	}
@@ -219,12 +277,47 @@ func (i Media) Build(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("failed to populate ISO directory tree: %w", err)
 	}
 
+	i.liveCmdline, err = i.liveImageKernelCmdline(liveRoot, osRoot)
+	if err != nil {
+		return fmt.Errorf("cannot boot the live root image: %w", err)
+	}
+
+	if i.sourceDateEpoch != nil {
+		i.buildID = i.reproducibleBuildID(d)
+		i.s.Logger().Info("Reproducible build pinned to %s, build ID %s", i.sourceDateEpoch.Format(time.RFC3339), i.buildID)
+	}
+
+	// ISOs also boot on legacy BIOS systems if the deployment they install does
+	bios := i.mType == ISO && d.GetBIOSPartition() != nil
+
+	// live media is always booted with grub, customized with the boot menu of the deployment
+	if i.bl == nil {
+		var menu *deployment.BootMenu
+		if d.BootConfig != nil {
+			menu = d.BootConfig.Menu
+		}
+		opts := []bootloader.Option{bootloader.WithMenu(menu)}
+		if bios {
+			opts = append(opts, bootloader.WithBIOS(""))
+		}
+		if i.sourceDateEpoch != nil {
+			opts = append(opts, bootloader.WithBootID(hex.EncodeToString(i.buildID[:4])))
+		}
+		i.bl = bootloader.NewGrub(i.s, opts...)
+	}
+
 	switch i.mType {
 	case ISO:
-		cmdline := fmt.Sprintf("%s %s", deployment.LiveKernelCmdline(i.Label), d.Installer.KernelCmdline)
-		err = i.buildISO(tempDir, liveRoot, osRoot, cmdline)
+		cmdline := fmt.Sprintf("%s %s", i.withLiveCmdline(deployment.LiveKernelCmdline(i.Label)), d.Installer.KernelCmdline)
+		err = i.buildISO(tempDir, liveRoot, osRoot, cmdline, bios)
 	case Disk:
 		err = i.buildDisk(tempDir, liveRoot, osRoot, d)
+		if err == nil {
+			err = i.convertDisk()
+		}
+	case Netboot:
+		cmdline := fmt.Sprintf("%s %s", i.withLiveCmdline(netbootKernelCmdline(i.NetbootURL)), d.Installer.KernelCmdline)
+		err = i.buildNetboot(liveRoot, osRoot, cmdline)
 	default:
 		return fmt.Errorf("unknown media type: %w", errors.ErrUnsupported)
 	}
@@ -232,7 +325,12 @@ func (i Media) Build(d *deployment.Deployment) (err error) {
 		return err
 	}
 
-	return i.writeChecksum()
+	err = i.writeChecksum()
+	if err != nil {
+		return err
+	}
+
+	return i.writeSBOM()
 }
 
 // PrepareInstallerFS prepares the directory tree of the installer image, rootDir is the path
@@ -254,12 +352,35 @@ func (i *Media) PrepareInstallerFS(rootDir, workDir string, d *deployment.Deploy
 		if err != nil {
 			return fmt.Errorf("failed copying OS image to installer root tree: %w", err)
 		}
+		if i.bom != nil {
+			packages, err := sbom.ReadImagePackages(i.ctx, i.s, squashImg, workDir)
+			if err != nil {
+				return fmt.Errorf("failed reading OS packages for the SBOM: %w", err)
+			}
+			i.bom.Add(packages...)
+		}
 	default:
 		err = i.prepareOSRoot(d.SourceOS, workDir)
 		if err != nil {
 			return fmt.Errorf("preparing unpack: %w", err)
 		}
-		err = filesystem.CreateSquashFS(i.ctx, i.s, workDir, squashImg, filesystem.DefaultSquashfsCompressionOptions())
+		if i.bom != nil {
+			packages, err := sbom.ReadPackages(i.ctx, i.s, workDir)
+			if err != nil {
+				return fmt.Errorf("failed reading OS packages for the SBOM: %w", err)
+			}
+			i.bom.Add(osComponent(d.SourceOS))
+			i.bom.Add(packages...)
+		}
+		var options []string
+		if i.sourceDateEpoch != nil {
+			if i.liveImage.Type == filesystem.EROFSImage {
+				options = filesystem.EROFSReproducibleOptions(*i.sourceDateEpoch, i.reproducibleBuildID(d).String())
+			} else {
+				options = filesystem.SquashfsReproducibleOptions(*i.sourceDateEpoch)
+			}
+		}
+		err = filesystem.CreateLiveImage(i.ctx, i.s, i.liveImage, workDir, squashImg, options...)
 		if err != nil {
 			return fmt.Errorf("failed creating image (%s) for live ISO: %w", squashImg, err)
 		}
@@ -307,7 +428,12 @@ func (i *Media) PrepareInstallerFS(rootDir, workDir string, d *deployment.Deploy
 		}
 	}
 
-	return i.writeInstallDescription(filepath.Join(rootDir, installDir), d)
+	err = i.writeInstallDescription(filepath.Join(rootDir, installDir), d)
+	if err != nil {
+		return err
+	}
+
+	return i.writeMediaChecksums(rootDir, nil)
 }
 
 // Customize repacks an existing installer with more artifacts.
@@ -331,6 +457,8 @@ func (i *Media) Customize(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("failed extracting install description from '%s': %w", i.InputFile, err)
 	}
 
+	i.liveCmdline = liveCmdlineFromDesc(installDesc)
+
 	m := map[string]string{}
 
 	grubEnvPath := filepath.Join(tempDir, "grubenv")
@@ -398,9 +526,15 @@ func (i *Media) Customize(d *deployment.Deployment) (err error) {
 
 	switch i.mType {
 	case ISO:
-		err = i.customizeISO(i.InputFile, i.outputFile, m)
+		err = i.updateISOChecksums(tempDir, assetsPath)
+		if err == nil {
+			err = i.customizeISO(i.InputFile, i.outputFile, m)
+		}
 	case Disk:
 		err = i.customizeDisk(tempDir, installDesc, m)
+		if err == nil {
+			err = i.convertDisk()
+		}
 	default:
 		err = fmt.Errorf("unknown media type: %w", errors.ErrUnsupported)
 	}
@@ -408,12 +542,33 @@ func (i *Media) Customize(d *deployment.Deployment) (err error) {
 		return err
 	}
 
-	return i.writeChecksum()
+	err = i.writeChecksum()
+	if err != nil {
+		return err
+	}
+
+	if i.bom != nil {
+		squashImg := filepath.Join(tempDir, squashfsImg)
+		err = extractISO(i.s, i.InputFile, SquashfsRelPath, squashImg)
+		if err != nil {
+			return fmt.Errorf("failed extracting OS image for the SBOM: %w", err)
+		}
+		packages, err := sbom.ReadImagePackages(i.ctx, i.s, squashImg, tempDir)
+		if err != nil {
+			return fmt.Errorf("failed reading OS packages for the SBOM: %w", err)
+		}
+		i.bom.Add(packages...)
+	}
+	return i.writeSBOM()
 }
 
 // writeChecksum computes the checksum for the current media output file and writes
 // the checksum file to the same output file path, but with the *.sha256 suffix
 func (i Media) writeChecksum() error {
+	if i.mType == Netboot {
+		return i.writeNetbootChecksums()
+	}
+
 	checksum, err := calcFileChecksum(i.s.FS(), i.outputFile)
 	if err != nil {
 		return fmt.Errorf("could not compute image checksum: %w", err)
@@ -435,19 +590,46 @@ func (i Media) recreateGrubenv(target, kernelCmdline string, loadedDep *deployme
 	}
 	switch i.mType {
 	case ISO:
-		kernelCmdline = fmt.Sprintf("%s %s", deployment.LiveKernelCmdline(i.Label), kernelCmdline)
+		kernelCmdline = i.withLiveCmdline(fmt.Sprintf("%s %s", deployment.LiveKernelCmdline(i.Label), kernelCmdline))
 	case Disk:
-		kernelCmdline = fmt.Sprintf("%s %s %s", loadedDep.RecoveryKernelCmdline(), deployment.ResetMark, kernelCmdline)
+		kernelCmdline = i.withLiveCmdline(fmt.Sprintf("%s %s %s", loadedDep.RecoveryKernelCmdline(), deployment.ResetMark, kernelCmdline))
 	default:
 		return fmt.Errorf("invalid media type")
 	}
-	err := i.writeGrubEnv(target, map[string]string{"cmdline": kernelCmdline})
+	err := grubenv.Set(i.s, target, map[string]string{"cmdline": kernelCmdline})
 	if err != nil {
 		return fmt.Errorf("error writing %s: %w", target, err)
 	}
 	return nil
 }
 
+// writeSBOM writes the software bill of materials next to the output file, if any
+func (i Media) writeSBOM() error {
+	if i.bom == nil {
+		return nil
+	}
+	if i.bom.Name == "" {
+		i.bom.Name = filepath.Base(i.outputFile)
+	}
+	err := sbom.Write(i.s, i.bom, i.outputFile, i.bomFormats...)
+	if err != nil {
+		return fmt.Errorf("failed writing SBOM: %w", err)
+	}
+	return nil
+}
+
+// osComponent returns the bill of materials component of the given OS image source
+func osComponent(src *deployment.ImageSource) sbom.Component {
+	c := sbom.Component{Type: sbom.OperatingSystem, Name: src.URI()}
+	if src.IsOCI() {
+		c = sbom.ImageComponent(sbom.OperatingSystem, src.URI())
+	}
+	if digest := src.GetDigest(); digest != "" {
+		c.Digest = digest
+	}
+	return c
+}
+
 // increaseRecoverySize increases the recovery partition size based on the data included as part
 // of the customize process
 func (i Media) increaseRecoverySize(mappedFiles map[string]string, d *deployment.Deployment) error {
@@ -487,6 +669,13 @@ func (i *Media) sanitize() error {
 		return fmt.Errorf("undefined label for the installer filesystem")
 	}
 
+	if i.mType == Netboot {
+		u, err := url.Parse(i.NetbootURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid netboot URL '%s', an http or https URL is required", i.NetbootURL)
+		}
+	}
+
 	if i.OutputDir == "" {
 		return fmt.Errorf("undefined output directory")
 	}
@@ -526,23 +715,6 @@ func (i Media) customizeISO(inputFile, outputFile string, fileMap map[string]str
 	return nil
 }
 
-func (i Media) writeGrubEnv(file string, vars map[string]string) error {
-	arr := make([]string, len(vars)+2)
-
-	arr[0] = file
-	arr[1] = "set"
-
-	j := 2
-	for k, v := range vars {
-		arr[j] = fmt.Sprintf("%s=%s", k, v)
-
-		j++
-	}
-
-	_, err := i.s.Runner().Run("grub2-editenv", arr...)
-	return err
-}
-
 // prepareOSRoot arranges the root directory tree that will be used to build the ISO's
 // squashfs image. It essentially extracts OS OCI images to the given location.
 func (i Media) prepareOSRoot(sourceOS *deployment.ImageSource, rootDir string) error {
@@ -615,6 +787,14 @@ func (i Media) addInstallationAssets(root string, d *deployment.Deployment) erro
 		}
 
 		switch {
+		case d.OverlayTree.IsDir() && i.mType == Netboot:
+			// directories can't be fetched over the network, netboot media ship the overlay as a tarball
+			tarball := filepath.Join(overlayPath, overlayTarball)
+			out, err := i.s.Runner().Run("tar", "-C", d.OverlayTree.URI(), "-cf", tarball, ".")
+			if err != nil {
+				return fmt.Errorf("failed archiving overlay tree: %s: %w", strings.TrimSpace(string(out)), err)
+			}
+			d.OverlayTree = deployment.NewTarSrc(filepath.Join(LiveMountPoint, installDir, overlayDir, overlayTarball))
 		case d.OverlayTree.IsDir():
 			r := rsync.NewRsync(i.s, rsync.WithFlags(rsync.OverlayTreeSyncFlags()...), rsync.WithContext(i.ctx))
 			err = r.SyncData(d.OverlayTree.URI(), overlayPath)
@@ -653,9 +833,9 @@ func (i Media) writeInstallDescription(installPath string, d *deployment.Deploym
 	if d.OverlayTree != nil && !d.OverlayTree.IsEmpty() {
 		switch {
 		case d.OverlayTree.IsDir():
-			d.OverlayTree = deployment.NewDirSrc(filepath.Join(LiveMountPoint, installDir, overlayDir))
+			d.OverlayTree = deployment.NewDirSrc(i.assetPath(filepath.Join(installDir, overlayDir)))
 		case d.OverlayTree.IsRaw() || d.OverlayTree.IsTar():
-			path := filepath.Join(LiveMountPoint, installDir, overlayDir, filepath.Base(d.OverlayTree.URI()))
+			path := i.assetPath(filepath.Join(installDir, overlayDir, filepath.Base(d.OverlayTree.URI())))
 			if d.OverlayTree.IsTar() {
 				d.OverlayTree = deployment.NewTarSrc(path)
 			} else {
@@ -665,15 +845,27 @@ func (i Media) writeInstallDescription(installPath string, d *deployment.Deploym
 	}
 
 	if d.CfgScript != "" {
-		d.CfgScript = InstallScript
+		d.CfgScript = i.assetPath(filepath.Join(installDir, cfgScript))
 	}
 
 	if d.Installer.CfgScript != "" {
-		d.Installer.CfgScript = filepath.Join(LiveMountPoint, liveDir, cfgScript)
+		d.Installer.CfgScript = i.assetPath(filepath.Join(liveDir, cfgScript))
 	}
 
-	d.SourceOS = deployment.NewRawSrc(SquashfsPath)
-	d.Installer.OverlayTree = deployment.NewDirSrc(LiveMountPoint)
+	// the argument loading the erofs driver is kept in the installer kernel command line, so
+	// customized media and the recovery entry of installed systems boot the EROFS image too
+	if i.liveCmdline != "" || isEROFSImage(i.s.FS(), filepath.Join(filepath.Dir(installPath), SquashfsRelPath)) {
+		d.Installer.KernelCmdline = appendKernelArgs(d.Installer.KernelCmdline, erofsKernelCmdline)
+	}
+
+	d.SourceOS = deployment.NewRawSrc(i.assetPath(SquashfsRelPath))
+	if i.mType == Netboot {
+		// the live tree of netboot media is not available at install time, the recovery
+		// partition is set from the fetched live image and installation assets only
+		d.Installer.OverlayTree = nil
+	} else {
+		d.Installer.OverlayTree = deployment.NewDirSrc(LiveMountPoint)
+	}
 
 	if i.mType == Disk {
 		for _, disk := range d.Disks {
@@ -695,6 +887,17 @@ func (i Media) writeInstallDescription(installPath string, d *deployment.Deploym
 	return nil
 }
 
+// assetPath returns the path of the given media asset as referenced by the install description.
+// Netboot media are not mounted at install time, so their assets are referenced relative to the
+// install description, which is fetched over the network together with them.
+func (i Media) assetPath(rel string) string {
+	if i.mType == Netboot {
+		path, _ := filepath.Rel(installDir, rel)
+		return path
+	}
+	return filepath.Join(LiveMountPoint, rel)
+}
+
 // customizeDisk creates an installer disk image from the prepared root
 func (i Media) customizeDisk(tempDir string, d *deployment.Deployment, mappedFiles map[string]string) error {
 	isoDir := filepath.Join(tempDir, "extracted-iso")
@@ -735,6 +938,11 @@ func (i Media) customizeDisk(tempDir string, d *deployment.Deployment, mappedFil
 		}
 	}
 
+	err = i.writeMediaChecksums(isoDir, nil)
+	if err != nil {
+		return err
+	}
+
 	parts := []repart.Partition{
 		{
 			Partition: esp,
@@ -765,7 +973,9 @@ func (i Media) buildDisk(tempDir, liveRoot, osRoot string, d *deployment.Deploym
 	}
 
 	// include the reset flag so it can be detected at boot this is an installer image
-	cmdline := fmt.Sprintf("%s %s %s", d.RecoveryKernelCmdline(), deployment.ResetMark, d.Installer.KernelCmdline)
+	cmdline := fmt.Sprintf(
+		"%s %s %s", i.withLiveCmdline(d.RecoveryKernelCmdline()), deployment.ResetMark, d.Installer.KernelCmdline,
+	)
 	err = i.bl.InstallLive(osRoot, espDir, cmdline)
 	if err != nil {
 		return fmt.Errorf("failed installing the bootloader for a installer raw image: %w", err)
@@ -780,15 +990,37 @@ func (i Media) buildDisk(tempDir, liveRoot, osRoot string, d *deployment.Deploym
 			CopyFiles: []string{fmt.Sprintf("%s:/", liveRoot)},
 		},
 	}
-	err = repart.CreateDiskImage(i.s, i.outputFile, 0, parts)
+	var flags []string
+	if i.sourceDateEpoch != nil {
+		for _, root := range []string{espDir, liveRoot} {
+			err = vfs.ClampModTimes(i.s.FS(), root, *i.sourceDateEpoch)
+			if err != nil {
+				return fmt.Errorf("failed pinning file times of %s: %w", root, err)
+			}
+		}
+		flags = append(flags, fmt.Sprintf("--seed=%s", i.buildID))
+	}
+
+	err = repart.CreateDiskImage(i.s, i.outputFile, 0, parts, flags...)
 	if err != nil {
 		return fmt.Errorf("failed creating disk image: %w", err)
 	}
 	return nil
 }
 
+// convertDisk converts the raw disk image to the configured disk format and updates the
+// output file accordingly
+func (i *Media) convertDisk() error {
+	output, err := diskimage.Convert(i.ctx, i.s, i.outputFile, i.diskFormat)
+	if err != nil {
+		return fmt.Errorf("failed converting disk image: %w", err)
+	}
+	i.outputFile = output
+	return nil
+}
+
 // buildISO creates an ISO image from the prepared root
-func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
+func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string, bios bool) error {
 	err := i.bl.InstallLive(osRoot, isoDir, kernelCmdline)
 	if err != nil {
 		return fmt.Errorf("failed installing bootloader in ISO directory tree: %w", err)
@@ -806,7 +1038,11 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 	}
 
 	efiImg := filepath.Join(tempDir, filepath.Base(espDir)+".img")
-	err = filesystem.CreatePreloadedFileSystemImage(i.s, espDir, efiImg, deployment.EfiLabel, 1, deployment.VFat)
+	var efiOpts []filesystem.PreloadOption
+	if i.sourceDateEpoch != nil {
+		efiOpts = append(efiOpts, filesystem.WithUUID(i.buildID.String()), filesystem.WithModTime(*i.sourceDateEpoch))
+	}
+	err = filesystem.CreatePreloadedFileSystemImage(i.s, espDir, efiImg, deployment.EfiLabel, 1, deployment.VFat, efiOpts...)
 	if err != nil {
 		return fmt.Errorf("failed creating EFI image for the installer image: %w", err)
 	}
@@ -815,7 +1051,13 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 		"-volid", "LIVE", "-padding", "0",
 		"-outdev", i.outputFile, "-map", isoDir, "/", "-chmod", "0755", "--",
 	}
+	if bios {
+		args = append(args, xorrisoBIOSBootloaderArgs(filepath.Join(isoDir, bootloader.LiveBIOSMBR))...)
+	}
 	args = append(args, xorrisoBootloaderArgs(efiImg)...)
+	if i.sourceDateEpoch != nil {
+		args = append(args, xorrisoReproducibleArgs(*i.sourceDateEpoch)...)
+	}
 
 	_, err = i.s.Runner().RunContext(i.ctx, xorriso, args...)
 	if err != nil {
@@ -825,6 +1067,77 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 	return nil
 }
 
+// buildNetboot creates the network boot artifacts from the prepared root. The output is a directory
+// to be served over http from the netboot URL.
+func (i Media) buildNetboot(liveRoot, osRoot, kernelCmdline string) error {
+	nb, ok := i.bl.(bootloader.NetbootInstaller)
+	if !ok {
+		return fmt.Errorf("bootloader does not support network boot: %w", errors.ErrUnsupported)
+	}
+
+	err := nb.InstallNetboot(osRoot, liveRoot, i.NetbootURL, kernelCmdline)
+	if err != nil {
+		return fmt.Errorf("failed installing bootloader in netboot directory tree: %w", err)
+	}
+
+	err = i.s.FS().Rename(liveRoot, i.outputFile)
+	if err != nil {
+		return fmt.Errorf("failed moving netboot artifacts to '%s': %w", i.outputFile, err)
+	}
+	return nil
+}
+
+// writeNetbootChecksums writes the sha256 checksums of all the netboot artifacts next to the
+// output directory
+func (i Media) writeNetbootChecksums() error {
+	var sums []byte
+	err := vfs.WalkDirFs(i.s.FS(), i.outputFile, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		checksum, err := calcFileChecksum(i.s.FS(), path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(i.outputFile, path)
+		if err != nil {
+			return err
+		}
+		sums = fmt.Appendf(sums, "%s %s\n", checksum, rel)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not compute netboot artifacts checksums: %w", err)
+	}
+
+	checksumFile := fmt.Sprintf("%s.sha256", i.outputFile)
+	err = i.s.FS().WriteFile(checksumFile, sums, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed writing checksum file %s: %w", checksumFile, err)
+	}
+	return nil
+}
+
+// reproducibleBuildID returns an identifier derived from the source OS image, the media name
+// and label and the source date epoch, so it only changes if any of the build inputs changes
+func (i Media) reproducibleBuildID(d *deployment.Deployment) uuid.UUID {
+	inputs := fmt.Sprintf(
+		"%s@%s %s %s %d", d.SourceOS.URI(), d.SourceOS.GetDigest(), i.Name, i.Label, i.sourceDateEpoch.Unix(),
+	)
+	return uuid.NewSHA1(uuid.Nil, []byte(inputs))
+}
+
+// netbootKernelCmdline returns the kernel arguments to boot the live root fetched over the network.
+// Only the live root is fetched at boot, the install description is referenced for the installer
+// to fetch it.
+func netbootKernelCmdline(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return fmt.Sprintf(
+		"root=live:%s/%s rd.neednet=1 ip=dhcp rd.live.overlay.overlayfs=1 %s=%s/%s/%s",
+		baseURL, SquashfsRelPath, ConfigCmdlineArg, baseURL, installDir, installCfg,
+	)
+}
+
 // xorrisoBootloaderArgs returns a slice of flags for xorriso to defined a common bootloader parameters
 func xorrisoBootloaderArgs(efiImg string) []string {
 	args := []string{
@@ -839,6 +1152,35 @@ func xorrisoBootloaderArgs(efiImg string) []string {
 	return args
 }
 
+// xorrisoReproducibleArgs returns a slice of flags for xorriso to pin all volume and file timestamps
+// to the given time. The volume UUID and the GPT disk GUID are derived from it.
+func xorrisoReproducibleArgs(t time.Time) []string {
+	epoch := fmt.Sprintf("=%d", t.Unix())
+	return []string{
+		"-volume_date", "c", epoch,
+		"-volume_date", "m", epoch,
+		"-volume_date", "uuid", t.UTC().Format("20060102150405") + "00",
+		"-volume_date", "all_file_dates", epoch,
+		"-boot_image", "any", "gpt_disk_guid=volume_date_uuid",
+	}
+}
+
+// xorrisoBIOSBootloaderArgs returns a slice of flags for xorriso to define an El Torito BIOS boot image
+// and a hybrid MBR, so the image boots on BIOS systems from optical and USB media. The BIOS boot
+// image is the first of the boot catalog, it is followed by the EFI one.
+func xorrisoBIOSBootloaderArgs(mbrImg string) []string {
+	return []string{
+		"-boot_image", "grub", fmt.Sprintf("bin_path=%s", bootloader.LiveBIOSImage),
+		"-boot_image", "grub", fmt.Sprintf("grub2_mbr=%s", mbrImg),
+		"-boot_image", "grub", "grub2_boot_info=on",
+		"-boot_image", "any", "platform_id=0x00",
+		"-boot_image", "any", "emul_type=no_emulation",
+		"-boot_image", "any", "load_size=2048",
+		"-boot_image", "any", "boot_info_table=on",
+		"-boot_image", "any", "next",
+	}
+}
+
 // calcFileChecksum opens the given file and returns the sha256 checksum of it.
 func calcFileChecksum(fs vfs.FS, fileName string) (string, error) {
 	f, err := fs.Open(fileName)
@@ -19,19 +19,25 @@ package installer_test
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"path/filepath"
 	"slices"
 	"strings"
-
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.yaml.in/yaml/v3"
 
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/diskimage"
+	"github.com/suse/elemental/v3/pkg/filesystem"
 	"github.com/suse/elemental/v3/pkg/installer"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sbom"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -108,6 +114,350 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 			{"xorriso", "-volid", "LIVE", "-padding", "0", "-outdev", "/some/dir/build/installer.iso"},
 		}))
 	})
+	It("Creates an installation ISO booting on BIOS systems", func() {
+		var xorrisoArgs []string
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			xorrisoArgs = args
+			Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.Disks[0].Partitions = append(deployment.Partitions{{Role: deployment.BIOS}}, d.Disks[0].Partitions...)
+
+		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso.OutputDir = "/some/dir/build"
+
+		Expect(iso.Build(d)).To(Succeed())
+		Expect(xorrisoArgs).To(ContainElements(
+			"bin_path=/boot/grub2/i386-pc/eltorito.img",
+			"grub2_mbr=/some/dir/build/elemental-installer/liveroot/boot/grub2/i386-pc/boot_hybrid.img",
+			"platform_id=0x00", "next", "platform_id=0xef",
+		))
+	})
+	It("Creates a reproducible installation ISO", func() {
+		var xorrisoArgs []string
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			xorrisoArgs = args
+			Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		epoch := time.Date(2025, 11, 14, 22, 13, 20, 0, time.UTC)
+		build := func() []string {
+			runner.ClearCmds()
+			Expect(fs.RemoveAll("/some/dir/build")).To(Succeed())
+			iso := installer.NewMedia(
+				context.Background(), s, installer.ISO,
+				installer.WithBootloader(bootloader.NewNone(s)), installer.WithSourceDateEpoch(epoch),
+			)
+			iso.OutputDir = "/some/dir/build"
+			Expect(iso.Build(d)).To(Succeed())
+			for _, cmd := range runner.GetCmds() {
+				if cmd[0] == "mkfs.vfat" {
+					return cmd
+				}
+			}
+			return nil
+		}
+
+		mkfs := build()
+		Expect(mkfs).To(ContainElements("-i", "--invariant"))
+		Expect(build()).To(Equal(mkfs))
+		Expect(runner.IncludesCmds([][]string{{
+			"mksquashfs", "/some/dir/build/elemental-installer/osroot", "/some/dir/build/elemental-installer/liveroot/LiveOS/squashfs.img",
+			"-b", "1024k", "-mkfs-time", "1763158400", "-all-time", "1763158400",
+		}})).To(Succeed())
+		Expect(strings.Join(xorrisoArgs, " ")).To(ContainSubstring(
+			"-volume_date c =1763158400 -volume_date m =1763158400 -volume_date uuid 2025111422132000 " +
+				"-volume_date all_file_dates =1763158400 -boot_image any gpt_disk_guid=volume_date_uuid",
+		))
+	})
+	It("Creates an installation ISO with an EROFS live image", func() {
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithLiveImage(filesystem.LiveImage{Type: filesystem.EROFSImage, Compression: filesystem.Zstd, Level: 9}),
+			installer.WithSourceDateEpoch(time.Unix(1700000000, 0)),
+		)
+		iso.OutputDir = "/some/dir/build"
+		Expect(iso.Build(d)).To(Succeed())
+
+		var mkfs []string
+		for _, cmd := range runner.GetCmds() {
+			if cmd[0] == "mkfs.erofs" {
+				mkfs = cmd
+			}
+		}
+		Expect(mkfs).To(HaveLen(10))
+		Expect(mkfs[:7]).To(Equal([]string{"mkfs.erofs", "-z", "zstd,9", "-T", "1700000000", "--all-time", "-U"}))
+		Expect(mkfs[8:]).To(Equal([]string{
+			"/some/dir/build/elemental-installer/liveroot/LiveOS/squashfs.img", "/some/dir/build/elemental-installer/osroot",
+		}))
+	})
+	Describe("EROFS live images", func() {
+		var bl *liveCmdlineRecorder
+		var iso *installer.Media
+		var builtin []byte
+		BeforeEach(func() {
+			builtin = nil
+			sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+				Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+				return []byte{}, nil
+			}
+			sideEffects["mkfs.erofs"] = func(args ...string) ([]byte, error) {
+				img, osRoot := args[len(args)-2], args[len(args)-1]
+				header := make([]byte, 1028)
+				binary.LittleEndian.PutUint32(header[1024:], 0xE0F5E1E2)
+				Expect(fs.WriteFile(img, header, vfs.FilePerm)).To(Succeed())
+				modules := filepath.Join(osRoot, "usr/lib/modules/6.12.0-1-default")
+				Expect(vfs.MkdirAll(fs, modules, vfs.DirPerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(modules, "vmlinuz"), []byte("kernel"), vfs.FilePerm)).To(Succeed())
+				Expect(fs.WriteFile(filepath.Join(modules, "initrd"), []byte("initrd"), vfs.FilePerm)).To(Succeed())
+				if builtin != nil {
+					Expect(fs.WriteFile(filepath.Join(modules, "modules.builtin"), builtin, vfs.FilePerm)).To(Succeed())
+				}
+				return []byte{}, nil
+			}
+
+			d.SourceOS = deployment.NewDirSrc("/some/root")
+			bl = &liveCmdlineRecorder{None: bootloader.NewNone(s)}
+			iso = installer.NewMedia(
+				context.Background(), s, installer.ISO, installer.WithBootloader(bl),
+				installer.WithLiveImage(filesystem.LiveImage{Type: filesystem.EROFSImage}),
+			)
+			iso.OutputDir = "/some/dir/build"
+		})
+		It("loads the erofs driver at boot if the initrd includes it", func() {
+			sideEffects["lsinitrd"] = func(args ...string) ([]byte, error) {
+				return []byte("-rw-r--r--   1 root  root  52312 Jan  1 00:00 usr/lib/modules/6.12.0-1-default/kernel/fs/erofs/erofs.ko.zst\n"), nil
+			}
+			Expect(iso.Build(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{
+				"lsinitrd", "/some/dir/build/elemental-installer/osroot/usr/lib/modules/6.12.0-1-default/initrd",
+			}})).To(Succeed())
+			Expect(bl.cmdline).To(HavePrefix("root=live:LABEL=LIVE rd.live.overlay.overlayfs=1 rd.driver.pre=erofs"))
+		})
+		It("does not inspect the initrd if erofs is built into the kernel", func() {
+			builtin = []byte("kernel/fs/erofs/erofs.ko\n")
+			Expect(iso.Build(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"lsinitrd"}})).NotTo(Succeed())
+			Expect(bl.cmdline).To(ContainSubstring("rd.driver.pre=erofs"))
+		})
+		It("keeps the erofs driver argument in the install description", func() {
+			builtin = []byte("kernel/fs/erofs/erofs.ko\n")
+			d.Installer.KernelCmdline = "console=ttyS0"
+			Expect(iso.PrepareInstallerFS("/some/dir/liveroot", "/some/dir/osroot", d)).To(Succeed())
+			Expect(d.Installer.KernelCmdline).To(Equal("console=ttyS0"))
+
+			data, err := fs.ReadFile("/some/dir/liveroot/Install/install.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			desc := &deployment.Deployment{}
+			Expect(yaml.Unmarshal(data, desc)).To(Succeed())
+			Expect(desc.Installer.KernelCmdline).To(Equal("console=ttyS0 rd.driver.pre=erofs"))
+		})
+		It("keeps loading the erofs driver when customizing the media", func() {
+			var grubenv []byte
+			sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+				if i := slices.Index(args, "-extract"); i >= 0 {
+					var desc string
+					if strings.HasSuffix(args[i+1], "install.yaml") {
+						desc = "installer:\n  kernelCmdline: console=ttyS0 rd.driver.pre=erofs\n"
+					}
+					return nil, fs.WriteFile(args[i+2], []byte(desc), vfs.FilePerm)
+				}
+				for i, arg := range args {
+					if arg == "/boot/grubenv" {
+						var err error
+						grubenv, err = fs.ReadFile(args[i-1])
+						Expect(err).NotTo(HaveOccurred())
+					}
+				}
+				return nil, fs.WriteFile("/some/dir/build/installer2.iso", []byte("data"), vfs.FilePerm)
+			}
+			Expect(fs.WriteFile("/some/dir/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+
+			custom := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+			custom.InputFile = "/some/dir/installer.iso"
+			custom.OutputDir = "/some/dir/build"
+			custom.Name = "installer2"
+			d.Installer.KernelCmdline = "quiet"
+			Expect(custom.Customize(d)).To(Succeed())
+			Expect(string(grubenv)).To(ContainSubstring("cmdline=root=live:LABEL=LIVE rd.live.overlay.overlayfs=1 quiet rd.driver.pre=erofs\n"))
+		})
+		It("fails if the initrd does not include the erofs driver", func() {
+			sideEffects["lsinitrd"] = func(args ...string) ([]byte, error) {
+				return []byte("-rw-r--r--   1 root  root  41230 Jan  1 00:00 usr/lib/modules/6.12.0-1-default/kernel/fs/squashfs/squashfs.ko.zst\n"), nil
+			}
+			Expect(iso.Build(d)).To(MatchError(ContainSubstring("does not include the erofs module")))
+			Expect(runner.IncludesCmds([][]string{{"xorriso"}})).NotTo(Succeed())
+		})
+	})
+	It("Creates an installation ISO with its SBOM", func() {
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+		sideEffects["rpm"] = func(args ...string) ([]byte, error) {
+			return []byte("bash\t5.2.37-1.1\tx86_64\tSUSE LLC\n"), nil
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		doc := &sbom.Document{}
+		iso := installer.NewMedia(
+			context.Background(), s, installer.ISO,
+			installer.WithBootloader(bootloader.NewNone(s)), installer.WithSBOM(doc, sbom.SPDX, sbom.CycloneDX),
+		)
+		iso.OutputDir = "/some/dir/build"
+
+		Expect(iso.Build(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"rpm", "--root", "/some/dir/build/elemental-installer/osroot", "--dbpath", "/usr/lib/sysimage/rpm", "--query", "--all"},
+		})).To(Succeed())
+		Expect(doc.Name).To(Equal("installer.iso"))
+		Expect(doc.Components).To(ContainElement(sbom.Component{
+			Type: sbom.Package, Name: "bash", Version: "5.2.37-1.1", Arch: "x86_64", Source: "SUSE LLC",
+		}))
+		Expect(vfs.Exists(fs, "/some/dir/build/installer.iso.spdx.json")).To(BeTrue())
+		Expect(vfs.Exists(fs, "/some/dir/build/installer.iso.cdx.json")).To(BeTrue())
+	})
+	It("Creates netboot artifacts", func() {
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.Installer.KernelCmdline = "console=ttyS0"
+
+		netboot := installer.NewMedia(context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewNone(s)))
+		netboot.OutputDir = "/some/dir/build"
+		netboot.NetbootURL = "http://10.0.0.1/installer/"
+
+		Expect(netboot.Build(d)).To(Succeed())
+		Expect(vfs.Exists(fs, "/some/dir/build/installer.netboot/Install/install.yaml")).To(BeTrue())
+		Expect(vfs.Exists(fs, "/some/dir/build/elemental-installer")).To(BeFalse())
+
+		sums, err := fs.ReadFile("/some/dir/build/installer.netboot.sha256")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(sums)).To(ContainSubstring(" Install/install.yaml\n"))
+		Expect(runner.MatchMilestones([][]string{
+			{"mksquashfs", "/some/dir/build/elemental-installer/osroot", "/some/dir/build/elemental-installer/liveroot/LiveOS/squashfs.img"},
+		})).To(Succeed())
+	})
+	It("writes a netboot install description with sources resolvable over the network", func() {
+		sideEffects["tar"] = func(args ...string) ([]byte, error) {
+			// tar gets the real paths of the test filesystem
+			dst := args[3]
+			return nil, fs.WriteFile(dst[strings.Index(dst, "/some/dir/"):], []byte("overlay"), vfs.FilePerm)
+		}
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.CfgScript = "/some/dir/config.sh"
+		d.OverlayTree = deployment.NewDirSrc("/some/dir/install-overlay")
+		Expect(vfs.MkdirAll(fs, "/some/dir/install-overlay", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/dir/config.sh", []byte("install config script"), vfs.FilePerm)).To(Succeed())
+
+		netboot := installer.NewMedia(context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewNone(s)))
+		netboot.OutputDir = "/some/dir/build"
+		netboot.NetbootURL = "http://10.0.0.1/installer/"
+		Expect(netboot.Build(d)).To(Succeed())
+		Expect(vfs.Exists(fs, "/some/dir/build/installer.netboot/Install/Overlay/overlay.tar")).To(BeTrue())
+
+		// the artifacts are served from the netboot URL
+		var downloads []string
+		download := func(_ context.Context, fs vfs.FS, url, path string) error {
+			downloads = append(downloads, url)
+			rel := strings.TrimPrefix(url, "http://10.0.0.1/installer/")
+			return vfs.CopyFile(fs, filepath.Join("/some/dir/build/installer.netboot", rel), path)
+		}
+		Expect(fs.WriteFile("/some/dir/build/installer.netboot/LiveOS/squashfs.img", []byte("image"), vfs.FilePerm)).To(Succeed())
+		remote, err := installer.LoadRemoteInstallDesc(
+			context.Background(), s, "http://10.0.0.1/installer/Install/install.yaml", "/run/config", download,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.SourceOS.String()).To(Equal("raw:///run/config/LiveOS/squashfs.img"))
+		Expect(remote.OverlayTree.String()).To(Equal("tar:///run/config/Overlay/overlay.tar"))
+		Expect(remote.CfgScript).To(Equal("/run/config/setup.sh"))
+		Expect(remote.Installer.OverlayTree).To(BeNil())
+		Expect(downloads).To(ConsistOf(
+			"http://10.0.0.1/installer/Install/install.yaml",
+			"http://10.0.0.1/installer/Install/setup.sh",
+			"http://10.0.0.1/installer/LiveOS/squashfs.img",
+			"http://10.0.0.1/installer/Install/Overlay/overlay.tar",
+		))
+	})
+	It("references the install description in the netboot kernel command line", func() {
+		// the OS root is synced from /some/root, which provides the kernel and GRUB
+		for _, dir := range []string{"/some/root/usr/share/efi/x86_64", "/some/root/usr/share/grub2/x86_64-efi", "/some/root/etc", "/some/root/usr/lib/modules/6.14.4-1-default"} {
+			Expect(vfs.MkdirAll(fs, dir, vfs.DirPerm)).To(Succeed())
+		}
+		Expect(fs.WriteFile("/some/root/usr/share/efi/x86_64/shim.efi", []byte("shim"), vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/root/usr/share/efi/x86_64/MokManager.efi", []byte("mok"), vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/root/usr/share/efi/x86_64/grub.efi", []byte("grub"), vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/root/etc/os-release", []byte("ID=opensuse-tumbleweed\nNAME=openSUSE Tumbleweed"), vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/root/usr/lib/modules/6.14.4-1-default/vmlinuz", []byte("kernel"), vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/root/usr/lib/modules/6.14.4-1-default/initrd", []byte("initrd"), vfs.FilePerm)).To(Succeed())
+		Expect(fs.WriteFile("/some/root/usr/lib/modules/6.14.4-1-default/.vmlinuz.hmac", []byte("hmac"), vfs.FilePerm)).To(Succeed())
+		sideEffects["rsync"] = func(args ...string) ([]byte, error) {
+			// rsync gets the real paths of the test filesystem
+			src, dst := args[len(args)-2], args[len(args)-1]
+			if strings.HasSuffix(filepath.Clean(src), "/some/root") {
+				return nil, vfs.CopyDir(fs, "/some/root", dst[strings.Index(dst, "/some/dir/"):], true, nil)
+			}
+			return nil, nil
+		}
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.Installer.KernelCmdline = "console=ttyS0"
+
+		netboot := installer.NewMedia(context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewGrub(s)))
+		netboot.OutputDir = "/some/dir/build"
+		netboot.NetbootURL = "http://10.0.0.1/installer/"
+		Expect(netboot.Build(d)).To(Succeed())
+
+		cmdline := "root=live:http://10.0.0.1/installer/LiveOS/squashfs.img rd.neednet=1 ip=dhcp rd.live.overlay.overlayfs=1 " +
+			"elemental.install.config=http://10.0.0.1/installer/Install/install.yaml console=ttyS0"
+		ipxe, err := fs.ReadFile("/some/dir/build/installer.netboot/boot.ipxe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(ipxe)).To(ContainSubstring("initrd=initrd " + cmdline + "\n"))
+		grubCfg, err := fs.ReadFile("/some/dir/build/installer.netboot/EFI/BOOT/grub.cfg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(grubCfg)).To(ContainSubstring("/vmlinuz " + cmdline + "\n"))
+	})
+	It("fails to create netboot artifacts without an http URL", func() {
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+
+		netboot := installer.NewMedia(context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewNone(s)))
+		netboot.OutputDir = "/some/dir/build"
+		netboot.NetbootURL = "tftp://10.0.0.1/installer"
+
+		Expect(netboot.Build(d)).To(MatchError(ContainSubstring("invalid netboot URL 'tftp://10.0.0.1/installer'")))
+	})
+	It("Creates an installation disk image converted to qcow2", func() {
+		sideEffects["systemd-repart"] = func(args ...string) ([]byte, error) {
+			Expect(fs.WriteFile(args[len(args)-1], []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte("[]"), nil
+		}
+		sideEffects["qemu-img"] = func(args ...string) ([]byte, error) {
+			Expect(fs.WriteFile(args[len(args)-1], []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+
+		d = deployment.New(deployment.WithRecoveryPartition(1024))
+		d.Installer = deployment.LiveInstaller{}
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		disk := installer.NewMedia(
+			context.Background(), s, installer.Disk,
+			installer.WithBootloader(bootloader.NewNone(s)), installer.WithDiskFormat(diskimage.QCOW2),
+		)
+		disk.OutputDir = "/some/dir/build"
+
+		Expect(disk.Build(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"qemu-img", "convert", "-f", "raw", "-O", "qcow2", "/some/dir/build/installer.raw", "/some/dir/build/installer.qcow2"},
+		})).To(Succeed())
+		Expect(vfs.Exists(fs, "/some/dir/build/installer.qcow2.sha256")).To(BeTrue())
+	})
 	It("fails to create an ISO without an output directory defined", func() {
 		d.SourceOS = deployment.NewDirSrc("/some/root")
 		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
@@ -183,14 +533,6 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 			}
 			return []byte{}, nil
 		}
-		sideEffects["grub2-editenv"] = func(args ...string) ([]byte, error) {
-			path := args[0]
-			if args[1] == "set" {
-				Expect(fs.WriteFile(path, []byte(strings.Join(args[2:], "\n")), vfs.FilePerm)).To(Succeed())
-			}
-			return []byte{}, nil
-		}
-
 		_, err := fs.Create("/some/dir/installer.iso")
 		Expect(err).To(Succeed())
 
@@ -252,3 +594,14 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 		Expect(err.Error()).To(ContainSubstring("failed to run xorriso"))
 	})
 })
+
+// liveCmdlineRecorder is a bootloader keeping the kernel command line of the live media
+type liveCmdlineRecorder struct {
+	*bootloader.None
+	cmdline string
+}
+
+func (l *liveCmdlineRecorder) InstallLive(_, _, kernelCmdline string) error {
+	l.cmdline = kernelCmdline
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// ConfigCmdlineArg is the kernel argument referencing an install description to apply
+	// over the one included in the installer media
+	ConfigCmdlineArg = "elemental.install.config"
+
+	// RemoteConfigDir is the directory where the install description referenced in the
+	// kernel command line and its configuration script are fetched to
+	RemoteConfigDir = "/run/elemental/install"
+
+	labelPrefix = "LABEL="
+	filePrefix  = "file://"
+)
+
+// DownloadFunc downloads the given URL to the given path
+type DownloadFunc func(ctx context.Context, fs vfs.FS, url, path string) error
+
+// ConfigFromCmdline returns the install description reference set in the kernel command line
+// with the ConfigCmdlineArg argument. Returns an empty string if not set.
+func ConfigFromCmdline(s *sys.System) string {
+	cmdline, err := s.FS().ReadFile("/proc/cmdline")
+	if err != nil {
+		return ""
+	}
+	var ref string
+	for _, field := range strings.Fields(string(cmdline)) {
+		if value, ok := strings.CutPrefix(field, ConfigCmdlineArg+"="); ok {
+			ref = value
+		}
+	}
+	return ref
+}
+
+// LoadRemoteInstallDesc fetches the install description referenced by ref into destDir and parses it
+// into a new deployment. The reference is an http(s) URL, a file:// URI or a 'LABEL=<label>:<path>'
+// path within the filesystem of the given label. Configuration scripts, raw or tar OS images and overlays
+// set in the description as relative paths or as references are also fetched, relative paths are
+// resolved against ref.
+func LoadRemoteInstallDesc(ctx context.Context, s *sys.System, ref, destDir string, download DownloadFunc) (*deployment.Deployment, error) {
+	err := vfs.MkdirAll(s.FS(), destDir, vfs.DirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("creating directory '%s': %w", destDir, err)
+	}
+
+	installDst := filepath.Join(destDir, installCfg)
+	err = fetchConfigFile(ctx, s, ref, installDst, download)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching install description '%s': %w", ref, err)
+	}
+
+	data, err := s.FS().ReadFile(installDst)
+	if err != nil {
+		return nil, fmt.Errorf("reading deployment file '%s': %w", installDst, err)
+	}
+	d := &deployment.Deployment{}
+	err = yaml.Unmarshal(data, d)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling deployment file '%s': %w", ref, err)
+	}
+
+	d.CfgScript, err = fetchAsset(ctx, s, ref, d.CfgScript, filepath.Join(destDir, cfgScript), download)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching configuration script: %w", err)
+	}
+	d.Installer.CfgScript, err = fetchAsset(
+		ctx, s, ref, d.Installer.CfgScript, filepath.Join(destDir, liveDir, cfgScript), download,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching installer configuration script: %w", err)
+	}
+
+	// descriptions of netboot media refer to the live image and overlay relative to them
+	d.SourceOS, err = fetchImageAsset(ctx, s, ref, d.SourceOS, filepath.Join(destDir, liveDir), download)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching OS image: %w", err)
+	}
+	d.OverlayTree, err = fetchImageAsset(ctx, s, ref, d.OverlayTree, filepath.Join(destDir, overlayDir), download)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching overlay tree: %w", err)
+	}
+	s.Logger().Info("Loaded deployment description: %s", ref)
+
+	return d, nil
+}
+
+// fetchAsset fetches the asset of the install description referenced by ref to the given destination
+// and returns its new path. Absolute paths are returned unchanged, references and relative paths,
+// which are resolved against ref, are fetched.
+func fetchAsset(ctx context.Context, s *sys.System, ref, asset, dst string, download DownloadFunc) (string, error) {
+	if asset == "" || filepath.IsAbs(asset) {
+		return asset, nil
+	}
+	assetRef := asset
+	if !isConfigRef(asset) {
+		var err error
+		assetRef, err = resolveConfigRef(ref, asset)
+		if err != nil {
+			return "", err
+		}
+	}
+	err := vfs.MkdirAll(s.FS(), filepath.Dir(dst), vfs.DirPerm)
+	if err != nil {
+		return "", fmt.Errorf("creating directory '%s': %w", filepath.Dir(dst), err)
+	}
+	err = fetchConfigFile(ctx, s, assetRef, dst, download)
+	if err != nil {
+		return "", fmt.Errorf("fetching '%s': %w", assetRef, err)
+	}
+	return dst, nil
+}
+
+// fetchImageAsset fetches the raw or tar image source set with a relative path into destDir and
+// returns the source of the fetched file. Any other image source is returned unchanged.
+func fetchImageAsset(ctx context.Context, s *sys.System, ref string, src *deployment.ImageSource, destDir string, download DownloadFunc) (*deployment.ImageSource, error) {
+	if src == nil || !(src.IsRaw() || src.IsTar()) || filepath.IsAbs(src.URI()) {
+		return src, nil
+	}
+	path, err := fetchAsset(ctx, s, ref, src.URI(), filepath.Join(destDir, filepath.Base(src.URI())), download)
+	if err != nil {
+		return nil, err
+	}
+	fetched := deployment.NewRawSrc(path)
+	if src.IsTar() {
+		fetched = deployment.NewTarSrc(path)
+	}
+	fetched.SetDigest(src.GetDigest())
+	return fetched, nil
+}
+
+// isConfigRef returns true if the given string is a reference supported by fetchConfigFile
+func isConfigRef(ref string) bool {
+	return strings.HasPrefix(ref, labelPrefix) || strings.Contains(ref, "://")
+}
+
+// resolveConfigRef resolves the relative path rel against the location of the given reference
+func resolveConfigRef(ref, rel string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, labelPrefix):
+		label, path, _ := strings.Cut(ref, ":")
+		return fmt.Sprintf("%s:%s", label, filepath.Join(filepath.Dir(path), rel)), nil
+	case strings.HasPrefix(ref, filePrefix):
+		return filePrefix + filepath.Join(filepath.Dir(strings.TrimPrefix(ref, filePrefix)), rel), nil
+	default:
+		base, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("parsing URL '%s': %w", ref, err)
+		}
+		relURL, err := url.Parse(rel)
+		if err != nil {
+			return "", fmt.Errorf("parsing relative path '%s': %w", rel, err)
+		}
+		return base.ResolveReference(relURL).String(), nil
+	}
+}
+
+// fetchConfigFile copies the file referenced by ref to the given destination path
+func fetchConfigFile(ctx context.Context, s *sys.System, ref, dst string, download DownloadFunc) (err error) {
+	switch {
+	case strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://"):
+		return download(ctx, s.FS(), ref, dst)
+	case strings.HasPrefix(ref, filePrefix):
+		return vfs.CopyFile(s.FS(), strings.TrimPrefix(ref, filePrefix), dst)
+	case strings.HasPrefix(ref, labelPrefix):
+		label, path, ok := strings.Cut(strings.TrimPrefix(ref, labelPrefix), ":")
+		if !ok || label == "" || path == "" {
+			return fmt.Errorf("invalid reference '%s', expected 'LABEL=<label>:<path>'", ref)
+		}
+		part, err := block.GetPartitionByLabel(s, lsblk.NewLsDevice(s), label, 4)
+		if err != nil {
+			return fmt.Errorf("finding device with label '%s': %w", label, err)
+		}
+		if len(part.MountPoints) > 0 {
+			return vfs.CopyFile(s.FS(), filepath.Join(part.MountPoints[0], path), dst)
+		}
+
+		mountPoint, err := vfs.TempDir(s.FS(), "", "elemental-config")
+		if err != nil {
+			return fmt.Errorf("creating temporary mount point: %w", err)
+		}
+		defer func() { err = errors.Join(err, s.FS().RemoveAll(mountPoint)) }()
+
+		err = s.Mounter().Mount(part.Path, mountPoint, "auto", []string{"ro"})
+		if err != nil {
+			return fmt.Errorf("mounting '%s': %w", part.Path, err)
+		}
+		defer func() { err = errors.Join(err, s.Mounter().Unmount(mountPoint)) }()
+
+		return vfs.CopyFile(s.FS(), filepath.Join(mountPoint, path), dst)
+	default:
+		return fmt.Errorf("unsupported reference '%s', expected an http(s) URL, a file:// URI or 'LABEL=<label>:<path>'", ref)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const configLsblk = `{
+	"blockdevices": [
+	   {
+		  "label": "CONFIG",
+		  "partlabel": "",
+		  "size": 1048576,
+		  "fstype": "vfat",
+		  "mountpoints": [%s],
+		  "path": "/dev/sdb1",
+		  "pkname": "/dev/sdb",
+		  "type": "part"
+	   }
+	]
+ }`
+
+const remoteInstall = `disks:
+- target: /dev/vda
+configScript: scripts/setup.sh
+`
+
+var _ = Describe("Remote install description", Label("installermedia", "remoteconfig"), func() {
+	var runner *sysmock.Runner
+	var mounter *sysmock.Mounter
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var downloads []string
+	var download installer.DownloadFunc
+
+	BeforeEach(func() {
+		var err error
+		downloads = []string{}
+		runner = sysmock.NewRunner()
+		mounter = sysmock.NewMounter()
+		fs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/proc/cmdline":                        "BOOT_IMAGE=/boot/vmlinuz elemental.install.config=file:///configs/install.yaml quiet",
+			"/configs/install.yaml":                remoteInstall,
+			"/configs/scripts/setup.sh":            "#!/bin/sh",
+			"/media/config/node1/install.yaml":     remoteInstall,
+			"/media/config/node1/scripts/setup.sh": "#!/bin/sh",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs), sys.WithMounter(mounter),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		download = func(_ context.Context, fs vfs.FS, url, path string) error {
+			downloads = append(downloads, url)
+			data := remoteInstall
+			if url != "http://example.com/configs/install.yaml" {
+				data = "#!/bin/sh"
+			}
+			return fs.WriteFile(path, []byte(data), vfs.FilePerm)
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("reads the install description reference from the kernel command line", func() {
+		Expect(installer.ConfigFromCmdline(s)).To(Equal("file:///configs/install.yaml"))
+
+		Expect(fs.WriteFile("/proc/cmdline", []byte("quiet"), vfs.FilePerm)).To(Succeed())
+		Expect(installer.ConfigFromCmdline(s)).To(BeEmpty())
+	})
+	It("loads the install description and its configuration script from a local file", func() {
+		d, err := installer.LoadRemoteInstallDesc(context.Background(), s, "file:///configs/install.yaml", "/run/config", download)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.CfgScript).To(Equal("/run/config/setup.sh"))
+		Expect(d.Disks).To(HaveLen(1))
+		Expect(d.Disks[0].Device).To(Equal("/dev/vda"))
+		Expect(vfs.Exists(fs, "/run/config/setup.sh")).To(BeTrue())
+		Expect(downloads).To(BeEmpty())
+	})
+	It("downloads the install description and its configuration script", func() {
+		d, err := installer.LoadRemoteInstallDesc(context.Background(), s, "http://example.com/configs/install.yaml", "/run/config", download)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.CfgScript).To(Equal("/run/config/setup.sh"))
+		Expect(downloads).To(Equal([]string{
+			"http://example.com/configs/install.yaml",
+			"http://example.com/configs/scripts/setup.sh",
+		}))
+	})
+	It("fetches the assets of a netboot media description relative to it", func() {
+		download = func(_ context.Context, fs vfs.FS, url, path string) error {
+			downloads = append(downloads, url)
+			data := "disks:\n- target: /dev/vda\nconfigScript: setup.sh\n" +
+				"sourceOS:\n  uri: raw://../LiveOS/squashfs.img\noverlayTree:\n  uri: tar://Overlay/overlay.tar\n" +
+				"installer:\n  configScript: ../LiveOS/setup.sh\n"
+			if url != "http://10.0.0.1/installer/Install/install.yaml" {
+				data = "content"
+			}
+			return fs.WriteFile(path, []byte(data), vfs.FilePerm)
+		}
+		d, err := installer.LoadRemoteInstallDesc(context.Background(), s, "http://10.0.0.1/installer/Install/install.yaml", "/run/config", download)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.CfgScript).To(Equal("/run/config/setup.sh"))
+		Expect(d.Installer.CfgScript).To(Equal("/run/config/LiveOS/setup.sh"))
+		Expect(d.SourceOS.IsRaw()).To(BeTrue())
+		Expect(d.SourceOS.URI()).To(Equal("/run/config/LiveOS/squashfs.img"))
+		Expect(d.OverlayTree.IsTar()).To(BeTrue())
+		Expect(d.OverlayTree.URI()).To(Equal("/run/config/Overlay/overlay.tar"))
+		Expect(downloads).To(Equal([]string{
+			"http://10.0.0.1/installer/Install/install.yaml",
+			"http://10.0.0.1/installer/Install/setup.sh",
+			"http://10.0.0.1/installer/LiveOS/setup.sh",
+			"http://10.0.0.1/installer/LiveOS/squashfs.img",
+			"http://10.0.0.1/installer/Install/Overlay/overlay.tar",
+		}))
+	})
+	It("reads the install description from a mounted filesystem by label", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return fmt.Appendf(nil, configLsblk, `"/media/config"`), nil
+			}
+			return nil, nil
+		}
+		d, err := installer.LoadRemoteInstallDesc(context.Background(), s, "LABEL=CONFIG:/node1/install.yaml", "/run/config", download)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.CfgScript).To(Equal("/run/config/setup.sh"))
+		Expect(vfs.Exists(fs, "/run/config/install.yaml")).To(BeTrue())
+	})
+	It("mounts the filesystem of the given label if not mounted", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return fmt.Appendf(nil, configLsblk, ""), nil
+			}
+			return nil, nil
+		}
+		mounter.ErrorOnMount = true
+		_, err := installer.LoadRemoteInstallDesc(context.Background(), s, "LABEL=CONFIG:/node1/install.yaml", "/run/config", download)
+		Expect(err).To(MatchError(ContainSubstring("mounting '/dev/sdb1'")))
+	})
+	It("fails on unsupported or invalid references", func() {
+		_, err := installer.LoadRemoteInstallDesc(context.Background(), s, "ftp://example.com/install.yaml", "/run/config", download)
+		Expect(err).To(MatchError(ContainSubstring("unsupported reference")))
+
+		_, err = installer.LoadRemoteInstallDesc(context.Background(), s, "LABEL=CONFIG", "/run/config", download)
+		Expect(err).To(MatchError(ContainSubstring("expected 'LABEL=<label>:<path>'")))
+
+		_, err = installer.LoadRemoteInstallDesc(context.Background(), s, "file:///configs/missing.yaml", "/run/config", download)
+		Expect(err).To(MatchError(ContainSubstring("failed fetching install description")))
+	})
+})
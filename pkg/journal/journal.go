@@ -0,0 +1,199 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// DefaultDir is the default location of the transactions journal, it must be persistent
+// across reboots and not part of any snapshot.
+const DefaultDir = "/var/lib/elemental/journal"
+
+const journalFile = "journal.yaml"
+
+// Phase is a completed step of a transaction
+type Phase string
+
+const (
+	Started    Phase = "started"
+	Synced     Phase = "synced"
+	Merged     Phase = "merged"
+	Locked     Phase = "locked"
+	Bootloader Phase = "bootloader"
+)
+
+// Record is the persisted state of an in progress transaction
+type Record struct {
+	TransactionID int       `yaml:"transactionID"`
+	Path          string    `yaml:"path"`
+	Started       time.Time `yaml:"started"`
+	Phases        []Phase   `yaml:"phases"`
+	// Backups maps the original files to their backup copies within the journal directory
+	Backups map[string]string `yaml:"backups,omitempty"`
+}
+
+// LastPhase returns the last completed phase of the transaction
+func (r Record) LastPhase() Phase {
+	if len(r.Phases) == 0 {
+		return ""
+	}
+	return r.Phases[len(r.Phases)-1]
+}
+
+// Journal persists the progress of a transaction, so a transaction interrupted by a crash
+// or a power loss can be detected and reverted on the next run.
+type Journal struct {
+	s      *sys.System
+	dir    string
+	record *Record
+}
+
+func New(s *sys.System, dir string) *Journal {
+	return &Journal{s: s, dir: dir}
+}
+
+// Begin starts the journal of a new transaction. It fails if there is an unfinished one.
+func (j *Journal) Begin(id int, path string) error {
+	rec, err := j.Load()
+	if err != nil {
+		return err
+	}
+	if rec != nil {
+		return fmt.Errorf("found unfinished transaction '%d' in journal", rec.TransactionID)
+	}
+
+	err = vfs.MkdirAll(j.s.FS(), j.dir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+	j.record = &Record{TransactionID: id, Path: path, Started: time.Now().UTC(), Backups: map[string]string{}}
+	return j.Record(Started)
+}
+
+// Record persists the given phase as completed
+func (j *Journal) Record(phase Phase) error {
+	if j.record == nil {
+		return fmt.Errorf("no transaction in journal")
+	}
+	j.record.Phases = append(j.record.Phases, phase)
+	return j.write()
+}
+
+// Backup copies the given file into the journal, so it can be restored if the transaction
+// is reverted. Non existing files are ignored.
+func (j *Journal) Backup(file string) error {
+	if j.record == nil {
+		return fmt.Errorf("no transaction in journal")
+	}
+	if _, ok := j.record.Backups[file]; ok {
+		return nil
+	}
+	if ok, _ := vfs.Exists(j.s.FS(), file); !ok {
+		return nil
+	}
+	backup := filepath.Join(j.dir, "backup-"+strconv.Itoa(len(j.record.Backups)))
+	err := vfs.CopyFile(j.s.FS(), file, backup)
+	if err != nil {
+		return fmt.Errorf("backing up '%s': %w", file, err)
+	}
+	j.record.Backups[file] = backup
+	return j.write()
+}
+
+// Load reads the journal record of an unfinished transaction, if any
+func (j *Journal) Load() (*Record, error) {
+	data, err := j.s.FS().ReadFile(filepath.Join(j.dir, journalFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+
+	rec := &Record{}
+	err = yaml.Unmarshal(data, rec)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling journal: %w", err)
+	}
+	j.record = rec
+	return rec, nil
+}
+
+// Restore copies back all the backed up files of the current record
+func (j *Journal) Restore() error {
+	if j.record == nil {
+		return nil
+	}
+	for file, backup := range j.record.Backups {
+		j.s.Logger().Info("Restoring '%s' from journal", file)
+		err := vfs.CopyFile(j.s.FS(), backup, file)
+		if err != nil {
+			return fmt.Errorf("restoring '%s': %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Finish closes the journal of the current transaction, removing any backup
+func (j *Journal) Finish() error {
+	j.record = nil
+	err := j.s.FS().RemoveAll(j.dir)
+	if err != nil {
+		return fmt.Errorf("removing journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) write() error {
+	data, err := yaml.Marshal(j.record)
+	if err != nil {
+		return fmt.Errorf("marshalling journal: %w", err)
+	}
+	journal := filepath.Join(j.dir, journalFile)
+	tmp := journal + ".tmp"
+	f, err := j.s.FS().OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	// the journal is flushed to disk before being renamed, rename is atomic
+	// hence the journal is never left half written
+	err = j.s.FS().Rename(tmp, journal)
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
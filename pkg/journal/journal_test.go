@@ -0,0 +1,109 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/journal"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestJournalSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Journal test suite")
+}
+
+var _ = Describe("Journal", Label("journal"), func() {
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var j *journal.Journal
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/boot/efi/grubenv": "saved_entry=active",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		j = journal.New(s, journal.DefaultDir)
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("records the phases of a transaction", func() {
+		rec, err := j.Load()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rec).To(BeNil())
+
+		Expect(j.Begin(3, "/.snapshots/3/snapshot")).To(Succeed())
+		Expect(j.Record(journal.Synced)).To(Succeed())
+
+		rec, err = journal.New(s, journal.DefaultDir).Load()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rec.TransactionID).To(Equal(3))
+		Expect(rec.Path).To(Equal("/.snapshots/3/snapshot"))
+		Expect(rec.Phases).To(Equal([]journal.Phase{journal.Started, journal.Synced}))
+		Expect(rec.LastPhase()).To(Equal(journal.Synced))
+	})
+	It("fails to begin a transaction if there is an unfinished one", func() {
+		Expect(j.Begin(3, "/.snapshots/3/snapshot")).To(Succeed())
+		err := journal.New(s, journal.DefaultDir).Begin(4, "/.snapshots/4/snapshot")
+		Expect(err).To(MatchError("found unfinished transaction '3' in journal"))
+	})
+	It("fails to record a phase without a transaction", func() {
+		Expect(j.Record(journal.Merged)).To(MatchError("no transaction in journal"))
+	})
+	It("restores backed up files", func() {
+		Expect(j.Begin(3, "/.snapshots/3/snapshot")).To(Succeed())
+		Expect(j.Backup("/boot/efi/grubenv")).To(Succeed())
+		Expect(j.Backup("/boot/efi/missing")).To(Succeed())
+		Expect(fs.WriteFile("/boot/efi/grubenv", []byte("saved_entry=new"), vfs.FilePerm)).To(Succeed())
+
+		j = journal.New(s, journal.DefaultDir)
+		rec, err := j.Load()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rec.Backups).To(HaveLen(1))
+		Expect(j.Restore()).To(Succeed())
+
+		data, err := fs.ReadFile("/boot/efi/grubenv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("saved_entry=active"))
+		ok, _ := vfs.Exists(fs, "/boot/efi/missing")
+		Expect(ok).To(BeFalse())
+	})
+	It("removes the journal once finished", func() {
+		Expect(j.Begin(3, "/.snapshots/3/snapshot")).To(Succeed())
+		Expect(j.Backup("/boot/efi/grubenv")).To(Succeed())
+		Expect(j.Finish()).To(Succeed())
+
+		ok, _ := vfs.Exists(fs, journal.DefaultDir)
+		Expect(ok).To(BeFalse())
+		rec, err := j.Load()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rec).To(BeNil())
+	})
+})
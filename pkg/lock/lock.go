@@ -0,0 +1,163 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// DefaultPath is the system wide lock file shared by all the commands modifying the system
+const DefaultPath = "/run/elemental/elemental.lock"
+
+const pollInterval = 500 * time.Millisecond
+
+// Lock is an exclusive advisory lock over a file. The lock is bound to the process
+// holding the file open, hence it is released by the kernel if the process dies.
+type Lock struct {
+	s    *sys.System
+	path string
+	f    *os.File
+}
+
+type Option func(*options)
+
+type options struct {
+	wait    bool
+	timeout time.Duration
+}
+
+// WithWait makes Acquire wait for the lock to be released by its current holder. A zero
+// timeout waits forever.
+func WithWait(timeout time.Duration) Option {
+	return func(o *options) {
+		o.wait = true
+		o.timeout = timeout
+	}
+}
+
+// HolderError is returned when the lock is held by another process
+type HolderError struct {
+	PID     int
+	Command string
+}
+
+func (h *HolderError) Error() string {
+	if h.PID == 0 {
+		return "system is locked by another process"
+	}
+	return fmt.Sprintf("system is locked by process %d ('%s')", h.PID, h.Command)
+}
+
+// Acquire takes the exclusive lock over the given path. By default it fails immediately
+// if the lock is already held.
+func Acquire(s *sys.System, path string, opts ...Option) (*Lock, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	err := vfs.MkdirAll(s.FS(), filepath.Dir(path), vfs.DirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+	f, err := s.FS().OpenFile(path, os.O_RDWR|os.O_CREATE, vfs.FilePerm)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	var deadline time.Time
+	if o.timeout > 0 {
+		deadline = time.Now().Add(o.timeout)
+	}
+	logged := false
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			_ = f.Close()
+			return nil, fmt.Errorf("locking '%s': %w", path, err)
+		}
+		holder := readHolder(f)
+		if !o.wait || (!deadline.IsZero() && time.Now().After(deadline)) {
+			_ = f.Close()
+			return nil, holder
+		}
+		if !logged {
+			s.Logger().Info("Waiting for the lock to be released: %v", holder)
+			logged = true
+		}
+		time.Sleep(pollInterval)
+	}
+
+	l := &Lock{s: s, path: path, f: f}
+	err = l.writeHolder()
+	if err != nil {
+		_ = l.Release()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Release frees the lock
+func (l *Lock) Release() error {
+	if l.f == nil {
+		return nil
+	}
+	// the holder is cleared before unlocking, so it never names a process not holding the lock
+	_ = l.f.Truncate(0)
+	err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	err = errors.Join(err, l.f.Close())
+	l.f = nil
+	if err != nil {
+		return fmt.Errorf("releasing lock '%s': %w", l.path, err)
+	}
+	return nil
+}
+
+// writeHolder stores the PID and the command line of the current process in the lock file
+func (l *Lock) writeHolder() error {
+	data := fmt.Sprintf("%d\n%s\n", os.Getpid(), strings.Join(os.Args, " "))
+	err := l.f.Truncate(0)
+	if err == nil {
+		_, err = l.f.WriteAt([]byte(data), 0)
+	}
+	if err != nil {
+		return fmt.Errorf("writing lock holder: %w", err)
+	}
+	return nil
+}
+
+func readHolder(f *os.File) *HolderError {
+	buf := make([]byte, 4096)
+	n, _ := f.ReadAt(buf, 0)
+	pid, cmd, _ := strings.Cut(string(buf[:n]), "\n")
+	id, _ := strconv.Atoi(pid)
+	return &HolderError{PID: id, Command: strings.TrimSpace(cmd)}
+}
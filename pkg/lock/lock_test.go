@@ -0,0 +1,97 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/lock"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestLockSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lock test suite")
+}
+
+var _ = Describe("Lock", Label("lock"), func() {
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("acquires and releases the lock", func() {
+		l, err := lock.Acquire(s, lock.DefaultPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Release()).To(Succeed())
+
+		l, err = lock.Acquire(s, lock.DefaultPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Release()).To(Succeed())
+	})
+	It("fails to acquire a lock held by another process and names the holder", func() {
+		l, err := lock.Acquire(s, lock.DefaultPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer l.Release()
+
+		_, err = lock.Acquire(s, lock.DefaultPath)
+		Expect(err).To(HaveOccurred())
+		var holder *lock.HolderError
+		Expect(errors.As(err, &holder)).To(BeTrue())
+		Expect(holder.PID).To(Equal(os.Getpid()))
+		Expect(holder.Command).NotTo(BeEmpty())
+	})
+	It("waits for the lock to be released", func() {
+		l, err := lock.Acquire(s, lock.DefaultPath)
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_ = l.Release()
+		}()
+
+		l2, err := lock.Acquire(s, lock.DefaultPath, lock.WithWait(5*time.Second))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l2.Release()).To(Succeed())
+	})
+	It("fails if the lock is not released before the timeout", func() {
+		l, err := lock.Acquire(s, lock.DefaultPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer l.Release()
+
+		_, err = lock.Acquire(s, lock.DefaultPath, lock.WithWait(100*time.Millisecond))
+		Expect(err).To(MatchError(ContainSubstring("system is locked by process")))
+	})
+})
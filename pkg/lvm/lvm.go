@@ -0,0 +1,86 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lvm
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// DevicePath returns the device mapper path of the given logical volume
+func DevicePath(vg, lv string) string {
+	return filepath.Join("/dev", vg, lv)
+}
+
+// CreatePhysicalVolume initializes the given block device as an LVM physical volume.
+// Any pre-existing LVM signature in the device is overwritten.
+func CreatePhysicalVolume(s *sys.System, device string) error {
+	s.Logger().Debug("Creating LVM physical volume: %s", device)
+	cmdOut, err := s.Runner().Run("pvcreate", "--force", "--yes", device)
+	if err != nil {
+		return fmt.Errorf("creating physical volume %s: %s: %w", device, string(cmdOut), err)
+	}
+	return nil
+}
+
+// CreateVolumeGroup creates the given volume group including all the given physical volumes
+func CreateVolumeGroup(s *sys.System, vg string, devices ...string) error {
+	s.Logger().Debug("Creating LVM volume group: %s", vg)
+	args := append([]string{"--yes", vg}, devices...)
+	cmdOut, err := s.Runner().Run("vgcreate", args...)
+	if err != nil {
+		return fmt.Errorf("creating volume group %s: %s: %w", vg, string(cmdOut), err)
+	}
+	return nil
+}
+
+// CreateLogicalVolume creates a logical volume of the given size in MiB within the given
+// volume group. A zero size allocates all the remaining free space of the volume group.
+func CreateLogicalVolume(s *sys.System, vg, lv string, size uint) error {
+	s.Logger().Debug("Creating LVM logical volume: %s/%s", vg, lv)
+	args := []string{"--yes", "--wipesignatures", "y", "--name", lv}
+	if size == 0 {
+		args = append(args, "--extents", "100%FREE")
+	} else {
+		args = append(args, "--size", fmt.Sprintf("%dM", size))
+	}
+	args = append(args, vg)
+	cmdOut, err := s.Runner().Run("lvcreate", args...)
+	if err != nil {
+		return fmt.Errorf("creating logical volume %s/%s: %s: %w", vg, lv, string(cmdOut), err)
+	}
+	return nil
+}
+
+// ActivateVolumeGroup activates all the logical volumes of the given volume group
+func ActivateVolumeGroup(s *sys.System, vg string) error {
+	s.Logger().Debug("Activating LVM volume group: %s", vg)
+	cmdOut, err := s.Runner().Run("vgchange", "--activate", "y", vg)
+	if err != nil {
+		return fmt.Errorf("activating volume group %s: %s: %w", vg, string(cmdOut), err)
+	}
+	return nil
+}
+
+// VolumeGroupExists returns true if the given volume group is known to the host
+func VolumeGroupExists(s *sys.System, vg string) bool {
+	_, err := s.Runner().Run("vgs", "--noheadings", vg)
+	return err == nil
+}
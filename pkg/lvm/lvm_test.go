@@ -0,0 +1,96 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lvm_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/lvm"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestLVMSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LVM test suite")
+}
+
+var _ = Describe("LVM", Label("lvm"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var err error
+	var runner *sysmock.Runner
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithRunner(runner),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("returns the device path of a logical volume", func() {
+		Expect(lvm.DevicePath("data", "logs")).To(Equal("/dev/data/logs"))
+	})
+	It("creates a physical volume", func() {
+		Expect(lvm.CreatePhysicalVolume(s, "/dev/sda3")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"pvcreate", "--force", "--yes", "/dev/sda3"},
+		})).To(Succeed())
+	})
+	It("creates a volume group", func() {
+		Expect(lvm.CreateVolumeGroup(s, "data", "/dev/sda3", "/dev/sdb1")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"vgcreate", "--yes", "data", "/dev/sda3", "/dev/sdb1"},
+		})).To(Succeed())
+	})
+	It("creates logical volumes", func() {
+		Expect(lvm.CreateLogicalVolume(s, "data", "logs", 2048)).To(Succeed())
+		Expect(lvm.CreateLogicalVolume(s, "data", "db", 0)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"lvcreate", "--yes", "--wipesignatures", "y", "--name", "logs", "--size", "2048M", "data"},
+			{"lvcreate", "--yes", "--wipesignatures", "y", "--name", "db", "--extents", "100%FREE", "data"},
+		})).To(Succeed())
+	})
+	It("activates a volume group", func() {
+		Expect(lvm.ActivateVolumeGroup(s, "data")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"vgchange", "--activate", "y", "data"},
+		})).To(Succeed())
+	})
+	It("checks if a volume group exists", func() {
+		Expect(lvm.VolumeGroupExists(s, "data")).To(BeTrue())
+		runner.ReturnError = errors.New("volume group not found")
+		Expect(lvm.VolumeGroupExists(s, "data")).To(BeFalse())
+	})
+	It("fails to create a logical volume", func() {
+		runner.ReturnError = errors.New("insufficient free space")
+		Expect(lvm.CreateLogicalVolume(s, "data", "logs", 2048)).NotTo(Succeed())
+	})
+})
@@ -18,6 +18,7 @@ limitations under the License.
 package resolver
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 
@@ -31,6 +32,15 @@ type ResolvedManifest struct {
 	CorePlatform *core.ReleaseManifest
 	// Product release manifest that extends the core platform
 	ProductExtension *product.ReleaseManifest
+	// Sources of the resolved release manifests, in resolution order
+	Sources []Source
+}
+
+// Source is the location of a resolved release manifest
+type Source struct {
+	URI string
+	// Digest is the sha256 digest of the release manifest file, in '<algorithm>:<hex>' form
+	Digest string
 }
 
 type SourceReader interface {
@@ -73,6 +83,7 @@ func (r *Resolver) resolveRecursive(uri string, rm *ResolvedManifest) error {
 	if len(data) == 0 {
 		return fmt.Errorf("empty file passed as release manifest: '%s'", rmSrc.URI())
 	}
+	rm.Sources = append(rm.Sources, Source{URI: uri, Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(data))})
 
 	productManifest, prodErr := product.Parse(data)
 	if prodErr != nil {
@@ -180,6 +180,16 @@ func validateResolvedManifest(rm *resolver.ResolvedManifest, coreOnly bool) {
 	Expect(rm.CorePlatform.Components.Helm.Repositories[0].Name).To(Equal("foo-charts"))
 	Expect(rm.CorePlatform.Components.Helm.Repositories[0].URL).To(Equal("https://foo.github.io/charts"))
 
+	if coreOnly {
+		Expect(rm.Sources).To(HaveLen(1))
+	} else {
+		Expect(rm.Sources).To(HaveLen(2))
+		Expect(rm.Sources[1].URI).To(Equal(fmt.Sprintf("%s://%s", source.OCI, expectedCorePlatformImage)))
+	}
+	for _, src := range rm.Sources {
+		Expect(src.Digest).To(MatchRegexp("^sha256:[0-9a-f]{64}$"))
+	}
+
 	if !coreOnly {
 		Expect(rm.ProductExtension).ToNot(BeNil())
 
@@ -0,0 +1,233 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/buildmeta"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// StatementType is the in-toto attestation statement type
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the SLSA provenance predicate type
+	PredicateType = "https://slsa.dev/provenance/v1"
+	// BuilderID identifies elemental as the builder of the described artifacts
+	BuilderID = "https://github.com/suse/elemental"
+
+	// EmbeddedPath is the path of the provenance file within the built image
+	EmbeddedPath = "/etc/elemental/provenance.json"
+)
+
+// ResourceDescriptor describes a build input or output, see
+// https://github.com/in-toto/attestation/blob/main/spec/v1/resource_descriptor.md
+type ResourceDescriptor struct {
+	Name        string            `json:"name,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Statement is an in-toto statement with a SLSA provenance predicate
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject,omitempty"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Predicate            `json:"predicate"`
+}
+
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type RunDetails struct {
+	Builder  Builder  `json:"builder"`
+	Metadata Metadata `json:"metadata"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type Metadata struct {
+	StartedOn  *time.Time `json:"startedOn,omitempty"`
+	FinishedOn *time.Time `json:"finishedOn,omitempty"`
+}
+
+// New returns a statement for a build of the given type run by the given elemental version, see
+// buildmeta.Now for the build timestamps
+func New(buildType, version string) (*Statement, error) {
+	started, err := buildmeta.Now()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{
+		Type:          StatementType,
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType:          buildType,
+				ExternalParameters: map[string]any{},
+			},
+			RunDetails: RunDetails{
+				Builder: Builder{
+					ID:      BuilderID,
+					Version: map[string]string{"elemental": version},
+				},
+				Metadata: Metadata{StartedOn: &started},
+			},
+		},
+	}, nil
+}
+
+// SetParameter sets an external parameter of the build
+func (st *Statement) SetParameter(key string, value any) {
+	st.Predicate.BuildDefinition.ExternalParameters[key] = value
+}
+
+// AddDependency appends the given resolved dependencies, dependencies already listed are skipped
+func (st *Statement) AddDependency(deps ...ResourceDescriptor) {
+	definition := &st.Predicate.BuildDefinition
+	definition.ResolvedDependencies = buildmeta.AppendUnique(definition.ResolvedDependencies, dependencyKey, deps...)
+}
+
+// dependencyKey identifies a dependency by its name and URI
+func dependencyKey(dep ResourceDescriptor) [2]string {
+	return [2]string{dep.Name, dep.URI}
+}
+
+// Finish sets the build finish time and the subject of the statement
+func (st *Statement) Finish(subject ...ResourceDescriptor) error {
+	finished, err := buildmeta.Now()
+	if err != nil {
+		return err
+	}
+	st.Predicate.RunDetails.Metadata.FinishedOn = &finished
+	st.Subject = subject
+	return nil
+}
+
+// OutputPath returns the path of the provenance file for the given image
+func OutputPath(image string) string {
+	return image + ".provenance.json"
+}
+
+// Write writes the statement as indented JSON to the given path, creating parent directories as needed
+func Write(s *sys.System, st *Statement, path string) error {
+	data, err := buildmeta.MarshalJSON(st)
+	if err != nil {
+		return fmt.Errorf("rendering provenance: %w", err)
+	}
+
+	if err := vfs.MkdirAll(s.FS(), filepath.Dir(path), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating provenance directory: %w", err)
+	}
+	if err := s.FS().WriteFile(path, data, vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing provenance file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// Digest parses a digest in '<algorithm>:<hex>' form into a digest set, it returns nil
+// for malformed digests
+func Digest(digest string) map[string]string {
+	algorithm, value, ok := strings.Cut(digest, ":")
+	if !ok || algorithm == "" || value == "" {
+		return nil
+	}
+	return map[string]string{algorithm: value}
+}
+
+// FileDigest returns the sha256 digest set of the given file
+func FileDigest(fs vfs.FS, path string) (map[string]string, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("reading '%s': %w", path, err)
+	}
+	return map[string]string{"sha256": fmt.Sprintf("%x", h.Sum(nil))}, nil
+}
+
+// DirDigest returns the sha256 digest set of the regular files found at the given paths relative to
+// root. The digest covers the relative path and content of each file in lexical order, so it does
+// not depend on file timestamps or ownership. Paths that do not exist are skipped.
+func DirDigest(fs vfs.FS, root string, paths ...string) (map[string]string, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		if ok, _ := vfs.Exists(fs, filepath.Join(root, path)); !ok {
+			continue
+		}
+
+		err := vfs.WalkDirFs(fs, filepath.Join(root, path), func(file string, d iofs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			digest, err := FileDigest(fs, file)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(h, "%s  %s\n", digest["sha256"], rel)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("computing digest of '%s': %w", root, err)
+		}
+	}
+	return map[string]string{"sha256": fmt.Sprintf("%x", h.Sum(nil))}, nil
+}
+
+// ImageDependency returns a dependency for the given container image reference, the digest
+// is only set if the reference is pinned to one
+func ImageDependency(name, ref string) ResourceDescriptor {
+	dep := ResourceDescriptor{Name: name, URI: "oci://" + ref}
+	if _, digest, ok := strings.Cut(ref, "@"); ok {
+		dep.Digest = Digest(digest)
+	}
+	return dep
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/provenance"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestProvenanceSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Provenance test suite")
+}
+
+var _ = Describe("Provenance", Label("provenance"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var err error
+	BeforeEach(func() {
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/build/image.raw":               "raw disk",
+			"/config/install.yaml":           "bootloader: grub",
+			"/config/release.yaml":           "manifestURI: file:///config/manifest.yaml",
+			"/config/network/node1.yaml":     "interfaces: []",
+			"/config/image-old.raw":          "previous build",
+			"/config/custom/scripts/run.sh":  "#!/bin/sh",
+			"/config/custom/files/motd.conf": "welcome",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("parses digests and container image references", func() {
+		Expect(provenance.Digest("sha256:abcd")).To(Equal(map[string]string{"sha256": "abcd"}))
+		Expect(provenance.Digest("abcd")).To(BeNil())
+
+		Expect(provenance.ImageDependency("os", "registry.com/foo/bar:1.0@sha256:abcd")).To(Equal(provenance.ResourceDescriptor{
+			Name: "os", URI: "oci://registry.com/foo/bar:1.0@sha256:abcd", Digest: map[string]string{"sha256": "abcd"},
+		}))
+		Expect(provenance.ImageDependency("os", "registry.com/foo/bar:1.0")).To(Equal(provenance.ResourceDescriptor{
+			Name: "os", URI: "oci://registry.com/foo/bar:1.0",
+		}))
+	})
+	It("computes file and directory digests", func() {
+		Expect(provenance.FileDigest(tfs, "/build/image.raw")).To(Equal(map[string]string{
+			// sha256 of 'raw disk'
+			"sha256": "87025b68c50228cec8c29f52307ad92116ff5fd09f65ae2b37b49f862be85222",
+		}))
+		_, err = provenance.FileDigest(tfs, "/build/missing.raw")
+		Expect(err).To(HaveOccurred())
+
+		paths := []string{"install.yaml", "release.yaml", "network", "custom", "kubernetes"}
+		digest, err := provenance.DirDigest(tfs, "/config", paths...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(HaveKey("sha256"))
+
+		By("ignoring files out of the given paths")
+		Expect(tfs.WriteFile("/config/image-new.raw", []byte("new build"), vfs.FilePerm)).To(Succeed())
+		Expect(provenance.DirDigest(tfs, "/config", paths...)).To(Equal(digest))
+
+		By("covering the content of the given paths")
+		Expect(tfs.WriteFile("/config/network/node1.yaml", []byte("interfaces: [eth0]"), vfs.FilePerm)).To(Succeed())
+		Expect(provenance.DirDigest(tfs, "/config", paths...)).NotTo(Equal(digest))
+	})
+	It("writes a provenance statement", func() {
+		GinkgoT().Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		st, err := provenance.New("https://example.com/build/v1", "v1.0.0+gabcdef0")
+		Expect(err).NotTo(HaveOccurred())
+		st.SetParameter("mediaType", "raw")
+		st.AddDependency(
+			provenance.ImageDependency("os", "registry.com/foo/bar:1.0"),
+			provenance.ResourceDescriptor{Name: "release-manifest", URI: "file:///config/manifest.yaml", Digest: provenance.Digest("sha256:abcd")},
+			provenance.ImageDependency("os", "registry.com/foo/bar:1.0"),
+		)
+		Expect(st.Finish(provenance.ResourceDescriptor{Name: "image.raw", Digest: provenance.Digest("sha256:1234")})).To(Succeed())
+		Expect(provenance.Write(s, st, provenance.OutputPath("/build/image.raw"))).To(Succeed())
+
+		data, err := tfs.ReadFile("/build/image.raw.provenance.json")
+		Expect(err).NotTo(HaveOccurred())
+		var doc map[string]any
+		Expect(json.Unmarshal(data, &doc)).To(Succeed())
+		Expect(doc["_type"]).To(Equal(provenance.StatementType))
+		Expect(doc["predicateType"]).To(Equal(provenance.PredicateType))
+		Expect(doc["subject"]).To(Equal([]any{map[string]any{"name": "image.raw", "digest": map[string]any{"sha256": "1234"}}}))
+
+		predicate := doc["predicate"].(map[string]any)
+		definition := predicate["buildDefinition"].(map[string]any)
+		Expect(definition["buildType"]).To(Equal("https://example.com/build/v1"))
+		Expect(definition["externalParameters"]).To(Equal(map[string]any{"mediaType": "raw"}))
+		Expect(definition["resolvedDependencies"]).To(HaveLen(2))
+
+		details := predicate["runDetails"].(map[string]any)
+		Expect(details["builder"]).To(Equal(map[string]any{
+			"id": provenance.BuilderID, "version": map[string]any{"elemental": "v1.0.0+gabcdef0"},
+		}))
+		timestamp := time.Unix(1700000000, 0).UTC().Format(time.RFC3339)
+		Expect(details["metadata"]).To(Equal(map[string]any{"startedOn": timestamp, "finishedOn": timestamp}))
+	})
+	It("fails on invalid SOURCE_DATE_EPOCH values", func() {
+		GinkgoT().Setenv("SOURCE_DATE_EPOCH", "yesterday")
+		_, err := provenance.New("https://example.com/build/v1", "v1.0.0")
+		Expect(err).To(HaveOccurred())
+	})
+})
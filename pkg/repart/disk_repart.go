@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -30,6 +31,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/block/lsblk"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/env"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
@@ -43,6 +45,12 @@ const (
 	// Do not change these values as this could break backward compatibility on already installed systems (e.g. reseting a system)
 	configType   = "2ecf8b13-6846-4e8a-9bc3-284ff5e2ac22"
 	recoveryType = "3265f37b-3105-4777-bd97-cfcd9cc7cf99"
+
+	// Well known GPT partition type for Linux LVM physical volumes
+	lvmType = "e6d6d379-f507-44c2-a23c-238f2a3df928"
+
+	// Well known GPT partition type for the BIOS boot partition embedding grub core image
+	biosType = "21686148-6449-6e6f-744e-656564454649"
 )
 
 //go:embed templates/partition.conf.tpl
@@ -84,8 +92,9 @@ func ReconcileDevicePartitions(s *sys.System, d *deployment.Disk) error {
 	return nil
 }
 
-// CreateDiskImage creates a disk image file with the given size and partitions
-func CreateDiskImage(s *sys.System, filename string, size deployment.MiB, partitions []Partition) error {
+// CreateDiskImage creates a disk image file with the given size and partitions. The optional given
+// flags are appended to the systemd-repart command, e.g. a '--seed' for reproducible UUIDs.
+func CreateDiskImage(s *sys.System, filename string, size deployment.MiB, partitions []Partition, extraFlags ...string) error {
 	s.Logger().Info("Partitioning image '%s'", filename)
 
 	var sizeFlag string
@@ -94,7 +103,7 @@ func CreateDiskImage(s *sys.System, filename string, size deployment.MiB, partit
 	} else {
 		sizeFlag = fmt.Sprintf("--size=%dM", size)
 	}
-	flags := []string{"--empty=create", sizeFlag}
+	flags := append([]string{"--empty=create", sizeFlag}, extraFlags...)
 	return runSystemdRepart(s, filename, partitions, flags...)
 }
 
@@ -225,7 +234,12 @@ func runSystemdRepart(s *sys.System, target string, parts []Partition, flags ...
 	}
 	args = append(args, target)
 
-	out, err := s.Runner().RunEnv("systemd-repart", []string{"PATH=/sbin:/usr/sbin:/usr/bin:/bin"}, args...)
+	environ := []string{"PATH=/sbin:/usr/sbin:/usr/bin:/bin"}
+	// systemd-repart pins the timestamps of the filesystems it creates to SOURCE_DATE_EPOCH
+	if epoch, ok := os.LookupEnv(env.SourceDateEpoch); ok {
+		environ = append(environ, fmt.Sprintf("%s=%s", env.SourceDateEpoch, epoch))
+	}
+	out, err := s.Runner().RunEnv("systemd-repart", environ, args...)
 	if err != nil {
 		return fmt.Errorf("failed partitioning disk '%s' with systemd-repart: %w", target, err)
 	}
@@ -261,12 +275,16 @@ func roleToType(s *sys.System, role deployment.PartRole) string {
 		return genericType
 	case deployment.EFI:
 		return espType
-	case deployment.System:
+	case deployment.System, deployment.SystemB:
 		return fmt.Sprintf(rootArchType, s.Platform().Arch)
 	case deployment.Recovery:
 		return recoveryType
 	case deployment.Config:
 		return configType
+	case deployment.LVM:
+		return lvmType
+	case deployment.BIOS:
+		return biosType
 	default:
 		return deployment.Unknown
 	}
@@ -130,6 +130,33 @@ var _ = Describe("Systemd-repart tests", Label("systemd-repart"), func() {
 		Expect(buffer.String()).ToNot(ContainSubstring("UUID"))
 	})
 
+	It("creates an unformatted partition configuration for LVM partitions", func() {
+		var buffer bytes.Buffer
+		part := &deployment.Partition{
+			Label: "DATA",
+			Role:  deployment.LVM,
+			Size:  4096,
+		}
+
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: part})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Type=e6d6d379-f507-44c2-a23c-238f2a3df928"))
+		Expect(buffer.String()).To(ContainSubstring("SizeMinBytes=4096M"))
+		Expect(buffer.String()).ToNot(ContainSubstring("Format"))
+	})
+
+	It("creates an unformatted partition configuration for BIOS boot partitions", func() {
+		var buffer bytes.Buffer
+		part := &deployment.Partition{
+			Role: deployment.BIOS,
+			Size: 1,
+		}
+
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: part})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Type=21686148-6449-6e6f-744e-656564454649"))
+		Expect(buffer.String()).To(ContainSubstring("SizeMinBytes=1M"))
+		Expect(buffer.String()).ToNot(ContainSubstring("Format"))
+	})
+
 	It("creates a partition configuration file", func() {
 		part := &deployment.Partition{
 			Label: "SYSTEM",
@@ -171,6 +198,14 @@ var _ = Describe("Systemd-repart tests", Label("systemd-repart"), func() {
 			"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d",
 			"--dry-run=no", "--empty=create", "--size=auto", "/temp/dir/image.raw",
 		}}))
+		runner.ClearCmds()
+
+		// Extra flags are appended
+		Expect(repart.CreateDiskImage(s, diskImg, 0, parts, "--seed=0b5e8e2a-2a96-4b6a-9d5c-2e7f8d1c3b4a")).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{
+			"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d", "--dry-run=no",
+			"--empty=create", "--size=auto", "--seed=0b5e8e2a-2a96-4b6a-9d5c-2e7f8d1c3b4a", "/temp/dir/image.raw",
+		}})).To(Succeed())
 	})
 
 	It("reparts a disk with force flag and feeds partition UUIDs", func() {
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/buildmeta"
+)
+
+// CycloneDX 1.5 JSON document, see https://cyclonedx.org/docs/1.5/json/
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type               string           `json:"type"`
+	BOMRef             string           `json:"bom-ref,omitempty"`
+	Name               string           `json:"name"`
+	Version            string           `json:"version,omitempty"`
+	Hashes             []cdxHash        `json:"hashes,omitempty"`
+	PURL               string           `json:"purl,omitempty"`
+	ExternalReferences []cdxExternalRef `json:"externalReferences,omitempty"`
+	Properties         []cdxProperty    `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxExternalRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cycloneDX renders the document in CycloneDX 1.5 JSON format
+func (d Document) cycloneDX() ([]byte, error) {
+	doc := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: fmt.Sprintf("urn:uuid:%s", d.id()),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: d.Created.Format(time.RFC3339),
+			Tools: cdxTools{
+				Components: []cdxComponent{{Type: "application", Name: toolName}},
+			},
+			Component: cdxComponent{Type: "operating-system", BOMRef: "image", Name: d.Name},
+		},
+		Components: []cdxComponent{},
+	}
+
+	for i, c := range d.Components {
+		component := cdxComponent{
+			Type:    cdxType(c.Type),
+			BOMRef:  fmt.Sprintf("%s-%d", c.Type, i),
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.purl(),
+			Properties: []cdxProperty{
+				{Name: "elemental:component-type", Value: string(c.Type)},
+			},
+		}
+		if alg, value, ok := c.digest(); ok {
+			component.Hashes = []cdxHash{{Alg: cdxHashAlg(alg), Content: value}}
+		}
+		if c.Source != "" {
+			component.ExternalReferences = []cdxExternalRef{{Type: "distribution", URL: c.Source}}
+		}
+		if c.Arch != "" {
+			component.Properties = append(component.Properties, cdxProperty{Name: "elemental:arch", Value: c.Arch})
+		}
+		doc.Components = append(doc.Components, component)
+	}
+
+	return buildmeta.MarshalJSON(doc)
+}
+
+func cdxType(cType ComponentType) string {
+	switch cType {
+	case OperatingSystem:
+		return "operating-system"
+	case ContainerImage:
+		return "container"
+	case Package:
+		return "library"
+	default:
+		return "application"
+	}
+}
+
+// cdxHashAlg converts an OCI digest algorithm into the CycloneDX hash algorithm name, e.g. sha256
+// into SHA-256
+func cdxHashAlg(alg string) string {
+	alg = strings.ToUpper(alg)
+	if after, ok := strings.CutPrefix(alg, "SHA"); ok && !strings.HasPrefix(after, "-") {
+		return "SHA-" + after
+	}
+	return alg
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+// ManifestComponents returns the system extensions, Helm charts and container images referenced
+// by the given release manifests
+func ManifestComponents(rm *resolver.ResolvedManifest) []Component {
+	var components []Component
+	if rm == nil {
+		return components
+	}
+
+	if rm.CorePlatform != nil {
+		components = append(components, systemdComponents(rm.CorePlatform.Components.Systemd)...)
+		components = append(components, helmComponents(rm.CorePlatform.Components.Helm)...)
+	}
+	if rm.ProductExtension != nil {
+		components = append(components, systemdComponents(rm.ProductExtension.Components.Systemd)...)
+		components = append(components, helmComponents(rm.ProductExtension.Components.Helm)...)
+	}
+	return components
+}
+
+func systemdComponents(systemd api.Systemd) []Component {
+	var components []Component
+	for _, ext := range systemd.Extensions {
+		c := Component{Type: Extension, Name: ext.Name, Source: ext.Image}
+		if !strings.Contains(ext.Image, "://") {
+			// extensions can also be shipped as container images
+			image := ImageComponent(Extension, ext.Image)
+			c.Version, c.Digest, c.Source = image.Version, image.Digest, image.Name
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+func helmComponents(helm *api.Helm) []Component {
+	var components []Component
+	if helm == nil {
+		return components
+	}
+
+	repositories := map[string]string{}
+	for _, repo := range helm.Repositories {
+		repositories[repo.Name] = repo.URL
+	}
+
+	for _, chart := range helm.Charts {
+		c := Component{Type: HelmChart, Name: chart.Chart, Version: chart.Version, Source: repositories[chart.Repository]}
+		if strings.HasPrefix(chart.Chart, "oci://") {
+			c.Source = chart.Chart
+		}
+		components = append(components, c)
+		for _, image := range chart.Images {
+			components = append(components, ImageComponent(ContainerImage, image.Image))
+		}
+	}
+	return components
+}
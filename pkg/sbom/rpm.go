@@ -0,0 +1,105 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// rpmDBPath is the location of the rpm database within the OS root
+	rpmDBPath = "usr/lib/sysimage/rpm"
+	// rpmQueryFormat lists one package per line, the epoch is only included if set
+	rpmQueryFormat = "%{NAME}\\t%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\t%{ARCH}\\t%{VENDOR}\\n"
+)
+
+// ReadPackages returns the packages installed in the given OS root as listed in its rpm database.
+// Packages are sorted by name. The database path is set explicitly as the host %_dbpath might not
+// match the one of the OS root.
+func ReadPackages(ctx context.Context, s *sys.System, root string) ([]Component, error) {
+	out, err := s.Runner().RunContext(
+		ctx, "rpm", "--root", root, "--dbpath", "/"+rpmDBPath,
+		"--query", "--all", "--queryformat", rpmQueryFormat,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying rpm database in '%s': %w", root, err)
+	}
+
+	var packages []Component
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		// Public keys imported in the database are listed as packages without architecture
+		if fields[2] == "(none)" {
+			continue
+		}
+		pkg := Component{Type: Package, Name: fields[0], Version: fields[1], Arch: fields[2]}
+		if fields[3] != "(none)" {
+			pkg.Source = fields[3]
+		}
+		packages = append(packages, pkg)
+	}
+	slices.SortFunc(packages, func(a, b Component) int {
+		return strings.Compare(a.Name+" "+a.Version+" "+a.Arch, b.Name+" "+b.Version+" "+b.Arch)
+	})
+	return packages, nil
+}
+
+// ReadImagePackages returns the packages installed in the OS root packed in the given live root
+// image. Squashfs images get only the rpm database extracted into the given working directory,
+// EROFS images are mounted read-only there instead.
+func ReadImagePackages(ctx context.Context, s *sys.System, img, workDir string) (packages []Component, err error) {
+	root := filepath.Join(workDir, "sbom-root")
+
+	if imgType, _ := filesystem.DetectLiveImageType(s.FS(), img); imgType == filesystem.EROFSImage {
+		if err = vfs.MkdirAll(s.FS(), root, vfs.DirPerm); err != nil {
+			return nil, fmt.Errorf("creating mount point for '%s': %w", img, err)
+		}
+		if err = s.Mounter().Mount(img, root, string(filesystem.EROFSImage), []string{"ro"}); err != nil {
+			return nil, fmt.Errorf("mounting '%s': %w", img, err)
+		}
+		defer func() {
+			err = errors.Join(err, s.Mounter().Unmount(root), s.FS().RemoveAll(root))
+		}()
+	} else {
+		_, err = s.Runner().RunContext(ctx, "unsquashfs", "-no-xattrs", "-force", "-dest", root, img, rpmDBPath)
+		if err != nil {
+			return nil, fmt.Errorf("extracting rpm database from '%s': %w", img, err)
+		}
+		defer func() { _ = s.FS().RemoveAll(root) }()
+	}
+
+	if ok, _ := vfs.Exists(s.FS(), filepath.Join(root, rpmDBPath)); !ok {
+		return nil, fmt.Errorf("no rpm database found in '%s'", img)
+	}
+	return ReadPackages(ctx, s, root)
+}
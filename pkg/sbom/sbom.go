@@ -0,0 +1,222 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/suse/elemental/v3/pkg/buildmeta"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// Format is the document format of a software bill of materials
+type Format string
+
+const (
+	SPDX      Format = "spdx"
+	CycloneDX Format = "cyclonedx"
+)
+
+// ComponentType is the kind of software component listed in a bill of materials
+type ComponentType string
+
+const (
+	Package         ComponentType = "package"
+	OperatingSystem ComponentType = "os-image"
+	Extension       ComponentType = "sysext"
+	HelmChart       ComponentType = "helm-chart"
+	ContainerImage  ComponentType = "container-image"
+)
+
+const toolName = "elemental"
+
+// Formats returns all the supported bill of materials formats
+func Formats() []Format {
+	return []Format{SPDX, CycloneDX}
+}
+
+// ParseFormats parses a comma separated list of bill of materials formats
+func ParseFormats(formats string) ([]Format, error) {
+	var parsed []Format
+	for value := range strings.SplitSeq(formats, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		f, err := ParseFormat(value)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, f)
+	}
+	return parsed, nil
+}
+
+// ParseFormat parses the given bill of materials format
+func ParseFormat(format string) (Format, error) {
+	for _, f := range Formats() {
+		if string(f) == format {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported SBOM format '%s': %w", format, errors.ErrUnsupported)
+}
+
+// OutputPath returns the path of the document in the given format for the given image
+func OutputPath(image string, format Format) string {
+	switch format {
+	case CycloneDX:
+		return image + ".cdx.json"
+	default:
+		return image + ".spdx.json"
+	}
+}
+
+// Component is a software component included in an image
+type Component struct {
+	Type    ComponentType
+	Name    string
+	Version string
+	// Arch is the architecture of packages, empty for other components
+	Arch string
+	// Digest is the content digest in '<algorithm>:<hex>' form, empty if unknown
+	Digest string
+	// Source is the registry, repository or URL the component comes from, empty if unknown
+	Source string
+}
+
+// Document is a software bill of materials of an image
+type Document struct {
+	// Name of the described image, usually the file name of the image
+	Name       string
+	Created    time.Time
+	Components []Component
+}
+
+// New returns an empty document for the given image name, see buildmeta.Now for its creation time
+func New(name string) (*Document, error) {
+	created, err := buildmeta.Now()
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Name: name, Created: created}, nil
+}
+
+// Add appends the given components to the document, components already listed are skipped
+func (d *Document) Add(components ...Component) {
+	d.Components = buildmeta.AppendUnique(d.Components, func(c Component) Component { return c }, components...)
+}
+
+// Write writes the document for the given image in each of the given formats, next to the image
+func Write(s *sys.System, d *Document, image string, formats ...Format) error {
+	for _, f := range formats {
+		var data []byte
+		var err error
+
+		switch f {
+		case SPDX:
+			data, err = d.spdx()
+		case CycloneDX:
+			data, err = d.cycloneDX()
+		default:
+			err = fmt.Errorf("unsupported SBOM format '%s': %w", f, errors.ErrUnsupported)
+		}
+		if err != nil {
+			return fmt.Errorf("rendering %s SBOM: %w", f, err)
+		}
+
+		path := OutputPath(image, f)
+		err = s.FS().WriteFile(path, data, vfs.FilePerm)
+		if err != nil {
+			return fmt.Errorf("writing SBOM file '%s': %w", path, err)
+		}
+		s.Logger().Info("SBOM written to %s", path)
+	}
+	return nil
+}
+
+// ImageComponent returns a component for the given container image reference. The version
+// is the reference tag and the digest is set if the reference is pinned to one.
+func ImageComponent(cType ComponentType, ref string) Component {
+	c := Component{Type: cType, Name: ref}
+
+	if name, digest, ok := strings.Cut(ref, "@"); ok {
+		c.Name, c.Digest = name, digest
+	}
+	if i := strings.LastIndex(c.Name, ":"); i > strings.LastIndex(c.Name, "/") {
+		c.Name, c.Version = c.Name[:i], c.Name[i+1:]
+	}
+	if i := strings.Index(c.Name, "/"); i > 0 && strings.ContainsAny(c.Name[:i], ".:") {
+		c.Source = c.Name[:i]
+	}
+	return c
+}
+
+// id returns an identifier derived from the document content
+func (d Document) id() uuid.UUID {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d\n", d.Name, d.Created.Unix())
+	for _, c := range d.Components {
+		fmt.Fprintf(&b, "%s %s %s %s %s %s\n", c.Type, c.Name, c.Version, c.Arch, c.Digest, c.Source)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(b.String()))
+}
+
+// purl returns the package URL of the component, empty if the component can't be identified by one
+func (c Component) purl() string {
+	switch c.Type {
+	case Package:
+		qualifiers := []string{}
+		version := c.Version
+		if epoch, v, ok := strings.Cut(c.Version, ":"); ok {
+			version = v
+			qualifiers = append(qualifiers, "epoch="+epoch)
+		}
+		if c.Arch != "" {
+			qualifiers = append(qualifiers, "arch="+c.Arch)
+		}
+		purl := fmt.Sprintf("pkg:rpm/%s@%s", c.Name, version)
+		if len(qualifiers) > 0 {
+			purl += "?" + strings.Join(qualifiers, "&")
+		}
+		return purl
+	case OperatingSystem, ContainerImage:
+		if c.Digest == "" {
+			return ""
+		}
+		name := c.Name[strings.LastIndex(c.Name, "/")+1:]
+		purl := fmt.Sprintf("pkg:oci/%s@%s?repository_url=%s", name, strings.Replace(c.Digest, ":", "%3A", 1), c.Name)
+		if c.Version != "" {
+			purl += "&tag=" + c.Version
+		}
+		return purl
+	default:
+		return ""
+	}
+}
+
+// digest splits the component digest into the algorithm and the value
+func (c Component) digest() (algorithm, value string, ok bool) {
+	return strings.Cut(c.Digest, ":")
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sbom"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const rpmOutput = "bash\t5.2.37-1.1\tx86_64\tSUSE LLC\n" +
+	"gpg-pubkey\t29b700a4-62b07e22\t(none)\t(none)\n" +
+	"aaa_base\t84.87-1.1\tx86_64\tSUSE LLC\n" +
+	"shim\t1:15.8-1.1\tx86_64\t(none)\n"
+
+func TestSBOMSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SBOM test suite")
+}
+
+var _ = Describe("SBOM", Label("sbom"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var runner *sysmock.Runner
+	var cleanup func()
+	var err error
+	BeforeEach(func() {
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/build/image.raw": "raw disk",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("parses SBOM formats", func() {
+		Expect(sbom.ParseFormats("spdx, cyclonedx")).To(Equal([]sbom.Format{sbom.SPDX, sbom.CycloneDX}))
+		Expect(sbom.ParseFormats("")).To(BeEmpty())
+		_, err := sbom.ParseFormats("spdx,swid")
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+	})
+	It("parses container image references", func() {
+		Expect(sbom.ImageComponent(sbom.ContainerImage, "registry.com/foo/bar:1.0@sha256:abcd")).To(Equal(sbom.Component{
+			Type: sbom.ContainerImage, Name: "registry.com/foo/bar", Version: "1.0",
+			Digest: "sha256:abcd", Source: "registry.com",
+		}))
+		Expect(sbom.ImageComponent(sbom.ContainerImage, "localhost:5000/bar")).To(Equal(sbom.Component{
+			Type: sbom.ContainerImage, Name: "localhost:5000/bar", Source: "localhost:5000",
+		}))
+	})
+	It("reads the packages of an OS root from the rpm database", func() {
+		runner.ReturnValue = []byte(rpmOutput)
+
+		packages, err := sbom.ReadPackages(context.Background(), s, "/os/root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(packages).To(Equal([]sbom.Component{
+			{Type: sbom.Package, Name: "aaa_base", Version: "84.87-1.1", Arch: "x86_64", Source: "SUSE LLC"},
+			{Type: sbom.Package, Name: "bash", Version: "5.2.37-1.1", Arch: "x86_64", Source: "SUSE LLC"},
+			{Type: sbom.Package, Name: "shim", Version: "1:15.8-1.1", Arch: "x86_64"},
+		}))
+		Expect(runner.CmdsMatch([][]string{{"rpm", "--root", "/os/root", "--dbpath", "/usr/lib/sysimage/rpm", "--query", "--all"}})).To(Succeed())
+	})
+	It("fails to read the packages of a squashfs image without rpm database", func() {
+		_, err := sbom.ReadImagePackages(context.Background(), s, "/build/squashfs.img", "/build")
+		Expect(err).To(MatchError(ContainSubstring("no rpm database found")))
+		Expect(runner.CmdsMatch([][]string{{
+			"unsquashfs", "-no-xattrs", "-force", "-dest", "/build/sbom-root", "/build/squashfs.img", "usr/lib/sysimage/rpm",
+		}})).To(Succeed())
+	})
+	It("reads the packages of a mounted EROFS image", func() {
+		mounter := sysmock.NewMounter()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithMounter(mounter),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		erofs := make([]byte, 2048)
+		copy(erofs[1024:], []byte{0xE2, 0xE1, 0xF5, 0xE0})
+		Expect(tfs.WriteFile("/build/erofs.img", erofs, vfs.FilePerm)).To(Succeed())
+		Expect(vfs.MkdirAll(tfs, "/build/sbom-root/usr/lib/sysimage/rpm", vfs.DirPerm)).To(Succeed())
+		runner.ReturnValue = []byte(rpmOutput)
+
+		packages, err := sbom.ReadImagePackages(context.Background(), s, "/build/erofs.img", "/build")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(packages).To(HaveLen(3))
+		Expect(runner.CmdsMatch([][]string{{"rpm", "--root", "/build/sbom-root", "--dbpath", "/usr/lib/sysimage/rpm", "--query", "--all"}})).To(Succeed())
+		Expect(mounter.IsMountPoint("/build/sbom-root")).To(BeFalse())
+		Expect(vfs.Exists(tfs, "/build/sbom-root")).To(BeFalse())
+	})
+	It("lists the components referenced by release manifests", func() {
+		rm := &resolver.ResolvedManifest{CorePlatform: &core.ReleaseManifest{
+			Components: core.Components{
+				Systemd: api.Systemd{Extensions: []api.SystemdExtension{
+					{Name: "rke2", Image: "https://example.com/rke2-1.32_0.0.raw"},
+				}},
+				Helm: &api.Helm{
+					Charts: []*api.HelmChart{{
+						Chart: "foo", Version: "0.0.0", Repository: "foo-charts",
+						Images: []api.HelmChartImage{{Name: "foo", Image: "registry.com/foo/foo:0.0.0"}},
+					}},
+					Repositories: []*api.HelmRepository{{Name: "foo-charts", URL: "https://foo.github.io/charts"}},
+				},
+			},
+		}}
+
+		Expect(sbom.ManifestComponents(rm)).To(Equal([]sbom.Component{
+			{Type: sbom.Extension, Name: "rke2", Source: "https://example.com/rke2-1.32_0.0.raw"},
+			{Type: sbom.HelmChart, Name: "foo", Version: "0.0.0", Source: "https://foo.github.io/charts"},
+			{Type: sbom.ContainerImage, Name: "registry.com/foo/foo", Version: "0.0.0", Source: "registry.com"},
+		}))
+	})
+	It("writes SPDX and CycloneDX documents", func() {
+		doc := &sbom.Document{Name: "image.raw", Created: time.Date(2025, 11, 14, 22, 13, 20, 0, time.UTC)}
+		doc.Add(
+			sbom.ImageComponent(sbom.OperatingSystem, "registry.com/os/base:6.2@sha256:abcd"),
+			sbom.Component{Type: sbom.Package, Name: "shim", Version: "1:15.8-1.1", Arch: "x86_64"},
+		)
+		doc.Add(sbom.Component{Type: sbom.Package, Name: "shim", Version: "1:15.8-1.1", Arch: "x86_64"})
+		Expect(doc.Components).To(HaveLen(2))
+
+		Expect(sbom.Write(s, doc, "/build/image.raw", sbom.SPDX, sbom.CycloneDX)).To(Succeed())
+
+		data, err := tfs.ReadFile("/build/image.raw.spdx.json")
+		Expect(err).NotTo(HaveOccurred())
+		spdx := map[string]any{}
+		Expect(json.Unmarshal(data, &spdx)).To(Succeed())
+		Expect(spdx["spdxVersion"]).To(Equal("SPDX-2.3"))
+		Expect(spdx["creationInfo"]).To(HaveKeyWithValue("created", "2025-11-14T22:13:20Z"))
+		Expect(spdx["packages"]).To(HaveLen(3))
+		Expect(spdx["relationships"]).To(HaveLen(3))
+		Expect(string(data)).To(ContainSubstring(`"referenceLocator": "pkg:rpm/shim@15.8-1.1?epoch=1&arch=x86_64"`))
+		Expect(string(data)).To(ContainSubstring(`"checksumValue": "abcd"`))
+
+		data, err = tfs.ReadFile("/build/image.raw.cdx.json")
+		Expect(err).NotTo(HaveOccurred())
+		cdx := map[string]any{}
+		Expect(json.Unmarshal(data, &cdx)).To(Succeed())
+		Expect(cdx["bomFormat"]).To(Equal("CycloneDX"))
+		Expect(cdx["components"]).To(HaveLen(2))
+		Expect(string(data)).To(ContainSubstring(`"purl": "pkg:oci/base@sha256%3Aabcd?repository_url=registry.com/os/base&tag=6.2"`))
+		Expect(string(data)).To(ContainSubstring(`"alg": "SHA-256"`))
+
+		// documents are reproducible
+		spdxData, _ := tfs.ReadFile("/build/image.raw.spdx.json")
+		Expect(sbom.Write(s, doc, "/build/image.raw", sbom.SPDX)).To(Succeed())
+		Expect(tfs.ReadFile("/build/image.raw.spdx.json")).To(Equal(spdxData))
+	})
+})
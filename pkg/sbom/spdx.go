@@ -0,0 +1,149 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/buildmeta"
+)
+
+// SPDX 2.3 JSON document, see https://spdx.github.io/spdx-spec/v2.3/
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const (
+	spdxNoAssertion = "NOASSERTION"
+	spdxImageID     = "SPDXRef-Image"
+)
+
+var spdxInvalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// spdx renders the document in SPDX 2.3 JSON format. The described image is the root package
+// which contains all the components.
+func (d Document) spdx() ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              d.Name,
+		DocumentNamespace: fmt.Sprintf("https://elemental.suse.com/spdxdocs/%s-%s", d.Name, d.id()),
+		CreationInfo: spdxCreationInfo{
+			Created:  d.Created.Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+		Packages: []spdxPackage{{
+			Name:                  d.Name,
+			SPDXID:                spdxImageID,
+			DownloadLocation:      spdxNoAssertion,
+			PrimaryPackagePurpose: "OPERATING-SYSTEM",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxImageID,
+		}},
+	}
+
+	for i, c := range d.Components {
+		id := fmt.Sprintf("SPDXRef-%s-%d-%s", c.Type, i, spdxInvalidIDChars.ReplaceAllString(c.Name, "-"))
+		pkg := spdxPackage{
+			Name:                  c.Name,
+			SPDXID:                id,
+			VersionInfo:           c.Version,
+			DownloadLocation:      spdxNoAssertion,
+			PrimaryPackagePurpose: spdxPurpose(c.Type),
+			Comment:               fmt.Sprintf("Component type: %s", c.Type),
+		}
+		if c.Source != "" {
+			pkg.DownloadLocation = c.Source
+		}
+		if alg, value, ok := c.digest(); ok {
+			pkg.Checksums = []spdxChecksum{{Algorithm: strings.ToUpper(alg), ChecksumValue: value}}
+		}
+		if purl := c.purl(); purl != "" {
+			pkg.ExternalRefs = []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl,
+			}}
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID: spdxImageID, RelationshipType: "CONTAINS", RelatedSPDXElement: id,
+		})
+	}
+
+	return buildmeta.MarshalJSON(doc)
+}
+
+func spdxPurpose(cType ComponentType) string {
+	switch cType {
+	case OperatingSystem:
+		return "OPERATING-SYSTEM"
+	case ContainerImage:
+		return "CONTAINER"
+	case Package:
+		return "LIBRARY"
+	default:
+		return "APPLICATION"
+	}
+}
@@ -21,8 +21,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/suse/elemental/v3/pkg/btrfs"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -38,6 +40,19 @@ const (
 	snapperSysconfig     = "/etc/sysconfig/snapper"
 	snapperRootConfig    = "/etc/snapper/configs/" + rootConfig
 	rootConfig           = "root"
+
+	// PinnedKey is the userdata key flagging snapshots that are never cleaned up
+	PinnedKey = "pinned"
+	// PinnedCleanupKey is the userdata key storing the cleanup algorithm of a snapshot before pinning it
+	PinnedCleanupKey = "pinned-cleanup"
+	// VersionKey is the userdata key storing the OS version of a snapshot
+	VersionKey = "version"
+
+	dateLayout = "2006-01-02 15:04:05"
+	// cleanupAlgorithm is restored on snapshots pinned without storing their cleanup algorithm
+	cleanupAlgorithm = "number"
+	// noCleanup is stored as the previous cleanup algorithm of pinned snapshots which had none
+	noCleanup = "none"
 )
 
 type Snapper struct {
@@ -45,10 +60,31 @@ type Snapper struct {
 }
 
 type Snapshot struct {
-	Number   int      `json:"number"`
-	Default  bool     `json:"default"`
-	Active   bool     `json:"active"`
-	UserData Metadata `json:"userdata,omitempty"`
+	Number    int      `json:"number"`
+	Default   bool     `json:"default"`
+	Active    bool     `json:"active"`
+	Date      string   `json:"date,omitempty"`
+	UsedSpace uint64   `json:"used-space,omitempty"`
+	Cleanup   string   `json:"cleanup,omitempty"`
+	UserData  Metadata `json:"userdata,omitempty"`
+}
+
+// Retention defines the policy to clean up the snapshots of a snapper configuration.
+// Active, default and pinned snapshots are never removed.
+type Retention struct {
+	// MaxSnapshots is the maximum number of snapshots to keep, zero means no limit
+	MaxSnapshots int
+	// MaxAge is the age beyond which snapshots are removed, zero means no limit. If combined
+	// with MaxSnapshots snapshots are only removed if both limits are exceeded.
+	MaxAge time.Duration
+	// KeepPerVersion keeps the latest snapshot of each OS version
+	KeepPerVersion bool
+	// MaxUsage is the maximum space in bytes used by all snapshots, zero means no limit.
+	// Oldest snapshots are removed until the usage is below this limit regardless of
+	// MaxSnapshots and MaxAge.
+	MaxUsage uint64
+	// Keep protects snapshots including any of the given userdata key-value pairs
+	Keep Metadata
 }
 
 type Metadata map[string]string
@@ -83,6 +119,57 @@ func (s Snapshots) GetWithUserdata(key, value string) []int {
 	return ids
 }
 
+// CreatedAt returns the creation time of the snapshot
+func (s Snapshot) CreatedAt() (time.Time, error) {
+	return time.ParseInLocation(dateLayout, s.Date, time.Local)
+}
+
+// IsPinned checks whether the snapshot is pinned
+func (s Snapshot) IsPinned() bool {
+	return s.UserData != nil && s.UserData[PinnedKey] == "yes"
+}
+
+// protected returns the snapshots that can't be removed by the given retention policy
+func (r Retention) protected(snaps Snapshots) map[int]bool {
+	protected := map[int]bool{}
+	latest := map[string]int{}
+	for _, snap := range snaps {
+		if snap.Active || snap.Default || snap.IsPinned() {
+			protected[snap.Number] = true
+		}
+		for k, v := range r.Keep {
+			if snap.UserData != nil && snap.UserData[k] == v {
+				protected[snap.Number] = true
+			}
+		}
+		if version := snap.UserData[VersionKey]; r.KeepPerVersion && version != "" {
+			latest[version] = max(latest[version], snap.Number)
+		}
+	}
+	for _, id := range latest {
+		protected[id] = true
+	}
+	return protected
+}
+
+// expired checks if the given snapshot exceeds the count or age limits of the retention policy.
+// count is the current number of snapshots.
+func (r Retention) expired(snap *Snapshot, count int, now time.Time) bool {
+	if r.MaxSnapshots <= 0 && r.MaxAge <= 0 {
+		return false
+	}
+	if r.MaxSnapshots > 0 && count <= r.MaxSnapshots {
+		return false
+	}
+	if r.MaxAge > 0 {
+		created, err := snap.CreatedAt()
+		if err != nil || now.Sub(created) <= r.MaxAge {
+			return false
+		}
+	}
+	return true
+}
+
 func (m Metadata) String() string {
 	var str string
 	for k, v := range m {
@@ -125,7 +212,7 @@ func (sn Snapper) ListSnapshots(root string, config string) (Snapshots, error) {
 	if config == "" {
 		config = root
 	}
-	args = append(args, "-c", config, "--jsonout", "list", "--columns", "number,default,active,userdata")
+	args = append(args, "-c", config, "--jsonout", "list", "--columns", "number,default,active,date,used-space,cleanup,userdata")
 	cmdOut, err := sn.s.Runner().Run("snapper", args...)
 	if err != nil {
 		return nil, fmt.Errorf("collecting snapshots: %s: %w", string(cmdOut), err)
@@ -239,25 +326,88 @@ func (sn Snapper) SetDefault(root string, id int, metadata Metadata) error {
 	return err
 }
 
-func (sn Snapper) Cleanup(root string, maxSnaps int) error {
+// Pin sets or unsets the pinned flag of the given snapshot of the snapper configuration of
+// volumePath. Pinned snapshots are excluded from any cleanup, including the snapper ones. The
+// cleanup algorithm of the snapshot is stored on pinning and restored on unpinning.
+func (sn Snapper) Pin(root, volumePath string, id int, pin bool) error {
+	snaps, err := sn.ListSnapshots(root, ConfigName(volumePath))
+	if err != nil {
+		return fmt.Errorf("listing snapshots: %w", err)
+	}
+	idx := slices.IndexFunc(snaps, func(snap *Snapshot) bool { return snap.Number == id })
+	if idx < 0 {
+		return fmt.Errorf("snapshot '%d' not found", id)
+	}
+	snap := snaps[idx]
+	if snap.IsPinned() == pin {
+		return nil
+	}
+
+	args := []string{"--no-dbus"}
+
+	if root != "" && root != "/" {
+		args = append(args, "--root", root)
+	}
+	args = append(args, "-c", ConfigName(volumePath), "modify")
+	if pin {
+		algorithm := snap.Cleanup
+		if algorithm == "" {
+			algorithm = noCleanup
+		}
+		userdata := fmt.Sprintf("%s=yes,%s=%s", PinnedKey, PinnedCleanupKey, algorithm)
+		args = append(args, "--userdata", userdata, "--cleanup-algorithm", "")
+	} else {
+		algorithm, ok := snap.UserData[PinnedCleanupKey]
+		switch {
+		case !ok:
+			algorithm = cleanupAlgorithm
+		case algorithm == noCleanup:
+			algorithm = ""
+		}
+		userdata := fmt.Sprintf("%s=,%s=", PinnedKey, PinnedCleanupKey)
+		args = append(args, "--userdata", userdata, "--cleanup-algorithm", algorithm)
+	}
+	args = append(args, strconv.Itoa(id))
+	cmdOut, err := sn.s.Runner().Run("snapper", args...)
+	if err != nil {
+		return fmt.Errorf("modifying snapshot '%d': %s: %w", id, strings.TrimSpace(string(cmdOut)), err)
+	}
+	return nil
+}
+
+// Cleanup removes the snapshots of the snapper configuration of volumePath according to the given
+// retention policy. Oldest snapshots are removed first.
+func (sn Snapper) Cleanup(root, volumePath string, policy Retention) error {
 	// TODO instead of relying on manual cleanup we could provide a snapper plugin
 	// to handle cleanup and rely on 'snapper cleanup' command
-	snaps, err := sn.ListSnapshots(root, rootConfig)
+	snaps, err := sn.ListSnapshots(root, ConfigName(volumePath))
 	if err != nil {
 		return fmt.Errorf("listing snapshots: %w", err)
 	}
-	deletes := len(snaps) - maxSnaps
-	i := 0
-	for deletes > 0 {
-		if !snaps[i].Active && !snaps[i].Default {
-			path := filepath.Join(root, SnapshotsPath, strconv.Itoa(snaps[i].Number), "snapshot")
-			err = sn.DeleteByPath(path)
-			if err != nil {
-				return fmt.Errorf("cleaning up snapshot '%s': %w", path, err)
-			}
-			deletes--
+
+	var usage uint64
+	for _, snap := range snaps {
+		usage += snap.UsedSpace
+	}
+
+	protected := policy.protected(snaps)
+	count := len(snaps)
+	now := time.Now()
+	for _, snap := range snaps {
+		if protected[snap.Number] {
+			continue
+		}
+		overUsage := policy.MaxUsage > 0 && usage > policy.MaxUsage
+		if !overUsage && !policy.expired(snap, count, now) {
+			continue
 		}
-		i++
+		path := filepath.Join(root, volumePath, SnapshotsPath, strconv.Itoa(snap.Number), "snapshot")
+		err = sn.DeleteByPath(path)
+		if err != nil {
+			return fmt.Errorf("cleaning up snapshot '%s': %w", path, err)
+		}
+		count--
+		usage -= snap.UsedSpace
 	}
 	return nil
 }
@@ -311,8 +461,10 @@ func (sn Snapper) ConfigureRoot(snapshotPath string, maxSnapshots int) error {
 
 	snapCfg["TIMELINE_CREATE"] = "no"
 	snapCfg["QGROUP"] = "1/0"
-	snapCfg["NUMBER_LIMIT"] = fmt.Sprintf("%d-%d", maxSnapshots/4, maxSnapshots)
-	snapCfg["NUMBER_LIMIT_IMPORTANT"] = fmt.Sprintf("%d-%d", maxSnapshots/2, maxSnapshots)
+	if maxSnapshots > 0 {
+		snapCfg["NUMBER_LIMIT"] = fmt.Sprintf("%d-%d", maxSnapshots/4, maxSnapshots)
+		snapCfg["NUMBER_LIMIT_IMPORTANT"] = fmt.Sprintf("%d-%d", maxSnapshots/2, maxSnapshots)
+	}
 
 	rootCfg := filepath.Join(snapshotPath, snapperRootConfig)
 	sn.s.Logger().Debug("Creating 'root' snapper configuration at '%s'", rootCfg)
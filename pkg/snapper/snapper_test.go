@@ -22,7 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 	. "github.com/onsi/ginkgo/v2"
@@ -81,6 +83,27 @@ const snapperList = `{
   ]
 }`
 
+const retentionList = `{
+  "etc": [
+    {"number": 1, "default": false, "active": false, "date": "2020-01-01 10:00:00", "used-space": 4096, "userdata": {"stock": "true"}},
+    {"number": 2, "default": false, "active": false, "date": "2020-01-02 10:00:00", "used-space": 4096, "userdata": {"version": "6.0"}},
+    {"number": 3, "default": false, "active": false, "date": "2020-01-03 10:00:00", "used-space": 4096, "userdata": {"pinned": "yes"}},
+    {"number": 4, "default": false, "active": false, "date": "2020-01-04 10:00:00", "used-space": 4096, "userdata": {"version": "6.1"}},
+    {"number": 5, "default": false, "active": false, "date": "2020-01-05 10:00:00", "used-space": 4096, "userdata": {"version": "6.1"}},
+    {"number": 6, "default": true, "active": true, "date": "2099-01-01 10:00:00", "used-space": 4096, "userdata": null}
+  ]
+}`
+
+const pinList = `{
+  "root": [
+    {"number": 3, "default": false, "active": false, "cleanup": "timeline", "userdata": null},
+    {"number": 4, "default": false, "active": false, "userdata": null},
+    {"number": 5, "default": false, "active": false, "userdata": {"pinned": "yes", "pinned-cleanup": "timeline"}},
+    {"number": 6, "default": false, "active": false, "userdata": {"pinned": "yes", "pinned-cleanup": "none"}},
+    {"number": 7, "default": true, "active": true, "userdata": {"pinned": "yes"}}
+  ]
+}`
+
 var _ = Describe("Snapper", Label("snapper"), func() {
 	var runner *sysmock.Runner
 	var mounter *sysmock.Mounter
@@ -270,21 +293,21 @@ var _ = Describe("Snapper", Label("snapper"), func() {
 			runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
 				return []byte(snapperList), nil
 			}
-			Expect(snap.Cleanup("/some/root", 4)).To(Succeed())
+			Expect(snap.Cleanup("/some/root", "/", snapper.Retention{MaxSnapshots: 4})).To(Succeed())
 			Expect(runner.CmdsMatch([][]string{{
 				"snapper", "--no-dbus", "--root", "/some/root", "-c", "root",
-				"--jsonout", "list", "--columns", "number,default,active,userdata",
+				"--jsonout", "list", "--columns", "number,default,active,date,used-space,cleanup,userdata",
 			}})).To(Succeed())
 		})
 		It("clears old snapshots until snapshots count is not higher than maximum", func() {
 			runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
 				return []byte(snapperList), nil
 			}
-			Expect(snap.Cleanup("/some/root", 2)).To(Succeed())
+			Expect(snap.Cleanup("/some/root", "/", snapper.Retention{MaxSnapshots: 2})).To(Succeed())
 			Expect(runner.CmdsMatch([][]string{
 				{
 					"snapper", "--no-dbus", "--root", "/some/root", "-c", "root",
-					"--jsonout", "list", "--columns", "number,default,active,userdata",
+					"--jsonout", "list", "--columns", "number,default,active,date,used-space,cleanup,userdata",
 				}, {"btrfs", "property"}, {"btrfs", "subvolume"}, {"btrfs", "property"}, {"btrfs", "subvolume"},
 			})).To(Succeed())
 		})
@@ -292,12 +315,12 @@ var _ = Describe("Snapper", Label("snapper"), func() {
 			runner.SideEffect = func(_ string, _ ...string) ([]byte, error) {
 				return []byte("<list-output>"), fmt.Errorf("listing failed")
 			}
-			err := snap.Cleanup("/some/root", 4)
+			err := snap.Cleanup("/some/root", "/", snapper.Retention{MaxSnapshots: 4})
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("listing snapshots: collecting snapshots: <list-output>: listing failed"))
 			Expect(runner.CmdsMatch([][]string{{
 				"snapper", "--no-dbus", "--root", "/some/root", "-c", "root",
-				"--jsonout", "list", "--columns", "number,default,active,userdata",
+				"--jsonout", "list", "--columns", "number,default,active,date,used-space,cleanup,userdata",
 			}})).To(Succeed())
 		})
 		It("fails to delete specific snapshot", func() {
@@ -309,20 +332,123 @@ var _ = Describe("Snapper", Label("snapper"), func() {
 				}
 				return []byte(snapperList), nil
 			}
-			err := snap.Cleanup("/some/root", 2)
+			err := snap.Cleanup("/some/root", "/", snapper.Retention{MaxSnapshots: 2})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cleaning up snapshot"))
 			Expect(err.Error()).To(ContainSubstring("deleting subvolume: delete failed"))
 			Expect(runner.CmdsMatch([][]string{
 				{
 					"snapper", "--no-dbus", "--root", "/some/root", "-c", "root",
-					"--jsonout", "list", "--columns", "number,default,active,userdata",
+					"--jsonout", "list", "--columns", "number,default,active,date,used-space,cleanup,userdata",
 				},
 				{"btrfs", "property"},
 				{"btrfs", "subvolume", "delete"},
 			})).To(Succeed())
 		})
 	})
+	Describe("Cleanup with retention policy", func() {
+		var paths []string
+		deleted := func() []string { return paths }
+		BeforeEach(func() {
+			paths = []string{}
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "btrfs" && args[0] == "subvolume" && args[1] == "delete" {
+					paths = append(paths, args[len(args)-1])
+				}
+				return []byte(retentionList), nil
+			}
+		})
+		It("never removes pinned, active or default snapshots", func() {
+			Expect(snap.Cleanup("/some/root", "/etc", snapper.Retention{MaxSnapshots: 1})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{
+				"snapper", "--no-dbus", "--root", "/some/root", "-c", "etc", "--jsonout", "list",
+			}})).To(Succeed())
+			Expect(deleted()).To(Equal([]string{
+				"/some/root/etc/.snapshots/1/snapshot", "/some/root/etc/.snapshots/2/snapshot",
+				"/some/root/etc/.snapshots/4/snapshot", "/some/root/etc/.snapshots/5/snapshot",
+			}))
+		})
+		It("keeps snapshots matching the given userdata and the latest one of each OS version", func() {
+			policy := snapper.Retention{MaxSnapshots: 1, KeepPerVersion: true, Keep: snapper.Metadata{"stock": "true"}}
+			Expect(snap.Cleanup("/some/root", "/etc", policy)).To(Succeed())
+			Expect(deleted()).To(Equal([]string{"/some/root/etc/.snapshots/4/snapshot"}))
+		})
+		It("removes snapshots older than the maximum age", func() {
+			Expect(snap.Cleanup("/some/root", "/etc", snapper.Retention{MaxAge: 24 * time.Hour})).To(Succeed())
+			Expect(deleted()).To(HaveLen(4))
+		})
+		It("keeps old snapshots within the maximum number of snapshots", func() {
+			policy := snapper.Retention{MaxSnapshots: 4, MaxAge: 24 * time.Hour}
+			Expect(snap.Cleanup("/some/root", "/etc", policy)).To(Succeed())
+			Expect(deleted()).To(Equal([]string{
+				"/some/root/etc/.snapshots/1/snapshot", "/some/root/etc/.snapshots/2/snapshot",
+			}))
+		})
+		It("removes the oldest snapshots until usage is below the limit", func() {
+			Expect(snap.Cleanup("/some/root", "/etc", snapper.Retention{MaxUsage: 16384})).To(Succeed())
+			Expect(deleted()).To(Equal([]string{
+				"/some/root/etc/.snapshots/1/snapshot", "/some/root/etc/.snapshots/2/snapshot",
+			}))
+		})
+	})
+	Describe("Pin", func() {
+		BeforeEach(func() {
+			runner.SideEffect = func(_ string, args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") {
+					return []byte(pinList), nil
+				}
+				return nil, nil
+			}
+		})
+		It("pins a snapshot keeping its cleanup algorithm", func() {
+			Expect(snap.Pin("/", "/", 3, true)).To(Succeed())
+			Expect(snap.Pin("/", "/", 4, true)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{
+					"snapper", "--no-dbus", "-c", "root", "modify",
+					"--userdata", "pinned=yes,pinned-cleanup=timeline", "--cleanup-algorithm", "", "3",
+				}, {
+					"snapper", "--no-dbus", "-c", "root", "modify",
+					"--userdata", "pinned=yes,pinned-cleanup=none", "--cleanup-algorithm", "", "4",
+				},
+			})).To(Succeed())
+		})
+		It("unpins a snapshot restoring its cleanup algorithm", func() {
+			Expect(snap.Pin("/", "/", 5, false)).To(Succeed())
+			Expect(snap.Pin("/", "/", 6, false)).To(Succeed())
+			Expect(snap.Pin("/", "/", 7, false)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{
+					"snapper", "--no-dbus", "-c", "root", "modify",
+					"--userdata", "pinned=,pinned-cleanup=", "--cleanup-algorithm", "timeline", "5",
+				}, {
+					"snapper", "--no-dbus", "-c", "root", "modify",
+					"--userdata", "pinned=,pinned-cleanup=", "--cleanup-algorithm", "", "6",
+				}, {
+					"snapper", "--no-dbus", "-c", "root", "modify",
+					"--userdata", "pinned=,pinned-cleanup=", "--cleanup-algorithm", "number", "7",
+				},
+			})).To(Succeed())
+		})
+		It("does nothing if the snapshot is already in the requested state", func() {
+			Expect(snap.Pin("/", "/", 5, true)).To(Succeed())
+			Expect(snap.Pin("/", "/", 3, false)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"snapper", "--no-dbus", "-c", "root", "modify"}})).NotTo(Succeed())
+		})
+		It("fails to pin a missing snapshot", func() {
+			Expect(snap.Pin("/", "/", 9, true)).To(MatchError("snapshot '9' not found"))
+		})
+		It("fails to modify the snapshot", func() {
+			runner.SideEffect = func(_ string, args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") {
+					return []byte(pinList), nil
+				}
+				return []byte("Snapshot '3' is busy."), fmt.Errorf("snapper failed")
+			}
+			err := snap.Pin("/", "/", 3, true)
+			Expect(err).To(MatchError("modifying snapshot '3': Snapshot '3' is busy.: snapper failed"))
+		})
+	})
 	Describe("ConfigureRoot", func() {
 		It("creates a root configuration", func() {
 			rootDir := "/some/root"
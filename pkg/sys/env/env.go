@@ -17,6 +17,31 @@ limitations under the License.
 
 package env
 
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
 const (
 	CLocale = "LC_ALL=C"
+
+	// SourceDateEpoch is the environment variable pinning the timestamps of reproducible builds,
+	// see https://reproducible-builds.org/specs/source-date-epoch/
+	SourceDateEpoch = "SOURCE_DATE_EPOCH"
 )
+
+// LookupSourceDateEpoch returns the time set in the SOURCE_DATE_EPOCH environment variable. The
+// returned boolean is false if the variable is not set or empty.
+func LookupSourceDateEpoch() (time.Time, bool, error) {
+	value, ok := os.LookupEnv(SourceDateEpoch)
+	if !ok || value == "" {
+		return time.Time{}, false, nil
+	}
+	epoch, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || epoch < 0 {
+		return time.Time{}, false, fmt.Errorf("invalid %s value '%s': must be a non negative integer", SourceDateEpoch, value)
+	}
+	return time.Unix(epoch, 0).UTC(), true, nil
+}
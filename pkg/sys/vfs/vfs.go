@@ -449,6 +449,29 @@ func TempFile(fs FS, dir, pattern string) (f *os.File, err error) {
 	return
 }
 
+// ClampModTimes sets the access and modification times of all files and directories in the given
+// tree which are newer than the given time to that time. Symlinks are skipped as their times can't
+// be changed without following them.
+func ClampModTimes(fs FS, root string, t time.Time) error {
+	return WalkDirFs(fs, root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.Type()&os.ModeSymlink != 0 {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().After(t) {
+			return nil
+		}
+		rawPath, err := fs.RawPath(path)
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(rawPath, t, t)
+	})
+}
+
 // Walkdir with an FS implementation
 type statDirEntry struct {
 	info fs.FileInfo
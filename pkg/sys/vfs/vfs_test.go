@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -98,6 +99,23 @@ var _ = Describe("FS", Label("fs"), func() {
 			Expect(err).Should(HaveOccurred())
 		})
 	})
+	Describe("ClampModTimes", func() {
+		It("sets newer file times to the given time", func() {
+			t := time.Unix(1700000000, 0)
+			Expect(vfs.ClampModTimes(tfs, "/folder", t)).To(Succeed())
+			for _, path := range []string{"/folder", "/folder/file", "/folder/subfolder/file1"} {
+				info, err := tfs.Stat(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.ModTime().Equal(t)).To(BeTrue())
+			}
+
+			// older times are kept
+			Expect(vfs.ClampModTimes(tfs, "/folder", t.Add(time.Hour))).To(Succeed())
+			info, err := tfs.Stat("/folder/file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime().Equal(t)).To(BeTrue())
+		})
+	})
 	Describe("IsDir", func() {
 		It("discriminates directories and files", func() {
 			Expect(tfs.Symlink("subfolder", "/folder/linkToSubfolder")).To(Succeed())
@@ -0,0 +1,315 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+// Transaction IDs of each A/B slot, these are also used as the boot entry IDs
+const (
+	slotA = 1
+	slotB = 2
+)
+
+// ABPartitions transaction alternates the OS between the 'system' (A) and the 'system-b' (B)
+// partitions. Every transaction formats and writes the slot which is not currently booted and,
+// once committed, the bootloader defaults to it. The previous slot is kept untouched as a fallback
+// boot entry. Slots are fully rewritten on each transaction, hence any persistent data must be
+// kept in other partitions or logical volumes.
+type ABPartitions struct {
+	s           *sys.System
+	ctx         context.Context
+	d           *deployment.Deployment
+	cleanStack  *cleanstack.CleanStack
+	lsBlk       block.Device
+	activeID    int
+	committedID int
+	target      *deployment.Partition
+}
+
+func NewABPartitions(ctx context.Context, s *sys.System, d *deployment.Deployment, lsBlk block.Device) Interface {
+	return &ABPartitions{s: s, ctx: ctx, d: d, cleanStack: cleanstack.NewCleanStack(), lsBlk: lsBlk}
+}
+
+var _ Interface = (*ABPartitions)(nil)
+var _ UpgradeHelper = (*ABPartitions)(nil)
+var _ Recoverer = (*ABPartitions)(nil)
+
+// Init checks the deployment defines both A/B slots
+func (ab *ABPartitions) Init(deployment.Deployment) (UpgradeHelper, error) {
+	if ab.d.GetSystemPartition() == nil || ab.d.GetSystemBPartition() == nil {
+		return nil, fmt.Errorf("the 'ab' snapshotter requires a 'system' and a 'system-b' partition")
+	}
+	return ab, nil
+}
+
+// Start formats and mounts the slot which is not currently booted. If none of the
+// slots is booted (e.g. install time) slot A is used.
+func (ab *ABPartitions) Start() (*Transaction, error) {
+	hwParts, slots, err := ab.probe()
+	if err != nil {
+		return nil, err
+	}
+
+	targetID := slotA
+	if ab.activeID == slotA {
+		targetID = slotB
+	}
+	ab.target = slots[targetID]
+	ab.s.Logger().Info("Starting an A/B transaction on partition '%s'", ab.target.Label)
+
+	dev := hwParts.GetByUUIDNameOrLabel(ab.target.UUID, ab.target.Role.String(), ab.target.Label)
+	if dev == nil {
+		return nil, fmt.Errorf("partition not found: %+v", ab.target)
+	}
+
+	err = filesystem.NewMkfsCall(ab.s, dev.Path, ab.target.FileSystem.String(), ab.target.Label, "").Apply()
+	if err != nil {
+		return nil, fmt.Errorf("formatting partition '%s': %w", ab.target.Label, err)
+	}
+
+	temp, err := vfs.TempDir(ab.s.FS(), "", "elemental_ab")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating temp-dir: %w", err)
+	}
+	ab.cleanStack.PushSuccessOnly(func() error { return ab.s.FS().RemoveAll(temp) })
+
+	err = ab.mount(dev.Path, temp)
+	if err != nil {
+		return nil, ab.cleanStack.Cleanup(err)
+	}
+
+	for _, disk := range ab.d.Disks {
+		for _, p := range disk.Partitions {
+			if p.Role == deployment.LVM {
+				err = mountLogicalVolumes(ab.s, ab.cleanStack, p.VolumeGroup, temp)
+				if err != nil {
+					return nil, ab.cleanStack.Cleanup(err)
+				}
+				continue
+			}
+			if p.Role == deployment.System || p.MountPoint == "" {
+				continue
+			}
+			part := hwParts.GetByUUIDNameOrLabel(p.UUID, p.Role.String(), p.Label)
+			if part == nil {
+				return nil, ab.cleanStack.Cleanup(fmt.Errorf("partition not found: %+v", p))
+			}
+			err = ab.mount(part.Path, filepath.Join(temp, p.MountPoint))
+			if err != nil {
+				return nil, ab.cleanStack.Cleanup(err)
+			}
+		}
+	}
+
+	return &Transaction{ID: targetID, Path: temp, Merges: map[string]*Merge{}, status: started}, nil
+}
+
+// probe lists the host partitions and finds the currently booted slot, if any
+func (ab *ABPartitions) probe() (block.PartitionList, map[int]*deployment.Partition, error) {
+	hwParts, err := ab.lsBlk.GetAllPartitions()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed listing partitions: %w", err)
+	}
+
+	slots := map[int]*deployment.Partition{
+		slotA: ab.d.GetSystemPartition(),
+		slotB: ab.d.GetSystemBPartition(),
+	}
+
+	ab.activeID = 0
+	for id, slot := range slots {
+		part := hwParts.GetByUUIDNameOrLabel(slot.UUID, slot.Role.String(), slot.Label)
+		if part != nil && slices.Contains(part.MountPoints, deployment.SystemMnt) {
+			ab.activeID = id
+		}
+	}
+	return hwParts, slots, nil
+}
+
+// mount mounts the given device at the given path. In addition it also sets the umount cleanup task.
+func (ab *ABPartitions) mount(device, target string) error {
+	ab.s.Logger().Debug("Mounting '%s' to '%s'", device, target)
+
+	if err := vfs.MkdirAll(ab.s.FS(), target, vfs.DirPerm); err != nil {
+		return fmt.Errorf("failed creating mountpoint %s: %w", target, err)
+	}
+
+	err := ab.s.Mounter().Mount(device, target, "", []string{"rw"})
+	if err != nil {
+		return fmt.Errorf("failed mounting '%s': %w", device, err)
+	}
+
+	ab.cleanStack.Push(func() error {
+		ab.s.Logger().Debug("Unmounting '%s'", target)
+		return ab.s.Mounter().Unmount(target)
+	})
+	return nil
+}
+
+// Commit closes the given transaction, from now on the bootloader defaults to the new slot.
+func (ab *ABPartitions) Commit(trans *Transaction, cleanup func() error) error {
+	if trans.status != started {
+		return fmt.Errorf("transaction '%d' is not started", trans.ID)
+	}
+	trans.status = committed
+	ab.committedID = trans.ID
+	if cleanup != nil {
+		ab.cleanStack.Push(cleanup)
+	}
+	return ab.cleanStack.Cleanup(nil)
+}
+
+// Rollback closes the given in progress transaction. The previously booted slot
+// is not modified by any transaction, hence there is nothing to restore.
+func (ab *ABPartitions) Rollback(trans *Transaction, e error) error {
+	if trans.status == committed {
+		ab.s.Logger().Warn("cannot rollback a committed transaction")
+		return e
+	}
+	ab.s.Logger().Error("Closing transaction due to a failure: %v", e)
+	trans.status = failed
+	return ab.cleanStack.Cleanup(e)
+}
+
+// Recover reports whether the slot of an interrupted transaction is the booted one, hence already
+// committed. Otherwise nothing is deleted, the slot is not booted by default and the next transaction
+// formats it again.
+func (ab *ABPartitions) Recover(trans *Transaction, _ bool) (bool, error) {
+	_, _, err := ab.probe()
+	if err != nil {
+		return false, err
+	}
+	return trans.ID == ab.activeID, nil
+}
+
+// GetActiveSnapshotIDs returns the IDs of the slots holding a committed system, that is the booted
+// slot and the slot of a transaction committed by this process. A slot rewritten by a transaction
+// which was not committed is not included, so its boot entry is pruned.
+func (ab *ABPartitions) GetActiveSnapshotIDs() ([]int, error) {
+	_, _, err := ab.probe()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{}
+	for _, id := range []int{slotA, slotB} {
+		if id == ab.activeID || id == ab.committedID {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// SyncImageContent mirrors the image source to the new slot. Data partitions are
+// excluded on upgrades and never deleted.
+func (ab *ABPartitions) SyncImageContent(imgSrc *deployment.ImageSource, trans *Transaction, opts ...unpack.Opt) error {
+	if trans.status != started {
+		return fmt.Errorf("given transaction '%d' is not started", trans.ID)
+	}
+
+	var dataPaths []string
+	for _, disk := range ab.d.Disks {
+		for _, p := range disk.Partitions {
+			if p.Role != deployment.System && p.MountPoint != "" {
+				dataPaths = append(dataPaths, p.MountPoint)
+			}
+		}
+		dataPaths = append(dataPaths, logicalVolumeMountPoints(disk.Partitions)...)
+	}
+
+	var excludes []string
+	if ab.activeID != 0 {
+		excludes = dataPaths
+	}
+
+	ab.s.Logger().Info("Unpacking image source: %s to %s", imgSrc.String(), trans.Path)
+	unpacker, err := unpack.NewUnpacker(ab.s, imgSrc, opts...)
+	if err != nil {
+		return fmt.Errorf("initializing unpacker: %w", err)
+	}
+	digest, err := unpacker.SynchedUnpack(ab.ctx, trans.Path, excludes, dataPaths)
+	if err != nil {
+		return fmt.Errorf("unpacking image to '%s': %w", trans.Path, err)
+	}
+	imgSrc.SetDigest(digest)
+
+	return nil
+}
+
+// Merge does nothing, slots do not include RW volumes to merge
+func (ab *ABPartitions) Merge(*Transaction) error {
+	return nil
+}
+
+// UpdateFstab writes the fstab of the new slot, mounting the slot itself as root
+func (ab *ABPartitions) UpdateFstab(trans *Transaction) error {
+	var lines []fstab.Line
+	for _, disk := range ab.d.Disks {
+		for _, part := range disk.Partitions {
+			if part.Hidden || part.MountPoint == "" {
+				continue
+			}
+			line := fstab.Line{
+				Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
+				MountPoint: part.MountPoint,
+				Options:    part.MountOpts,
+				FileSystem: part.FileSystem.String(),
+				FsckOrder:  2,
+			}
+			if part.Role == deployment.System {
+				line.Device = fmt.Sprintf("PARTUUID=%s", ab.target.UUID)
+				line.Options = ab.target.MountOpts
+				line.FsckOrder = 1
+			}
+			if len(line.Options) == 0 {
+				line.Options = []string{"defaults"}
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, logicalVolumeFstabLines(disk.Partitions)...)
+	}
+
+	return fstab.Write(ab.s, filepath.Join(trans.Path, fstab.File), lines)
+}
+
+// Lock does nothing, the slot is mounted according to its mount options
+func (ab *ABPartitions) Lock(*Transaction) error {
+	return nil
+}
+
+// GenerateKernelCmdline generates the kernel cmdline needed to boot into the slot of the given transaction
+func (ab *ABPartitions) GenerateKernelCmdline(*Transaction) string {
+	if ab.target == nil {
+		return ""
+	}
+	return fmt.Sprintf("root=PARTUUID=%s rootfstype=%s", ab.target.UUID, ab.target.FileSystem.String())
+}
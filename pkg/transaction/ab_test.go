@@ -0,0 +1,222 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+var _ = Describe("ABPartitionsTransaction", Label("transaction", "ab"), func() {
+	var ab transaction.Interface
+	var d *deployment.Deployment
+	var blk *blockmock.Device
+	var parts []*block.Partition
+	var runner *sysmock.Runner
+	var cleanup func()
+	var tfs vfs.FS
+
+	BeforeEach(func() {
+		mount := sysmock.NewMounter()
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithRunner(runner), sys.WithMounter(mount),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		d = deployment.DefaultDeployment()
+		d.Disks[0].Partitions[0].UUID = "1234-ABCD"
+		sysPart := d.GetSystemPartition()
+		sysPart.FileSystem = deployment.Ext4
+		sysPart.RWVolumes = nil
+		sysPart.MountOpts = nil
+		sysPart.Size = 2048
+		sysPart.UUID = "2345-ABCD"
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Label:      deployment.SystemBLabel,
+			Role:       deployment.SystemB,
+			FileSystem: deployment.Ext4,
+			UUID:       "3456-ABCD",
+		})
+
+		parts = []*block.Partition{
+			{
+				Name:       "/dev/loop0p1",
+				Path:       "/dev/loop0p1",
+				Label:      deployment.EfiLabel,
+				UUID:       "1234-ABCD",
+				FileSystem: deployment.VFat.String(),
+			}, {
+				Name:       "/dev/loop0p2",
+				Path:       "/dev/loop0p2",
+				Label:      deployment.SystemLabel,
+				UUID:       "2345-ABCD",
+				FileSystem: deployment.Ext4.String(),
+			}, {
+				Name:       "/dev/loop0p3",
+				Path:       "/dev/loop0p3",
+				Label:      deployment.SystemBLabel,
+				UUID:       "3456-ABCD",
+				FileSystem: deployment.Ext4.String(),
+			},
+		}
+		blk = blockmock.NewBlockDevice(parts...)
+
+		ab = transaction.NewABPartitions(context.TODO(), s, d, blk)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("writes the first slot if none is booted", func() {
+		uh, err := ab.Init(*d)
+		Expect(err).NotTo(HaveOccurred())
+		trans, err := ab.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trans.ID).To(Equal(1))
+		Expect(runner.IncludesCmds([][]string{
+			{"mkfs.ext4", "-L", deployment.SystemLabel, "-F", "/dev/loop0p2"},
+		})).To(Succeed())
+		Expect(uh.GenerateKernelCmdline(trans)).To(Equal("root=PARTUUID=2345-ABCD rootfstype=ext4"))
+
+		Expect(vfs.MkdirAll(tfs, filepath.Join(trans.Path, "etc"), vfs.DirPerm)).To(Succeed())
+		Expect(uh.UpdateFstab(trans)).To(Succeed())
+		data, err := tfs.ReadFile(filepath.Join(trans.Path, fstab.File))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(MatchRegexp(`PARTUUID=2345-ABCD\s+/\s`))
+		Expect(string(data)).To(MatchRegexp(`PARTUUID=1234-ABCD\s+/boot\s`))
+		Expect(string(data)).NotTo(ContainSubstring("3456-ABCD"))
+
+		// no slot is booted, slot A is only kept once committed
+		ids, err := ab.GetActiveSnapshotIDs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(BeEmpty())
+		Expect(ab.Commit(trans, func() error {
+			ids, err = ab.GetActiveSnapshotIDs()
+			return err
+		})).To(Succeed())
+		Expect(ids).To(Equal([]int{1}))
+	})
+
+	It("writes the alternate slot of the booted one", func() {
+		parts[1].MountPoints = []string{"/"}
+		uh, err := ab.Init(*d)
+		Expect(err).NotTo(HaveOccurred())
+		trans, err := ab.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trans.ID).To(Equal(2))
+		Expect(runner.IncludesCmds([][]string{
+			{"mkfs.ext4", "-L", deployment.SystemBLabel, "-F", "/dev/loop0p3"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"mkfs.ext4", "-L", deployment.SystemLabel, "-F", "/dev/loop0p2"},
+		})).NotTo(Succeed())
+		Expect(uh.GenerateKernelCmdline(trans)).To(Equal("root=PARTUUID=3456-ABCD rootfstype=ext4"))
+
+		Expect(vfs.MkdirAll(tfs, filepath.Join(trans.Path, "etc"), vfs.DirPerm)).To(Succeed())
+		Expect(uh.UpdateFstab(trans)).To(Succeed())
+		data, err := tfs.ReadFile(filepath.Join(trans.Path, fstab.File))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(MatchRegexp(`PARTUUID=3456-ABCD\s+/\s`))
+		Expect(string(data)).NotTo(ContainSubstring("2345-ABCD"))
+
+		// the rewritten slot B is only kept once committed
+		ids, err := ab.GetActiveSnapshotIDs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{1}))
+		Expect(ab.Commit(trans, func() error {
+			ids, err = ab.GetActiveSnapshotIDs()
+			return err
+		})).To(Succeed())
+		Expect(ids).To(Equal([]int{1, 2}))
+	})
+
+	It("returns back to slot A when slot B is booted", func() {
+		parts[2].MountPoints = []string{"/"}
+		_, err := ab.Init(*d)
+		Expect(err).NotTo(HaveOccurred())
+		trans, err := ab.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trans.ID).To(Equal(1))
+	})
+
+	It("rolls back a failed transaction", func() {
+		_, err := ab.Init(*d)
+		Expect(err).NotTo(HaveOccurred())
+		trans, err := ab.Start()
+		Expect(err).NotTo(HaveOccurred())
+		err = ab.Rollback(trans, fmt.Errorf("sync failed"))
+		Expect(err).To(MatchError(ContainSubstring("sync failed")))
+		Expect(ab.Commit(trans, nil)).NotTo(Succeed())
+	})
+
+	It("recovers an interrupted transaction depending on the booted slot", func() {
+		parts[1].MountPoints = []string{"/"}
+		_, err := ab.Init(*d)
+		Expect(err).NotTo(HaveOccurred())
+		r, ok := ab.(transaction.Recoverer)
+		Expect(ok).To(BeTrue())
+
+		// the interrupted slot is booted, hence it was committed
+		committed, err := r.Recover(&transaction.Transaction{ID: 1}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(committed).To(BeTrue())
+
+		committed, err = r.Recover(&transaction.Transaction{ID: 2}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(committed).To(BeFalse())
+		Expect(runner.GetCmds()).To(BeEmpty())
+
+		// the boot entry of the interrupted slot is pruned
+		ids, err := ab.GetActiveSnapshotIDs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{1}))
+	})
+
+	It("fails to init without a system-b partition", func() {
+		d.Disks[0].Partitions = d.Disks[0].Partitions[:2]
+		_, err := ab.Init(*d)
+		Expect(err).To(MatchError(ContainSubstring("requires a 'system' and a 'system-b' partition")))
+	})
+
+	It("fails to start if the target slot can't be formatted", func() {
+		runner.ReturnError = fmt.Errorf("mkfs failed")
+		_, err := ab.Init(*d)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = ab.Start()
+		Expect(err).To(MatchError(ContainSubstring("mkfs failed")))
+	})
+})
@@ -28,10 +28,19 @@ type Transactioner struct {
 	StartErr          error
 	CommitErr         error
 	RollbackErr       error
+	RecoverErr        error
+	StageErr          error
+	ResumeErr         error
+	DiscardErr        error
+	Committed         bool
 	Trans             *transaction.Transaction
 	UpgradeHelper     UpgradeHelper
 	SrcDigest         string
 	rollbackCalled    bool
+	recovered         *transaction.Transaction
+	recoveredStaged   bool
+	staged            bool
+	discarded         bool
 	activeSnapshotIDs []int
 }
 
@@ -78,7 +87,10 @@ func (t Transactioner) Start() (*transaction.Transaction, error) {
 	return t.Trans, t.StartErr
 }
 
-func (t Transactioner) Commit(_ *transaction.Transaction, _ func() error) error {
+func (t Transactioner) Commit(_ *transaction.Transaction, cleanup func() error) error {
+	if t.CommitErr == nil && cleanup != nil {
+		return cleanup()
+	}
 	return t.CommitErr
 }
 
@@ -91,6 +103,47 @@ func (t Transactioner) RollbackCalled() bool {
 	return t.rollbackCalled
 }
 
+func (t *Transactioner) Recover(trans *transaction.Transaction, staged bool) (bool, error) {
+	t.recovered = trans
+	t.recoveredStaged = staged
+	return t.Committed, t.RecoverErr
+}
+
+// Recovered returns the transaction passed to Recover, if any
+func (t Transactioner) Recovered() *transaction.Transaction {
+	return t.recovered
+}
+
+// RecoveredStaged returns true if the transaction passed to Recover was staged
+func (t Transactioner) RecoveredStaged() bool {
+	return t.recoveredStaged
+}
+
+func (t *Transactioner) Stage(_ *transaction.Transaction) error {
+	t.staged = true
+	return t.StageErr
+}
+
+func (t Transactioner) Staged() bool {
+	return t.staged
+}
+
+func (t Transactioner) Resume(id int, path string) (*transaction.Transaction, error) {
+	if t.ResumeErr != nil {
+		return nil, t.ResumeErr
+	}
+	return &transaction.Transaction{ID: id, Path: path}, nil
+}
+
+func (t *Transactioner) Discard(_ *transaction.Transaction) error {
+	t.discarded = true
+	return t.DiscardErr
+}
+
+func (t Transactioner) Discarded() bool {
+	return t.discarded
+}
+
 func (t Transactioner) GetActiveSnapshotIDs() ([]int, error) {
 	return t.activeSnapshotIDs, nil
 }
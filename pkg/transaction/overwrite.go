@@ -32,6 +32,7 @@ import (
 )
 
 // Overwrite transaction snapshotter is a passthrough snapshotter built to be able to verify that the transaction code works as advertised and verify the transaction interface. Should only be used for debugging.
+// It writes the system partition in place, hence an interrupted transaction can't be recovered, only its boot entries are restored.
 type Overwrite struct {
 	s          *sys.System
 	ctx        context.Context
@@ -90,6 +91,14 @@ func (n Overwrite) Start() (*Transaction, error) {
 			continue
 		}
 
+		if p.Role == deployment.LVM {
+			err = n.MountLogicalVolumes(temp, p.VolumeGroup)
+			if err != nil {
+				return nil, fmt.Errorf("failed mounting logical volumes of '%s': %w", p.Label, err)
+			}
+			continue
+		}
+
 		err = n.MountPartition(temp, hwParts, p)
 		if err != nil {
 			return nil, fmt.Errorf("failed mounting partition '%s': %w", p.Label, err)
@@ -126,6 +135,10 @@ func (n Overwrite) MountPartition(root string, hwParts block.PartitionList, p *d
 	return nil
 }
 
+func (n Overwrite) MountLogicalVolumes(root string, vg *deployment.VolumeGroup) error {
+	return mountLogicalVolumes(n.s, n.cleanStack, vg, root)
+}
+
 func (n Overwrite) Rollback(*Transaction, error) error {
 	return fmt.Errorf("cannot rollback transactions using 'overwrite' snapshotter")
 }
@@ -166,6 +179,9 @@ func (n Overwrite) UpdateFstab(trans *Transaction) error {
 	}
 
 	for _, part := range sysDisk.Partitions {
+		if part.Role == deployment.LVM {
+			continue
+		}
 		lines = append(lines, fstab.Line{
 			Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
 			MountPoint: part.MountPoint,
@@ -174,6 +190,8 @@ func (n Overwrite) UpdateFstab(trans *Transaction) error {
 		})
 
 	}
+	lines = append(lines, logicalVolumeFstabLines(sysDisk.Partitions)...)
+
 	fstabFile := filepath.Join(trans.Path, fstab.File)
 	return fstab.Write(n.s, fstabFile, lines)
 }
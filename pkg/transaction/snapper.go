@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"time"
 
 	"github.com/suse/elemental/v3/pkg/block"
 	"github.com/suse/elemental/v3/pkg/block/lsblk"
@@ -41,12 +43,12 @@ const (
 )
 
 type snapperContext struct {
-	ctx          context.Context
-	s            *sys.System
-	partitions   deployment.Partitions
-	cleanStack   *cleanstack.CleanStack
-	snap         *snapper.Snapper
-	maxSnapshots int
+	ctx        context.Context
+	s          *sys.System
+	partitions deployment.Partitions
+	cleanStack *cleanstack.CleanStack
+	snap       *snapper.Snapper
+	retention  snapper.Retention
 }
 
 // checkCancelled returns the given error if not nil, otherwise it returns the context error if any.
@@ -67,11 +69,11 @@ type snapperT struct {
 
 func NewSnapper(ctx context.Context, s *sys.System) Interface {
 	sc := snapperContext{
-		ctx:          ctx,
-		s:            s,
-		cleanStack:   cleanstack.NewCleanStack(),
-		snap:         snapper.New(s),
-		maxSnapshots: maxSnapshots,
+		ctx:        ctx,
+		s:          s,
+		cleanStack: cleanstack.NewCleanStack(),
+		snap:       snapper.New(s),
+		retention:  snapper.Retention{MaxSnapshots: maxSnapshots},
 	}
 	return &snapperT{
 		snapperContext: sc,
@@ -85,6 +87,9 @@ func (sn *snapperT) Init(d deployment.Deployment) (uh UpgradeHelper, err error)
 	for _, disk := range d.Disks {
 		sn.partitions = append(sn.partitions, disk.Partitions...)
 	}
+	if d.Snapshotter != nil {
+		sn.retention = retentionFromPolicy(d.Snapshotter.Retention)
+	}
 
 	if ok, err := sn.isInitiated(d); ok {
 		return sn.snapperContext, nil
@@ -140,7 +145,11 @@ func (sn snapperT) Commit(trans *Transaction, cleanup func() error) (err error)
 	}
 
 	sn.s.Logger().Info("Setting new default snapshot")
-	err = sn.snap.SetDefault(trans.Path, trans.ID, map[string]string{updateProgress: ""})
+	metadata := map[string]string{updateProgress: ""}
+	if version := osVersion(sn.s, trans.Path); version != "" {
+		metadata[snapper.VersionKey] = version
+	}
+	err = sn.snap.SetDefault(trans.Path, trans.ID, metadata)
 	if err != nil {
 		return fmt.Errorf("setting new default snapshot: %w", err)
 	}
@@ -150,7 +159,7 @@ func (sn snapperT) Commit(trans *Transaction, cleanup func() error) (err error)
 	if cleanup != nil {
 		sn.cleanStack.Push(cleanup)
 	}
-	sn.cleanStack.Push(func() error { return sn.snap.Cleanup(sn.rootDir, sn.maxSnapshots) })
+	sn.cleanStack.Push(func() error { return sn.snap.Cleanup(sn.rootDir, "/", sn.retention) })
 
 	err = sn.cleanStack.Cleanup(err)
 	if err != nil {
@@ -254,6 +263,80 @@ func (sn snapperT) GetActiveSnapshotIDs() ([]int, error) {
 	return snapIDs, nil
 }
 
+// Recover deletes the snapshot of a transaction interrupted before being committed. Snapshots
+// already set as default and snapshots of staged transactions are kept.
+func (sn snapperT) Recover(trans *Transaction, staged bool) (bool, error) {
+	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
+	if err != nil {
+		return false, fmt.Errorf("listing snapshots: %w", err)
+	}
+	if snaps.GetDefault() == trans.ID {
+		return true, nil
+	}
+	if staged {
+		sn.s.Logger().Info("Keeping snapshot of staged transaction '%d'", trans.ID)
+		return false, nil
+	}
+	if ok, _ := vfs.Exists(sn.s.FS(), trans.Path); !ok {
+		return false, nil
+	}
+	sn.s.Logger().Info("Deleting snapshot of interrupted transaction '%d'", trans.ID)
+	err = sn.snap.DeleteByPath(trans.Path)
+	if err != nil {
+		return false, fmt.Errorf("deleting snapshot '%d': %w", trans.ID, err)
+	}
+	return false, nil
+}
+
+// Stage closes the given in progress transaction without setting its snapshot as the default one.
+// The snapshot is kept, so the transaction can be resumed and committed later on.
+func (sn snapperT) Stage(trans *Transaction) error {
+	if trans.status != started {
+		return fmt.Errorf("transaction '%d' is not started", trans.ID)
+	}
+	sn.s.Logger().Info("Staging transaction '%d'", trans.ID)
+	err := sn.cleanStack.Cleanup(nil)
+	if err != nil {
+		return fmt.Errorf("closing staged transaction: %w", err)
+	}
+	trans.status = staged
+	return nil
+}
+
+// Resume reopens a staged transaction by mounting again its partitions and volumes
+func (sn snapperT) Resume(id int, path string) (*Transaction, error) {
+	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	if !slices.ContainsFunc(snaps, func(snap *snapper.Snapshot) bool { return snap.Number == id }) {
+		return nil, fmt.Errorf("staged snapshot '%d' not found", id)
+	}
+	if snaps.GetDefault() == id {
+		return nil, fmt.Errorf("staged snapshot '%d' is already the default one", id)
+	}
+
+	sn.s.Logger().Info("Resuming staged transaction '%d'", id)
+	trans := &Transaction{ID: id, Path: path, Merges: map[string]*Merge{}, status: started}
+	err = sn.mountPartitions(trans)
+	if err != nil {
+		return nil, sn.cleanStack.Cleanup(fmt.Errorf("mounting partitions: %w", err))
+	}
+	return trans, nil
+}
+
+// Discard deletes the snapshot of the given staged transaction
+func (sn snapperT) Discard(trans *Transaction) error {
+	if trans.status == committed {
+		return fmt.Errorf("cannot discard a committed transaction")
+	}
+	sn.s.Logger().Info("Discarding staged transaction '%d'", trans.ID)
+	err := sn.cleanStack.Cleanup(nil)
+	err = errors.Join(err, sn.snap.DeleteByPath(trans.Path))
+	trans.status = failed
+	return err
+}
+
 // mountPartition mounts the given partition to the given mount point. In addition it also
 // sets the umount cleanup task.
 func (sn snapperT) mountPartition(part *deployment.Partition, mountPoint string) error {
@@ -389,6 +472,12 @@ func (sn snapperT) preparePartitions(baseID int, trans *Transaction) error {
 				return err
 			}
 		}
+		if part.Role == deployment.LVM && part.VolumeGroup != nil {
+			err := mountLogicalVolumes(sn.s, sn.cleanStack, part.VolumeGroup, trans.Path)
+			if err != nil {
+				return err
+			}
+		}
 		for _, rwVol := range part.RWVolumes {
 			volumePath := filepath.Join(trans.Path, rwVol.Path)
 			err := vfs.MkdirAll(sn.s.FS(), filepath.Dir(volumePath), vfs.DirPerm)
@@ -414,6 +503,13 @@ func (sn snapperT) preparePartitions(baseID int, trans *Transaction) error {
 				if merge != nil {
 					trans.Merges[rwVol.Path] = merge
 				}
+				if baseID > 0 {
+					// Snapshots of the volume accumulate in the base snapshot, they are only
+					// cleaned up once the transaction succeeds
+					sn.cleanStack.PushSuccessOnly(func() error {
+						return sn.snap.Cleanup(basePath, rwVol.Path, sn.volumeRetention())
+					})
+				}
 				if part.Role != deployment.System {
 					err = sn.mountVol(part, filepath.Join(fmt.Sprintf(snapshotPathTmpl, trans.ID), rwVol.Path), volumePath)
 					if err != nil {
@@ -426,6 +522,50 @@ func (sn snapperT) preparePartitions(baseID int, trans *Transaction) error {
 					return fmt.Errorf("mounting partition '%s': %w", part.UUID, err)
 				}
 			}
+			// Snapshotted volumes are new subvolumes on each transaction, hence the quota
+			// limit is applied every time
+			if rwVol.Quota > 0 {
+				err = btrfs.SetQuotaLimit(sn.s, volumePath, uint(rwVol.Quota))
+				if err != nil {
+					return fmt.Errorf("setting quota of rw volume '%s': %w", rwVol.Path, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mountPartitions mounts all the partitions and volumes at the expected locations of the snapshot of
+// an already prepared transaction. Snapshotted volumes of the system partition are nested within the
+// snapshot, hence they do not require any mount.
+func (sn snapperT) mountPartitions(trans *Transaction) error {
+	for _, part := range sn.partitions {
+		if part.Role != deployment.System && part.MountPoint != "" {
+			err := sn.mountPartition(part, filepath.Join(trans.Path, part.MountPoint))
+			if err != nil {
+				return err
+			}
+		}
+		if part.Role == deployment.LVM && part.VolumeGroup != nil {
+			err := mountLogicalVolumes(sn.s, sn.cleanStack, part.VolumeGroup, trans.Path)
+			if err != nil {
+				return err
+			}
+		}
+		for _, rwVol := range part.RWVolumes {
+			var err error
+			volumePath := filepath.Join(trans.Path, rwVol.Path)
+			switch {
+			case rwVol.Snapshotted && part.Role == deployment.System:
+				continue
+			case rwVol.Snapshotted:
+				err = sn.mountVol(part, filepath.Join(fmt.Sprintf(snapshotPathTmpl, trans.ID), rwVol.Path), volumePath)
+			default:
+				err = sn.mountVol(part, rwVol.Path, volumePath)
+			}
+			if err != nil {
+				return fmt.Errorf("mounting partition '%s': %w", part.UUID, err)
+			}
 		}
 	}
 	return nil
@@ -460,3 +600,35 @@ func (sn snapperT) createNewSnapshot(baseID int) (*Transaction, error) {
 		status: started,
 	}, nil
 }
+
+// retentionFromPolicy converts the deployment retention policy to the snapper one. It falls back
+// to the default maximum number of snapshots if no limit is set.
+func retentionFromPolicy(policy deployment.RetentionPolicy) snapper.Retention {
+	retention := snapper.Retention{
+		MaxSnapshots:   policy.MaxSnapshots,
+		MaxAge:         time.Duration(policy.MaxAge),
+		KeepPerVersion: policy.KeepPerVersion,
+		MaxUsage:       uint64(policy.MaxUsage) * 1024 * 1024,
+	}
+	if retention.MaxSnapshots <= 0 && retention.MaxAge <= 0 && retention.MaxUsage == 0 {
+		retention.MaxSnapshots = maxSnapshots
+	}
+	return retention
+}
+
+// volumeRetention returns the retention policy for snapshotted RW volumes. Stock snapshots are
+// required for future merges, hence they are always kept.
+func (sc snapperContext) volumeRetention() snapper.Retention {
+	retention := sc.retention
+	retention.Keep = snapper.Metadata{"stock": "true"}
+	return retention
+}
+
+// osVersion returns the VERSION_ID of the OS under the given root, if any
+func osVersion(s *sys.System, root string) string {
+	osRelease, err := vfs.LoadEnvFile(s.FS(), filepath.Join(root, "etc", "os-release"))
+	if err != nil {
+		return ""
+	}
+	return osRelease["VERSION_ID"]
+}
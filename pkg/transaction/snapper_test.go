@@ -24,6 +24,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
 )
 
@@ -167,6 +169,12 @@ var _ = Describe("SnapperTransaction", Label("transaction"), func() {
 							return []byte("2\n"), nil
 						}
 					}
+					if slices.Contains(args, "etc") && slices.Contains(args, "list") {
+						return []byte(etcSnaps), nil
+					}
+					if slices.Contains(args, "home") && slices.Contains(args, "list") {
+						return []byte(homeSnaps), nil
+					}
 					if slices.Contains(args, "list") {
 						return []byte(installSnapList), nil
 					}
@@ -176,8 +184,139 @@ var _ = Describe("SnapperTransaction", Label("transaction"), func() {
 				Expect(runner.MatchMilestones([][]string{
 					{"snapper", "--no-dbus", "--root", "/.snapshots/5/snapshot", "modify", "--default"},
 				})).To(Succeed())
+				Expect(runner.IncludesCmds([][]string{{"btrfs", "subvolume", "delete"}})).NotTo(Succeed())
 			})
 		})
+		It("cleans up the snapshots of rw volumes according to the retention policy", func() {
+			d.Snapshotter.Retention.MaxSnapshots = 1
+			runner.ClearCmds()
+			sn = transaction.NewSnapper(ctx, s)
+			_, err = sn.Init(*d)
+			Expect(err).NotTo(HaveOccurred())
+			trans = startUpgradeTransaction()
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "create") {
+					return []byte("3\n"), nil
+				}
+				if slices.Contains(args, "etc") && slices.Contains(args, "list") {
+					return []byte(etcSnaps), nil
+				}
+				if slices.Contains(args, "home") && slices.Contains(args, "list") {
+					return []byte(homeSnaps), nil
+				}
+				if slices.Contains(args, "list") {
+					return []byte(installSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			Expect(sn.Commit(trans, nil)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/4/snapshot/etc/.snapshots/2/snapshot"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/tmp/elemental_generic/.snapshots/4/snapshot/home/.snapshots/2/snapshot"},
+			})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/4/snapshot/etc/.snapshots/1/snapshot"},
+			})).NotTo(Succeed())
+		})
+		It("applies quota limits of rw volumes on each transaction", func() {
+			d.Disks[0].Partitions[1].RWVolumes[0].Quota = 2048
+			d.Disks[0].Partitions[1].RWVolumes[2].Quota = 512
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "create") {
+					return []byte("5\n"), nil
+				}
+				if slices.Contains(args, "etc") && slices.Contains(args, "list") {
+					return []byte(etcSnaps), nil
+				}
+				if slices.Contains(args, "home") && slices.Contains(args, "list") {
+					return []byte(homeSnaps), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			trans, err = sn.Start()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.IncludesCmds([][]string{
+				{"btrfs", "qgroup", "limit", "2048M", "/.snapshots/5/snapshot/var"},
+				{"btrfs", "qgroup", "limit", "512M", "/.snapshots/5/snapshot/etc"},
+			})).To(Succeed())
+		})
+		It("stages a transaction and resumes it", func() {
+			trans = startUpgradeTransaction()
+			stager, ok := sn.(transaction.Stager)
+			Expect(ok).To(BeTrue())
+			Expect(stager.Stage(trans)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"snapper", "modify", "--default"}})).NotTo(Succeed())
+			Expect(stager.Stage(trans)).To(MatchError("transaction '5' is not started"))
+
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") && slices.Contains(args, "root") {
+					return []byte(upgradeSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			trans, err = stager.Resume(3, "/.snapshots/3/snapshot")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trans.ID).To(Equal(3))
+			Expect(mount.IsMountPoint("/.snapshots/3/snapshot/home")).To(BeTrue())
+			Expect(stager.Stage(trans)).To(Succeed())
+			Expect(mount.IsMountPoint("/.snapshots/3/snapshot/home")).To(BeFalse())
+		})
+		It("fails to resume the default snapshot or a missing one", func() {
+			stager := sn.(transaction.Stager)
+			_, err = stager.Resume(4, "/.snapshots/4/snapshot")
+			Expect(err).To(MatchError("staged snapshot '4' is already the default one"))
+			_, err = stager.Resume(9, "/.snapshots/9/snapshot")
+			Expect(err).To(MatchError("staged snapshot '9' not found"))
+		})
+		It("discards a staged transaction", func() {
+			stager := sn.(transaction.Stager)
+			Expect(stager.Discard(&transaction.Transaction{ID: 3, Path: "/.snapshots/3/snapshot"})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/3/snapshot"},
+			})).To(Succeed())
+		})
+		It("deletes the snapshot of an interrupted transaction", func() {
+			Expect(vfs.MkdirAll(tfs, "/.snapshots/5/snapshot", vfs.DirPerm)).To(Succeed())
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") {
+					return []byte(installSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			r, ok := sn.(transaction.Recoverer)
+			Expect(ok).To(BeTrue())
+			committed, err := r.Recover(&transaction.Transaction{ID: 5, Path: "/.snapshots/5/snapshot"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(committed).To(BeFalse())
+			Expect(runner.IncludesCmds([][]string{
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/5/snapshot"},
+			})).To(Succeed())
+		})
+		It("keeps the snapshot of an interrupted transaction already committed", func() {
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") {
+					return []byte(installSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			committed, err := sn.(transaction.Recoverer).Recover(&transaction.Transaction{ID: 1, Path: "/.snapshots/1/snapshot"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(committed).To(BeTrue())
+			Expect(runner.IncludesCmds([][]string{{"btrfs", "subvolume", "delete"}})).NotTo(Succeed())
+		})
+		It("keeps the snapshot of a staged transaction whose activation was interrupted", func() {
+			Expect(vfs.MkdirAll(tfs, "/.snapshots/5/snapshot", vfs.DirPerm)).To(Succeed())
+			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+				if slices.Contains(args, "list") {
+					return []byte(installSnapList), nil
+				}
+				return runner.ReturnValue, runner.ReturnError
+			}
+			committed, err := sn.(transaction.Recoverer).Recover(&transaction.Transaction{ID: 5, Path: "/.snapshots/5/snapshot"}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(committed).To(BeFalse())
+			Expect(runner.IncludesCmds([][]string{{"btrfs", "subvolume", "delete"}})).NotTo(Succeed())
+		})
 		It("it fails to start a transaction if it does not find previous snapshotted volumes", func() {
 			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
 				if slices.Contains(args, "create") {
@@ -195,6 +334,48 @@ var _ = Describe("SnapperTransaction", Label("transaction"), func() {
 			})).To(Succeed())
 		})
 	})
+	It("applies quota limits of rw volumes in non system partitions", func() {
+		dataPart := d.Disks[0].Partitions[2]
+		dataPart.RWVolumes[0].Quota = 1024
+		dataPart.RWVolumes = append(dataPart.RWVolumes, deployment.RWVolume{Path: "/data", Quota: 256})
+		_ = initSnapperInstall("/some/root")
+		sideEffects["snapper"] = func(args ...string) ([]byte, error) {
+			if slices.Contains(args, "--print-number") {
+				return []byte("1\n"), nil
+			}
+			return runner.ReturnValue, runner.ReturnError
+		}
+		_, err = sn.Start()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.IncludesCmds([][]string{
+			{"btrfs", "qgroup", "limit", "1024M", "/some/root/@/.snapshots/1/snapshot/home"},
+			{"btrfs", "qgroup", "limit", "256M", "/some/root/@/.snapshots/1/snapshot/data"},
+		})).To(Succeed())
+		Expect(mount.IsMountPoint("/some/root/@/.snapshots/1/snapshot/data")).To(BeTrue())
+	})
+	It("mounts logical volumes with their filesystem and mount options", func() {
+		d.Disks[0].Partitions = append(d.Disks[0].Partitions, &deployment.Partition{
+			Role: deployment.LVM,
+			VolumeGroup: &deployment.VolumeGroup{
+				Name: "data",
+				LogicalVolumes: []*deployment.LogicalVolume{{
+					Name:       "srv",
+					FileSystem: deployment.XFS,
+					MountPoint: "/srv",
+					MountOpts:  []string{"noatime"},
+				}},
+			},
+		})
+		_ = initSnapperInstall("/some/root")
+		_ = startInstallTransaction()
+
+		mnts, err := mount.GetMountPoints("/dev/data/srv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mnts).To(HaveLen(1))
+		Expect(mnts[0].Path).To(Equal("/some/root/@/.snapshots/1/snapshot/srv"))
+		Expect(mnts[0].Type).To(Equal("xfs"))
+		Expect(mnts[0].Opts).To(Equal([]string{"noatime"}))
+	})
 	It("fails to init snapper transactioner if it can't list snapshots", func() {
 		Expect(mount.Mount("/dev/sda2", "/", "", []string{"ro", "subvol=@/.snapshots/4/snapshot"})).To(Succeed())
 		sideEffects["lsblk"] = func(args ...string) ([]byte, error) {
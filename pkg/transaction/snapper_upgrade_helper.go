@@ -19,6 +19,7 @@ package transaction
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,16 +27,27 @@ import (
 	"strconv"
 	"strings"
 
+	"go.yaml.in/yaml/v3"
+
 	"github.com/suse/elemental/v3/pkg/btrfs"
 	"github.com/suse/elemental/v3/pkg/chroot"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/lvm"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
+const (
+	// ConflictsReport is the path of the merge conflicts report within a transaction
+	ConflictsReport = "/etc/elemental/merge-conflicts.yaml"
+
+	conflictSuffix = ".elemental-new"
+)
+
 // SyncImageContent syncs the given image tree to given transaction. For the first transaction all content
 // is synced regardless if some paths are under a persistent path or not. On upgrades it only syncs the immutable
 // content and snapshotted paths.
@@ -80,6 +92,11 @@ func (sc snapperContext) Merge(trans *Transaction) (err error) {
 	if err != nil {
 		return fmt.Errorf("merging content of snapshotted rw volumes: %w", err)
 	}
+
+	err = writeConflictsReport(sc.s, trans)
+	if err != nil {
+		return fmt.Errorf("writing merge conflicts report: %w", err)
+	}
 	return nil
 }
 
@@ -139,6 +156,9 @@ func (sc snapperContext) syncSnapshotExcludes(fullSync bool) []string {
 			}
 		}
 	}
+	if !fullSync {
+		excludes = append(excludes, logicalVolumeMountPoints(sc.partitions)...)
+	}
 	return excludes
 }
 
@@ -154,12 +174,12 @@ func (sc snapperContext) syncSnapshotDeleteExcludes() []string {
 			excludes = append(excludes, rwVol.Path)
 		}
 	}
-	return excludes
+	return append(excludes, logicalVolumeMountPoints(sc.partitions)...)
 }
 
 // configureSnapper sets the snapper configuration for root and any snapshotted volume.
 func (sc snapperContext) configureSnapper(trans *Transaction) error {
-	err := sc.snap.ConfigureRoot(trans.Path, sc.maxSnapshots)
+	err := sc.snap.ConfigureRoot(trans.Path, sc.retention.MaxSnapshots)
 	if err != nil {
 		return fmt.Errorf("setting root configuration: %w", err)
 	}
@@ -212,8 +232,8 @@ func (sc snapperContext) configureRWVolumes(trans *Transaction) error {
 }
 
 // merge runs a 3 way merge for snapshotted RW volumes.
-// Current implementation solves potential conflicts by always keeping
-// custom changes over changes coming from the OS image.
+// Conflicts, files changed by both the user and the new OS image, are solved
+// according to the merge policy of each volume path and recorded in the transaction.
 func (sc snapperContext) merge(trans *Transaction) (err error) {
 	var status, tmpDir string
 
@@ -240,10 +260,11 @@ func (sc snapperContext) merge(trans *Transaction) (err error) {
 			return err
 		}
 
-		err = sc.applyCustomChanges(status, rwVol.Path, m)
+		conflicts, err := sc.applyCustomChanges(status, rwVol, m)
 		if err != nil {
 			return err
 		}
+		trans.Conflicts = append(trans.Conflicts, conflicts...)
 	}
 	return nil
 }
@@ -275,12 +296,14 @@ func (sc snapperContext) customChangesStatus(volPath string, merge *Merge, outpu
 }
 
 // applyCustomChanges reads the given status file and applies reported changes in to the target destination.
-// This method is the responsible of applying customizations to the new volume
-func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Merge) (err error) {
-	sc.s.Logger().Debug("rw volume path: %s", rwVolPath)
+// This method is the responsible of applying customizations to the new volume. Customizations of files
+// that were also changed by the new OS image are solved according to the volume merge policy and
+// returned as conflicts.
+func (sc snapperContext) applyCustomChanges(status string, rwVol deployment.RWVolume, merge *Merge) (conflicts []Conflict, err error) {
+	sc.s.Logger().Debug("rw volume path: %s", rwVol.Path)
 	statusF, err := sc.s.FS().OpenFile(status, os.O_RDONLY, vfs.FilePerm)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		e := statusF.Close()
@@ -289,10 +312,10 @@ func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Mer
 		}
 	}()
 
-	syncFiles := filepath.Join(filepath.Dir(status), fmt.Sprintf("sync_%s", snapper.ConfigName(rwVolPath)))
+	syncFiles := filepath.Join(filepath.Dir(status), fmt.Sprintf("sync_%s", snapper.ConfigName(rwVol.Path)))
 	syncF, err := sc.s.FS().OpenFile(syncFiles, os.O_CREATE|os.O_WRONLY, vfs.FilePerm)
 	if err != nil {
-		return fmt.Errorf("failed opening modified files list: %w", err)
+		return nil, fmt.Errorf("failed opening modified files list: %w", err)
 	}
 
 	r := regexp.MustCompile(`(([-+ct.])[p.][u.][g.][x.][a.])\s+(.*)`)
@@ -302,31 +325,44 @@ func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Mer
 		line := scanner.Text()
 		match := r.FindStringSubmatch(line)
 
-		switch {
-		case len(match) == 0:
-			continue
-		case match[1] == "....":
+		if len(match) == 0 || match[1] == "...." {
 			// Ignore extended attributes changes because the stock snapshot used for
 			// comparison was taken before SELINUX relabelling, hence this is likely to
 			// list almost every single file.
 			continue
-		case match[2] == "-":
-			err = sc.s.FS().RemoveAll(filepath.Join(merge.New, match[3]))
-			if err != nil {
-				_ = syncF.Close()
-				return err
-			}
-		default:
-			_, err = fmt.Fprintln(syncF, strings.TrimPrefix(match[3], rwVolPath)) // #nosec G705
-			if err != nil {
-				_ = syncF.Close()
-				return err
+		}
+
+		relPath := strings.TrimPrefix(match[3], rwVol.Path)
+		if sc.imageChanged(merge, relPath) {
+			policy := rwVol.MergePolicy(match[3])
+			sc.s.Logger().Warn("Merge conflict on '%s', applying policy '%s'", match[3], policy)
+			conflicts = append(conflicts, Conflict{Volume: rwVol.Path, Path: match[3], Policy: policy})
+
+			switch policy {
+			case deployment.TakeImage:
+				continue
+			case deployment.KeepBoth:
+				err = sc.keepImageVersion(filepath.Join(merge.New, relPath))
+				if err != nil {
+					_ = syncF.Close()
+					return nil, err
+				}
 			}
 		}
+
+		if match[2] == "-" {
+			err = sc.s.FS().RemoveAll(filepath.Join(merge.New, relPath))
+		} else {
+			_, err = fmt.Fprintln(syncF, relPath) // #nosec G705
+		}
+		if err != nil {
+			_ = syncF.Close()
+			return nil, err
+		}
 	}
 	err = syncF.Close()
 	if err != nil {
-		return fmt.Errorf("failed closing modified files list: %w", err)
+		return nil, fmt.Errorf("failed closing modified files list: %w", err)
 	}
 
 	syncFlags := append(rsync.DefaultFlags(), "--files-from", syncFiles)
@@ -334,12 +370,79 @@ func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Mer
 	sync := rsync.NewRsync(sc.s, rsync.WithContext(sc.ctx), rsync.WithFlags(syncFlags...))
 	err = sync.SyncData(merge.Modified, merge.New, snapper.SnapshotsPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
+// imageChanged checks whether the given path, relative to the volume, differs between the old
+// stock tree and the new image tree. Directories are not considered as changed.
+func (sc snapperContext) imageChanged(merge *Merge, relPath string) bool {
+	oldFile := filepath.Join(merge.Old, relPath)
+	newFile := filepath.Join(merge.New, relPath)
+
+	oldInfo, oldErr := sc.s.FS().Lstat(oldFile)
+	newInfo, newErr := sc.s.FS().Lstat(newFile)
+	switch {
+	case oldErr != nil && newErr != nil:
+		return false
+	case oldErr != nil || newErr != nil:
+		return true
+	case oldInfo.IsDir() && newInfo.IsDir():
+		return false
+	case oldInfo.Mode().Type() != newInfo.Mode().Type():
+		return true
+	case oldInfo.Mode()&os.ModeSymlink != 0:
+		oldLink, _ := sc.s.FS().Readlink(oldFile)
+		newLink, _ := sc.s.FS().Readlink(newFile)
+		return oldLink != newLink
+	case oldInfo.Size() != newInfo.Size():
+		return true
+	}
+
+	oldData, err := sc.s.FS().ReadFile(oldFile)
+	if err != nil {
+		return true
 	}
+	newData, err := sc.s.FS().ReadFile(newFile)
+	if err != nil {
+		return true
+	}
+	return !bytes.Equal(oldData, newData)
+}
 
+// keepImageVersion stores the new image version of the given file with the '.elemental-new' suffix
+func (sc snapperContext) keepImageVersion(path string) error {
+	if ok, _ := vfs.Exists(sc.s.FS(), path); !ok {
+		return nil
+	}
+	err := vfs.CopyFile(sc.s.FS(), path, path+conflictSuffix)
+	if err != nil {
+		return fmt.Errorf("keeping image version of '%s': %w", path, err)
+	}
 	return nil
 }
 
+// writeConflictsReport stores the merge conflicts of the given transaction within the transaction
+// itself. Any previous report is removed if there are no conflicts.
+func writeConflictsReport(s *sys.System, trans *Transaction) error {
+	report := filepath.Join(trans.Path, ConflictsReport)
+	if len(trans.Conflicts) == 0 {
+		return s.FS().RemoveAll(report)
+	}
+
+	data, err := yaml.Marshal(trans.Conflicts)
+	if err != nil {
+		return fmt.Errorf("marshalling conflicts: %w", err)
+	}
+	err = vfs.MkdirAll(s.FS(), filepath.Dir(report), vfs.DirPerm)
+	if err != nil {
+		return err
+	}
+	return s.FS().WriteFile(report, data, vfs.FilePerm)
+}
+
 // snapshotIDFromPath determines the snapshot ID form the snapshot root path
 func snapshotIDFromPath(path string) (int, error) {
 	r := regexp.MustCompile(`.*/.snapshots/(\d+)/snapshot$`)
@@ -390,6 +493,10 @@ func (sc snapperContext) updateFstab(trans *Transaction) error {
 			})
 		}
 	}
+	for _, line := range logicalVolumeFstabLines(sc.partitions) {
+		oldLines = append(oldLines, fstab.Line{MountPoint: line.MountPoint})
+		newLines = append(newLines, line)
+	}
 	fstabFile := filepath.Join(trans.Path, fstab.File)
 	return fstab.Update(sc.s, fstabFile, oldLines, newLines)
 }
@@ -446,6 +553,47 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 			fstabLines = append(fstabLines, line)
 		}
 	}
+	fstabLines = append(fstabLines, logicalVolumeFstabLines(sc.partitions)...)
 
 	return fstab.Write(sc.s, filepath.Join(trans.Path, fstab.File), fstabLines)
 }
+
+// logicalVolumeFstabLines returns the fstab lines of all the logical volumes including a mount point
+func logicalVolumeFstabLines(parts deployment.Partitions) []fstab.Line {
+	var fstabLines []fstab.Line
+	for _, part := range parts {
+		if part.Hidden || part.Role != deployment.LVM || part.VolumeGroup == nil {
+			continue
+		}
+		for _, lv := range part.VolumeGroup.LogicalVolumes {
+			if lv.MountPoint == "" {
+				continue
+			}
+			opts := lv.MountOpts
+			if len(opts) == 0 {
+				opts = []string{"defaults"}
+			}
+			fstabLines = append(fstabLines, fstab.Line{
+				Device:     lvm.DevicePath(part.VolumeGroup.Name, lv.Name),
+				MountPoint: lv.MountPoint,
+				Options:    opts,
+				FileSystem: lv.FileSystem.String(),
+				FsckOrder:  2,
+			})
+		}
+	}
+	return fstabLines
+}
+
+// logicalVolumeMountPoints returns the mount points of all the logical volumes
+func logicalVolumeMountPoints(parts deployment.Partitions) []string {
+	var mountPoints []string
+	for _, vg := range parts.GetVolumeGroups() {
+		for _, lv := range vg.LogicalVolumes {
+			if lv.MountPoint != "" {
+				mountPoints = append(mountPoints, lv.MountPoint)
+			}
+		}
+	}
+	return mountPoints
+}
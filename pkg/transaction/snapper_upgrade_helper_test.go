@@ -26,6 +26,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/pkg/btrfs"
+	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
 )
@@ -192,6 +193,70 @@ var _ = Describe("SnapperUpgradeHelper", Label("transaction"), func() {
 				{"rsync"},
 			})).To(Succeed())
 		})
+		Describe("merge conflicts", func() {
+			var oldDir, newDir string
+			BeforeEach(func() {
+				snapshotP := ".snapshots/5/snapshot"
+				template := filepath.Join(root, snapshotP, "/usr/share/snapper/config-templates/default")
+				snSysConf := filepath.Join(root, snapshotP, "/etc/sysconfig/snapper")
+				Expect(vfs.MkdirAll(tfs, filepath.Join(root, snapshotP, "/etc/snapper/configs"), vfs.DirPerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, filepath.Dir(template), vfs.DirPerm)).To(Succeed())
+				Expect(tfs.WriteFile(template, []byte{}, vfs.FilePerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, filepath.Dir(snSysConf), vfs.DirPerm)).To(Succeed())
+				Expect(tfs.WriteFile(snSysConf, []byte{}, vfs.FilePerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, "/tmp/snapStatus", vfs.DirPerm)).To(Succeed())
+				Expect(tfs.WriteFile("/tmp/snapStatus/snap_status_etc", []byte(snapperStatus), vfs.FilePerm)).To(Succeed())
+				Expect(tfs.WriteFile("/tmp/snapStatus/snap_status_home", []byte{}, vfs.FilePerm)).To(Succeed())
+
+				oldDir = "/.snapshots/4/snapshot/etc/.snapshots/1/snapshot"
+				newDir = "/.snapshots/5/snapshot/etc"
+				Expect(vfs.MkdirAll(tfs, oldDir, vfs.DirPerm)).To(Succeed())
+				for file, data := range map[string]string{
+					filepath.Join(oldDir, "modifiedFile"): "old default",
+					filepath.Join(newDir, "modifiedFile"): "new default",
+					filepath.Join(oldDir, "deletedFile"):  "old default",
+					filepath.Join(newDir, "deletedFile"):  "new default",
+				} {
+					Expect(tfs.WriteFile(file, []byte(data), vfs.FilePerm)).To(Succeed())
+				}
+			})
+			It("keeps user changes by default and reports the conflicts", func() {
+				d.Disks[0].Partitions[1].RWVolumes[2].MergeRules = []deployment.MergeRule{
+					{Path: "/etc/modifiedFile", Policy: deployment.KeepBoth},
+				}
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(trans.Conflicts).To(ConsistOf(
+					transaction.Conflict{Volume: "/etc", Path: "/etc/deletedFile", Policy: deployment.KeepUser},
+					transaction.Conflict{Volume: "/etc", Path: "/etc/modifiedFile", Policy: deployment.KeepBoth},
+				))
+				Expect(vfs.Exists(tfs, filepath.Join(newDir, "deletedFile"))).To(BeFalse())
+				data, err := tfs.ReadFile(filepath.Join(newDir, "modifiedFile.elemental-new"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal("new default"))
+
+				data, err = tfs.ReadFile(filepath.Join("/.snapshots/5/snapshot", transaction.ConflictsReport))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("path: /etc/modifiedFile\n  policy: keep-both"))
+			})
+			It("takes the image version for matching paths", func() {
+				d.Disks[0].Partitions[1].RWVolumes[2].MergeRules = []deployment.MergeRule{
+					{Path: "/etc/*", Policy: deployment.TakeImage},
+				}
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(trans.Conflicts).To(HaveLen(2))
+				data, err := tfs.ReadFile(filepath.Join(newDir, "deletedFile"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal("new default"))
+				Expect(vfs.Exists(tfs, filepath.Join(newDir, "modifiedFile.elemental-new"))).To(BeFalse())
+			})
+			It("does not report files only changed by the user", func() {
+				Expect(tfs.WriteFile(filepath.Join(newDir, "modifiedFile"), []byte("old default"), vfs.FilePerm)).To(Succeed())
+				Expect(tfs.WriteFile(filepath.Join(newDir, "deletedFile"), []byte("old default"), vfs.FilePerm)).To(Succeed())
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(trans.Conflicts).To(BeEmpty())
+				Expect(vfs.Exists(tfs, filepath.Join("/.snapshots/5/snapshot", transaction.ConflictsReport))).To(BeFalse())
+			})
+		})
 		It("updates fstab", func() {
 			fstab := filepath.Join(root, ".snapshots/5/snapshot/etc/fstab")
 			Expect(vfs.MkdirAll(tfs, filepath.Dir(fstab), vfs.DirPerm)).To(Succeed())
@@ -20,10 +20,14 @@ package transaction
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/suse/elemental/v3/pkg/block/lsblk"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/lvm"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
@@ -35,6 +39,7 @@ const (
 	started transactionState = iota + 1
 	committed
 	failed
+	staged
 )
 
 func New(ctx context.Context, s *sys.System, d *deployment.Deployment, name string) (Interface, error) {
@@ -43,6 +48,8 @@ func New(ctx context.Context, s *sys.System, d *deployment.Deployment, name stri
 		return NewSnapper(ctx, s), nil
 	case "overwrite":
 		return NewOverwrite(ctx, s, d, lsblk.NewLsDevice(s)), nil
+	case "ab":
+		return NewABPartitions(ctx, s, d, lsblk.NewLsDevice(s)), nil
 	}
 
 	return nil, fmt.Errorf("unknown snapshotter '%s'", name)
@@ -54,10 +61,19 @@ type Merge struct {
 	Modified string // modified tree on top of the old tree
 }
 
+// Conflict describes a file modified by both the user and the new OS image while
+// merging a snapshotted volume
+type Conflict struct {
+	Volume string                 `yaml:"volume"`
+	Path   string                 `yaml:"path"`
+	Policy deployment.MergePolicy `yaml:"policy"`
+}
+
 type Transaction struct {
-	ID     int
-	Path   string
-	Merges map[string]*Merge
+	ID        int
+	Path      string
+	Merges    map[string]*Merge
+	Conflicts []Conflict
 
 	status transactionState
 }
@@ -71,6 +87,25 @@ type Interface interface {
 	GetActiveSnapshotIDs() ([]int, error)
 }
 
+// Recoverer is implemented by the snapshotters capable of discarding a transaction left behind
+// by an interrupted process. Recover returns true if the given transaction was already committed,
+// in that case it is kept. Staged transactions are not discarded either, they are kept to be
+// activated later on.
+type Recoverer interface {
+	Recover(trans *Transaction, staged bool) (bool, error)
+}
+
+// Stager is implemented by the snapshotters capable of keeping a fully prepared transaction
+// to commit it later on, so the new system is activated only when required.
+type Stager interface {
+	// Stage closes the in progress transaction keeping its snapshot without activating it
+	Stage(*Transaction) error
+	// Resume reopens the given staged transaction, so it can be committed
+	Resume(id int, path string) (*Transaction, error)
+	// Discard deletes the given staged transaction
+	Discard(*Transaction) error
+}
+
 type UpgradeHelper interface {
 	SyncImageContent(*deployment.ImageSource, *Transaction, ...unpack.Opt) error
 	Merge(*Transaction) error
@@ -78,3 +113,38 @@ type UpgradeHelper interface {
 	Lock(*Transaction) error
 	GenerateKernelCmdline(*Transaction) string
 }
+
+// mountLogicalVolumes mounts the logical volumes of the given volume group including a mount point
+// under the given root using their filesystem and mount options. In addition it also sets the umount
+// cleanup tasks in the given clean stack.
+func mountLogicalVolumes(s *sys.System, cleanStack *cleanstack.CleanStack, vg *deployment.VolumeGroup, root string) error {
+	if vg == nil {
+		return nil
+	}
+
+	for _, lv := range vg.LogicalVolumes {
+		if lv.MountPoint == "" {
+			continue
+		}
+
+		target := filepath.Join(root, lv.MountPoint)
+
+		s.Logger().Debug("Mounting logical volume '%s/%s' to '%s'", vg.Name, lv.Name, target)
+
+		if err := vfs.MkdirAll(s.FS(), target, vfs.DirPerm); err != nil {
+			return fmt.Errorf("failed creating mountpoint %s: %w", target, err)
+		}
+
+		err := s.Mounter().Mount(lvm.DevicePath(vg.Name, lv.Name), target, lv.FileSystem.String(), lv.MountOpts)
+		if err != nil {
+			return fmt.Errorf("failed mounting logical volume '%s/%s': %w", vg.Name, lv.Name, err)
+		}
+
+		cleanStack.Push(func() error {
+			s.Logger().Debug("Unmounting '%s'", target)
+			return s.Mounter().Unmount(target)
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unpack
+
+import (
+	"context"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ArchDetector is implemented by unpackers able to tell the architecture of the image without unpacking it
+type ArchDetector interface {
+	Arch(ctx context.Context) (string, error)
+}
+
+// rootArchBinaries are the binaries of a root tree used to find out its architecture
+var rootArchBinaries = []string{
+	"/usr/lib/systemd/systemd",
+	"/usr/bin/bash",
+}
+
+// RootArch returns the architecture of the given root tree based on the ELF header of its binaries
+func RootArch(s *sys.System, root string) (string, error) {
+	for _, bin := range rootArchBinaries {
+		path := filepath.Join(root, bin)
+		if ok, _ := vfs.Exists(s.FS(), path); !ok {
+			continue
+		}
+		return elfArch(s, path)
+	}
+	return "", fmt.Errorf("none of %v found in '%s'", rootArchBinaries, root)
+}
+
+func elfArch(s *sys.System, path string) (string, error) {
+	f, err := s.FS().Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	r, ok := f.(io.ReaderAt)
+	if !ok {
+		return "", errors.New("file does not support random access")
+	}
+	e, err := elf.NewFile(r)
+	if err != nil {
+		return "", fmt.Errorf("parsing ELF header of '%s': %w", path, err)
+	}
+
+	switch e.Machine {
+	case elf.EM_X86_64:
+		return platform.Archx86, nil
+	case elf.EM_AARCH64:
+		return platform.ArchAarch64, nil
+	case elf.EM_RISCV:
+		return platform.ArchRiscv64, nil
+	default:
+		return "", fmt.Errorf("unsupported machine '%s' in '%s'", e.Machine, path)
+	}
+}
@@ -64,6 +64,11 @@ func (d Directory) SynchedUnpack(ctx context.Context, destination string, exclud
 	return digest, sync.MirrorData(d.path, destination, excludes, deleteExcludes)
 }
 
+// Arch returns the architecture of the root tree in the directory
+func (d Directory) Arch(_ context.Context) (string, error) {
+	return RootArch(d.s, d.path)
+}
+
 // findDeploymentDigest attempts to read a deployment file from the source directory tree
 // and read the source digest if any. This is helpful to get the original image digest
 // if the source is already a deployment.
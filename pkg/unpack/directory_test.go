@@ -18,7 +18,10 @@ limitations under the License.
 package unpack_test
 
 import (
+	"bytes"
 	"context"
+	"debug/elf"
+	"encoding/binary"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -77,6 +80,24 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 		ok, _ = vfs.Exists(tfs, "/target/dir/pre-existing-file")
 		Expect(ok).To(BeFalse())
 	})
+	It("detects the architecture of the source tree", func() {
+		_, err := unpacker.Arch(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("none of")))
+
+		h := elf.Header64{Machine: uint16(elf.EM_AARCH64), Version: uint32(elf.EV_CURRENT), Ehsize: 64}
+		copy(h.Ident[:], elf.ELFMAG)
+		h.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+		h.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+		h.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+		var bin bytes.Buffer
+		Expect(binary.Write(&bin, binary.LittleEndian, h)).To(Succeed())
+		Expect(vfs.MkdirAll(tfs, "/some/root/usr/bin", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/some/root/usr/bin/bash", bin.Bytes(), vfs.FilePerm)).To(Succeed())
+
+		arch, err := unpacker.Arch(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(arch).To(Equal("aarch64"))
+	})
 	It("reads the deployment data from the source tree", func() {
 		d := deployment.DefaultDeployment()
 		d.SourceOS = deployment.NewOCISrc("domain.org/image:tag")
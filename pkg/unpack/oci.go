@@ -119,27 +119,7 @@ func (o OCI) SynchedUnpack(ctx context.Context, destination string, excludes []s
 }
 
 func (o OCI) Unpack(ctx context.Context, destination string, excludes ...string) (string, error) {
-	platform, err := containerregistry.ParsePlatform(o.platformRef)
-	if err != nil {
-		return "", err
-	}
-
-	opts := []name.Option{}
-	if !o.verify {
-		opts = append(opts, name.Insecure)
-	}
-
-	ref, err := name.ParseReference(o.imageRef, opts...)
-	if err != nil {
-		return "", err
-	}
-
-	var img containerregistry.Image
-
-	err = backoff.Retry(func() error {
-		img, err = fetchImage(ctx, ref, *platform, o.local)
-		return err
-	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(3*time.Second), 3))
+	img, err := o.image(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -168,6 +148,47 @@ func (o OCI) Unpack(ctx context.Context, destination string, excludes ...string)
 	return digest.String(), err
 }
 
+// Arch returns the architecture set in the image configuration, without extracting any layer
+func (o OCI) Arch(ctx context.Context) (string, error) {
+	img, err := o.image(ctx)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Architecture, nil
+}
+
+func (o OCI) image(ctx context.Context) (containerregistry.Image, error) {
+	platform, err := containerregistry.ParsePlatform(o.platformRef)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []name.Option{}
+	if !o.verify {
+		opts = append(opts, name.Insecure)
+	}
+
+	ref, err := name.ParseReference(o.imageRef, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var img containerregistry.Image
+
+	err = backoff.Retry(func() error {
+		img, err = fetchImage(ctx, ref, *platform, o.local)
+		return err
+	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(3*time.Second), 3))
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
 func fetchImage(ctx context.Context, ref name.Reference, platform containerregistry.Platform, local bool) (containerregistry.Image, error) {
 	if local {
 		return daemon.Image(ref, daemon.WithContext(ctx))
@@ -86,6 +86,22 @@ func (r Raw) SynchedUnpack(ctx context.Context, destination string, excludes []s
 	return unpackD.SynchedUnpack(ctx, destination, excludes, deleteExcludes)
 }
 
+// Arch returns the architecture of the root tree in the raw image
+func (r Raw) Arch(_ context.Context) (arch string, err error) {
+	mountpoint, umount, err := r.mountImage()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		nErr := umount()
+		if err == nil && nErr != nil {
+			err = nErr
+		}
+	}()
+
+	return RootArch(r.s, mountpoint)
+}
+
 func (r Raw) mountImage() (string, umountFunc, error) {
 	dir, err := vfs.TempDir(r.s.FS(), "", "elemental_unpack")
 	if err != nil {
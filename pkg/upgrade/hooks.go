@@ -0,0 +1,88 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/chroot"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+const hookFile = "/etc/elemental/hook.sh"
+
+// runHooks executes, in order, all the deployment hooks of the given phase
+func (u Upgrader) runHooks(d *deployment.Deployment, phase deployment.HookPhase, trans *transaction.Transaction) error {
+	for _, hook := range d.GetHooks(phase) {
+		u.s.Logger().Info("Running %s hook '%s'", phase, hook.Script)
+		err := u.runHook(hook, d.SourceOS.GetDigest(), trans)
+		if err != nil {
+			return fmt.Errorf("running %s hook '%s': %w", phase, hook.Script, err)
+		}
+	}
+	return nil
+}
+
+// runHook executes the given hook including the transaction data as environment variables
+func (u Upgrader) runHook(hook deployment.Hook, digest string, trans *transaction.Transaction) error {
+	ctx := u.ctx
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(u.ctx, time.Duration(hook.Timeout))
+		defer cancel()
+	}
+
+	script, transPath := hook.Script, trans.Path
+	if hook.Chroot {
+		script, transPath = hookFile, "/"
+	}
+	args := []string{
+		fmt.Sprintf("ELEMENTAL_HOOK_PHASE=%s", hook.Phase),
+		fmt.Sprintf("ELEMENTAL_TRANSACTION_ID=%d", trans.ID),
+		fmt.Sprintf("ELEMENTAL_TRANSACTION_PATH=%s", transPath),
+		fmt.Sprintf("ELEMENTAL_SOURCE_DIGEST=%s", digest),
+		script,
+	}
+
+	callback := func() error {
+		var stdOut, stdErr string
+		defer func() {
+			logOutput(u.s, stdOut, stdErr)
+		}()
+		err := u.s.Runner().RunContextParseOutput(ctx, stdHandler(&stdOut), stdHandler(&stdErr), "env", args...)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s: %w", time.Duration(hook.Timeout), ctx.Err())
+		}
+		return err
+	}
+
+	if !hook.Chroot {
+		return callback()
+	}
+	err := vfs.MkdirAll(u.s.FS(), filepath.Join(trans.Path, filepath.Dir(hookFile)), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating hook directory: %w", err)
+	}
+	return chroot.ChrootedCallback(u.s, trans.Path, map[string]string{hook.Script: hookFile}, callback)
+}
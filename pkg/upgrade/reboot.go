@@ -0,0 +1,134 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+// RebootMode sets how the system is rebooted into the new snapshot once the upgrade is committed
+type RebootMode string
+
+const (
+	NoReboot RebootMode = ""
+	// KexecReboot boots the kernel of the new snapshot skipping the firmware initialization
+	KexecReboot RebootMode = "kexec"
+	// SoftReboot only restarts the userspace, it requires the new snapshot to ship the running kernel
+	SoftReboot RebootMode = "soft"
+)
+
+const (
+	osReleaseFile = "/proc/sys/kernel/osrelease"
+	// nextRootPath is where systemd looks for the root to switch to on a soft reboot
+	nextRootPath = "/run/nextroot"
+)
+
+// WithReboot sets the system to be rebooted into the new snapshot after a successful upgrade
+func WithReboot(mode RebootMode) Option {
+	return func(u *Upgrader) {
+		u.reboot = mode
+	}
+}
+
+// prepareReboot sets everything required to reboot into the given transaction and returns the reboot
+// mode to use. Soft reboots fall back to kexec if the kernel of the new snapshot differs from the running
+// one, otherwise the new snapshot is mounted at /run/nextroot for systemd to switch into it. The kernel for
+// kexec reboots is loaded in advance, as the transaction path might not be available once committed.
+func (u Upgrader) prepareReboot(trans *transaction.Transaction, kernelCmdline string) (RebootMode, error) {
+	if u.reboot == NoReboot {
+		return NoReboot, nil
+	}
+
+	kernel, version, err := vfs.FindKernel(u.s.FS(), trans.Path)
+	if err != nil {
+		return NoReboot, fmt.Errorf("finding kernel: %w", err)
+	}
+
+	if u.reboot == SoftReboot {
+		running, err := u.s.FS().ReadFile(osReleaseFile)
+		if err != nil {
+			return NoReboot, fmt.Errorf("reading running kernel version: %w", err)
+		}
+		if strings.TrimSpace(string(running)) == version {
+			// the snapshot is bind mounted, so it stays available once the transaction path is released
+			err = vfs.MkdirAll(u.s.FS(), nextRootPath, vfs.DirPerm)
+			if err != nil {
+				return NoReboot, fmt.Errorf("creating '%s': %w", nextRootPath, err)
+			}
+			err = u.s.Mounter().Mount(trans.Path, nextRootPath, "", []string{"bind"})
+			if err != nil {
+				return NoReboot, fmt.Errorf("mounting new snapshot at '%s': %w", nextRootPath, err)
+			}
+			return SoftReboot, nil
+		}
+		u.s.Logger().Warn(
+			"Kernel changed from '%s' to '%s', a soft reboot is not possible, using kexec instead",
+			strings.TrimSpace(string(running)), version,
+		)
+	}
+
+	u.s.Logger().Info("Loading kernel '%s' for kexec", version)
+	initrd := filepath.Join(filepath.Dir(kernel), bootloader.Initrd)
+	_, err = u.s.Runner().Run("kexec", "--load", kernel, "--initrd", initrd, "--append", kernelCmdline)
+	if err != nil {
+		return NoReboot, fmt.Errorf("loading kernel for kexec: %w", err)
+	}
+	return KexecReboot, nil
+}
+
+// cancelReboot unloads any kernel loaded for kexec or unmounts the root prepared for a soft reboot
+func (u Upgrader) cancelReboot(mode RebootMode) {
+	switch mode {
+	case KexecReboot:
+		_, err := u.s.Runner().Run("kexec", "--unload")
+		if err != nil {
+			u.s.Logger().Warn("Failed unloading kexec kernel: %v", err)
+		}
+	case SoftReboot:
+		err := u.s.Mounter().Unmount(nextRootPath)
+		if err != nil {
+			u.s.Logger().Warn("Failed unmounting '%s': %v", nextRootPath, err)
+		}
+	}
+}
+
+// rebootInto reboots the system with the given mode, systemd takes care of stopping the
+// services before jumping into the new kernel or userspace
+func (u Upgrader) rebootInto(mode RebootMode) error {
+	var err error
+	switch mode {
+	case KexecReboot:
+		u.s.Logger().Info("Rebooting into the new snapshot with kexec")
+		_, err = u.s.Runner().Run("systemctl", "kexec")
+	case SoftReboot:
+		u.s.Logger().Info("Soft rebooting into the new snapshot")
+		_, err = u.s.Runner().Run("systemctl", "soft-reboot")
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("rebooting into the new snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/journal"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+// Recover reverts any transaction left unfinished by an interrupted upgrade, this includes
+// deleting its snapshot and restoring the boot entries. Transactions which were already
+// committed are kept, as well as staged transactions whose activation was interrupted.
+func (u Upgrader) Recover(d *deployment.Deployment) error {
+	_, err := u.t.Init(*d)
+	if err != nil {
+		return fmt.Errorf("initializing transaction: %w", err)
+	}
+	return u.recover(d)
+}
+
+func (u Upgrader) recover(d *deployment.Deployment) error {
+	if u.j == nil {
+		return nil
+	}
+	rec, err := u.j.Load()
+	if err != nil {
+		return err
+	} else if rec == nil {
+		return nil
+	}
+
+	u.s.Logger().Warn(
+		"Found transaction '%d' started at %s and interrupted after the '%s' phase",
+		rec.TransactionID, rec.Started.Format("2006-01-02 15:04:05"), rec.LastPhase(),
+	)
+
+	staged, err := u.GetStaged()
+	if err != nil {
+		return err
+	}
+	isStaged := staged != nil && staged.TransactionID == rec.TransactionID
+
+	var committed bool
+	if r, ok := u.t.(transaction.Recoverer); ok {
+		committed, err = r.Recover(&transaction.Transaction{ID: rec.TransactionID, Path: rec.Path}, isStaged)
+		if err != nil {
+			return fmt.Errorf("reverting transaction '%d': %w", rec.TransactionID, err)
+		}
+	}
+
+	switch {
+	case committed:
+		u.s.Logger().Warn("Transaction '%d' was already committed, keeping it", rec.TransactionID)
+		// an interrupted activation of a staged upgrade which already committed it
+		if isStaged {
+			err = u.removeStaged()
+			if err != nil {
+				return err
+			}
+			err = u.unscheduleActivation()
+			if err != nil {
+				return err
+			}
+		}
+	case isStaged:
+		err = u.j.Restore()
+		if err != nil {
+			return err
+		}
+		u.s.Logger().Warn("Activation of transaction '%d' rolled back, it is kept staged", rec.TransactionID)
+	default:
+		err = u.j.Restore()
+		if err != nil {
+			return err
+		}
+		err = u.pruneBootEntries(d)
+		if err != nil {
+			return err
+		}
+		u.s.Logger().Warn("Transaction '%d' rolled back", rec.TransactionID)
+	}
+	return u.j.Finish()
+}
+
+// pruneBootEntries removes the restored boot entries of systems which are no longer available, as
+// the A/B slot rewritten by the interrupted transaction.
+func (u Upgrader) pruneBootEntries(d *deployment.Deployment) error {
+	esp := d.GetEfiPartition()
+	if esp == nil {
+		return nil
+	}
+	ids, err := u.t.GetActiveSnapshotIDs()
+	if err != nil {
+		return fmt.Errorf("get active snapshots: %w", err)
+	}
+	err = u.b.Prune("/", esp.MountPoint, ids)
+	if err != nil {
+		return fmt.Errorf("pruning boot entries: %w", err)
+	}
+	return nil
+}
+
+func (u Upgrader) beginJournal(trans *transaction.Transaction) error {
+	if u.j == nil {
+		return nil
+	}
+	return u.j.Begin(trans.ID, trans.Path)
+}
+
+func (u Upgrader) recordPhase(phase journal.Phase) error {
+	if u.j == nil {
+		return nil
+	}
+	err := u.j.Record(phase)
+	if err != nil {
+		return fmt.Errorf("recording phase '%s' in journal: %w", phase, err)
+	}
+	return nil
+}
+
+// backupBootState stores a copy of the boot entries state before installing the bootloader
+func (u Upgrader) backupBootState(espDir string) error {
+	if u.j == nil {
+		return nil
+	}
+	for _, file := range bootloader.StateFiles(espDir) {
+		err := u.j.Backup(file)
+		if err != nil {
+			return fmt.Errorf("backing up boot state: %w", err)
+		}
+	}
+	return nil
+}
+
+// revertJournal restores the backed up files and closes the journal of a failed transaction
+func (u Upgrader) revertJournal() error {
+	if u.j == nil {
+		return nil
+	}
+	err := u.j.Restore()
+	if err != nil {
+		return err
+	}
+	return u.j.Finish()
+}
+
+func (u Upgrader) finishJournal() {
+	if u.j == nil {
+		return
+	}
+	err := u.j.Finish()
+	if err != nil {
+		u.s.Logger().Warn("Failed closing transaction journal: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// ActivationUnit is the name of the systemd timer and service activating a staged upgrade
+	ActivationUnit = "elemental-upgrade-activate"
+	// SystemdUnitsDir is where the activation units are installed, so they persist across reboots
+	SystemdUnitsDir = "/etc/systemd/system"
+)
+
+// activationService runs the activation only while there is a staged upgrade
+const activationService = `[Unit]
+Description=Activate the staged Elemental upgrade
+ConditionPathExists=%s
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`
+
+// activationTimer is persistent, so an activation missed while the system was down runs on the next boot
+const activationTimer = `[Unit]
+Description=Scheduled activation of the staged Elemental upgrade
+
+[Timer]
+OnCalendar=%s
+AccuracySec=1s
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`
+
+// ScheduleActivation installs and enables a systemd timer which activates the staged upgrade at
+// the given calendar time by running the given command. The timer is removed once the staged
+// upgrade is activated or discarded.
+func (u Upgrader) ScheduleActivation(at string, command ...string) error {
+	staged, err := u.GetStaged()
+	if err != nil {
+		return err
+	} else if staged == nil {
+		return fmt.Errorf("no staged upgrade found")
+	}
+
+	out, err := u.s.Runner().Run("systemd-analyze", "calendar", at)
+	if err != nil {
+		return fmt.Errorf("invalid activation time '%s': %s: %w", at, strings.TrimSpace(string(out)), err)
+	}
+
+	err = vfs.MkdirAll(u.s.FS(), SystemdUnitsDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd units directory: %w", err)
+	}
+	service := fmt.Sprintf(activationService, StagedFile, strings.Join(command, " "))
+	err = u.s.FS().WriteFile(activationUnitPath(".service"), []byte(service), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing activation service: %w", err)
+	}
+	timer := fmt.Sprintf(activationTimer, at)
+	err = u.s.FS().WriteFile(activationUnitPath(".timer"), []byte(timer), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing activation timer: %w", err)
+	}
+
+	_, err = u.s.Runner().Run("systemctl", "daemon-reload")
+	if err != nil {
+		return fmt.Errorf("reloading systemd units: %w", err)
+	}
+	_, err = u.s.Runner().Run("systemctl", "enable", "--now", ActivationUnit+".timer")
+	if err != nil {
+		return fmt.Errorf("enabling activation timer: %w", err)
+	}
+	u.s.Logger().Info("Activation of staged transaction '%d' scheduled at '%s'", staged.TransactionID, at)
+	return nil
+}
+
+// unscheduleActivation disables and removes the activation timer, if any
+func (u Upgrader) unscheduleActivation() error {
+	timer := activationUnitPath(".timer")
+	if ok, _ := vfs.Exists(u.s.FS(), timer); !ok {
+		return nil
+	}
+
+	_, err := u.s.Runner().Run("systemctl", "disable", "--now", ActivationUnit+".timer")
+	if err != nil {
+		return fmt.Errorf("disabling activation timer: %w", err)
+	}
+	for _, unit := range []string{timer, activationUnitPath(".service")} {
+		err = u.s.FS().RemoveAll(unit)
+		if err != nil {
+			return fmt.Errorf("removing activation unit '%s': %w", unit, err)
+		}
+	}
+	_, err = u.s.Runner().Run("systemctl", "daemon-reload")
+	if err != nil {
+		return fmt.Errorf("reloading systemd units: %w", err)
+	}
+	return nil
+}
+
+func activationUnitPath(suffix string) string {
+	return filepath.Join(SystemdUnitsDir, ActivationUnit+suffix)
+}
@@ -0,0 +1,270 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+// StagedFile stores the staged upgrade pending to be activated, it must be persistent
+// across reboots and not part of any snapshot.
+const StagedFile = "/var/lib/elemental/staged.yaml"
+
+// Staged describes an upgrade prepared but not activated yet
+type Staged struct {
+	TransactionID int       `yaml:"transactionID"`
+	Path          string    `yaml:"path"`
+	Source        string    `yaml:"source"`
+	Digest        string    `yaml:"digest,omitempty"`
+	Date          time.Time `yaml:"date"`
+}
+
+// GetStaged returns the staged upgrade, if any
+func (u Upgrader) GetStaged() (*Staged, error) {
+	data, err := u.s.FS().ReadFile(StagedFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading staged upgrade: %w", err)
+	}
+
+	staged := &Staged{}
+	err = yaml.Unmarshal(data, staged)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling staged upgrade: %w", err)
+	}
+	return staged, nil
+}
+
+// Stage prepares the new snapshot for the given deployment without activating it. The
+// current system keeps being booted by default until the staged upgrade is activated.
+func (u Upgrader) Stage(d *deployment.Deployment) (err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	stager, ok := u.t.(transaction.Stager)
+	if !ok {
+		return fmt.Errorf("staged upgrades are not supported by the current snapshotter")
+	}
+
+	if d.GetEfiPartition() == nil {
+		return fmt.Errorf("no EFI partition defined in deployment")
+	}
+
+	uh, err := u.t.Init(*d)
+	if err != nil {
+		return fmt.Errorf("initializing transaction: %w", err)
+	}
+
+	err = u.recover(d)
+	if err != nil {
+		return fmt.Errorf("recovering interrupted transaction: %w", err)
+	}
+
+	staged, err := u.GetStaged()
+	if err != nil {
+		return err
+	} else if staged != nil {
+		return fmt.Errorf("transaction '%d' is already staged, discard it first", staged.TransactionID)
+	}
+
+	trans, err := u.t.Start()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	cleanup.PushErrorOnly(func() error { return u.t.Rollback(trans, err) })
+	cleanup.PushErrorOnly(func() error { return u.runHooks(d, deployment.OnRollback, trans) })
+
+	err = u.beginJournal(trans)
+	if err != nil {
+		return fmt.Errorf("starting transaction journal: %w", err)
+	}
+	cleanup.PushErrorOnly(u.revertJournal)
+
+	err = u.prepare(d, uh, trans)
+	if err != nil {
+		return err
+	}
+
+	err = stager.Stage(trans)
+	if err != nil {
+		return fmt.Errorf("staging transaction: %w", err)
+	}
+
+	err = u.writeStaged(&Staged{
+		TransactionID: trans.ID,
+		Path:          trans.Path,
+		Source:        d.SourceOS.String(),
+		Digest:        d.SourceOS.GetDigest(),
+		Date:          time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	u.finishJournal()
+	u.reportConflicts(trans)
+	u.s.Logger().Info("Transaction '%d' staged, it will be booted once activated", trans.ID)
+	return nil
+}
+
+// Activate commits the staged upgrade, so its snapshot is booted by default. The boot
+// configuration is taken from the deployment of the staged snapshot. The given deployment
+// describes the current system.
+func (u Upgrader) Activate(d *deployment.Deployment) error {
+	mode, err := u.activateStaged(d)
+	if err != nil {
+		return err
+	}
+	return u.rebootInto(mode)
+}
+
+func (u Upgrader) activateStaged(d *deployment.Deployment) (mode RebootMode, err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	stager, ok := u.t.(transaction.Stager)
+	if !ok {
+		return NoReboot, fmt.Errorf("staged upgrades are not supported by the current snapshotter")
+	}
+
+	uh, err := u.t.Init(*d)
+	if err != nil {
+		return NoReboot, fmt.Errorf("initializing transaction: %w", err)
+	}
+
+	err = u.recover(d)
+	if err != nil {
+		return NoReboot, fmt.Errorf("recovering interrupted transaction: %w", err)
+	}
+
+	staged, err := u.GetStaged()
+	if err != nil {
+		return NoReboot, err
+	} else if staged == nil {
+		return NoReboot, fmt.Errorf("no staged upgrade found")
+	}
+
+	trans, err := stager.Resume(staged.TransactionID, staged.Path)
+	if err != nil {
+		return NoReboot, fmt.Errorf("resuming staged transaction '%d': %w", staged.TransactionID, err)
+	}
+	// a failed activation keeps the snapshot staged, so it can be activated again
+	cleanup.PushErrorOnly(func() error { return stager.Stage(trans) })
+
+	stagedD, err := deployment.Parse(u.s, trans.Path)
+	if err != nil {
+		return NoReboot, fmt.Errorf("parsing staged deployment: %w", err)
+	} else if stagedD == nil {
+		stagedD = d
+	}
+
+	err = u.beginJournal(trans)
+	if err != nil {
+		return NoReboot, fmt.Errorf("starting transaction journal: %w", err)
+	}
+	cleanup.PushErrorOnly(u.revertJournal)
+
+	mode, err = u.activate(stagedD, uh, trans)
+	if err != nil {
+		return NoReboot, err
+	}
+
+	err = u.removeStaged()
+	if err != nil {
+		u.s.Logger().Warn("Failed removing staged upgrade record: %v", err)
+	}
+	err = u.unscheduleActivation()
+	if err != nil {
+		u.s.Logger().Warn("Failed removing scheduled activation: %v", err)
+	}
+
+	u.finishJournal()
+	u.s.Logger().Info("Staged transaction '%d' activated", trans.ID)
+	return mode, nil
+}
+
+// Discard deletes the staged upgrade
+func (u Upgrader) Discard(d *deployment.Deployment) error {
+	stager, ok := u.t.(transaction.Stager)
+	if !ok {
+		return fmt.Errorf("staged upgrades are not supported by the current snapshotter")
+	}
+
+	_, err := u.t.Init(*d)
+	if err != nil {
+		return fmt.Errorf("initializing transaction: %w", err)
+	}
+
+	staged, err := u.GetStaged()
+	if err != nil {
+		return err
+	} else if staged == nil {
+		return fmt.Errorf("no staged upgrade found")
+	}
+
+	err = stager.Discard(&transaction.Transaction{ID: staged.TransactionID, Path: staged.Path})
+	if err != nil {
+		return fmt.Errorf("discarding staged transaction '%d': %w", staged.TransactionID, err)
+	}
+
+	err = u.removeStaged()
+	if err != nil {
+		return err
+	}
+	err = u.unscheduleActivation()
+	if err != nil {
+		return err
+	}
+	u.s.Logger().Info("Staged transaction '%d' discarded", staged.TransactionID)
+	return nil
+}
+
+func (u Upgrader) writeStaged(staged *Staged) error {
+	data, err := yaml.Marshal(staged)
+	if err != nil {
+		return fmt.Errorf("marshalling staged upgrade: %w", err)
+	}
+	err = vfs.MkdirAll(u.s.FS(), filepath.Dir(StagedFile), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating staged upgrade directory: %w", err)
+	}
+	err = u.s.FS().WriteFile(StagedFile, data, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing staged upgrade: %w", err)
+	}
+	return nil
+}
+
+func (u Upgrader) removeStaged() error {
+	err := u.s.FS().Remove(StagedFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing staged upgrade: %w", err)
+	}
+	return nil
+}
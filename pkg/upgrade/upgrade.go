@@ -30,6 +30,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/journal"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/selinux"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -51,7 +52,10 @@ type Upgrader struct {
 	t          transaction.Interface
 	bm         *firmware.EfiBootManager
 	b          bootloader.Bootloader
+	j          *journal.Journal
+	reboot     RebootMode
 	unpackOpts []unpack.Opt
+	postSync   func(root string) error
 }
 
 func WithTransaction(t transaction.Interface) Option {
@@ -78,12 +82,28 @@ func WithSnapshotter(s transaction.Interface) Option {
 	}
 }
 
+// WithJournal sets the journal to record the progress of the upgrade, so it can be
+// recovered if it gets interrupted
+func WithJournal(j *journal.Journal) Option {
+	return func(u *Upgrader) {
+		u.j = j
+	}
+}
+
 func WithUnpackOpts(opts ...unpack.Opt) Option {
 	return func(u *Upgrader) {
 		u.unpackOpts = opts
 	}
 }
 
+// WithPostSyncFunc sets a function called with the root of the new snapshot once the OS image is
+// synced, before any hook, overlay or configuration script modifies it
+func WithPostSyncFunc(f func(root string) error) Option {
+	return func(u *Upgrader) {
+		u.postSync = f
+	}
+}
+
 func New(ctx context.Context, s *sys.System, opts ...Option) *Upgrader {
 	up := &Upgrader{
 		s:   s,
@@ -101,8 +121,15 @@ func New(ctx context.Context, s *sys.System, opts ...Option) *Upgrader {
 	return up
 }
 
-//nolint:gocyclo
-func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
+func (u Upgrader) Upgrade(d *deployment.Deployment) error {
+	mode, err := u.upgrade(d)
+	if err != nil {
+		return err
+	}
+	return u.rebootInto(mode)
+}
+
+func (u Upgrader) upgrade(d *deployment.Deployment) (mode RebootMode, err error) {
 	cleanup := cleanstack.NewCleanStack()
 	defer func() { err = cleanup.Cleanup(err) }()
 
@@ -110,30 +137,102 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 
 	esp := d.GetEfiPartition()
 	if esp == nil {
-		return fmt.Errorf("no EFI partition defined in deployment")
+		return NoReboot, fmt.Errorf("no EFI partition defined in deployment")
 	}
 
 	uh, err = u.t.Init(*d)
 	if err != nil {
-		return fmt.Errorf("initializing transaction: %w", err)
+		return NoReboot, fmt.Errorf("initializing transaction: %w", err)
+	}
+
+	err = u.recover(d)
+	if err != nil {
+		return NoReboot, fmt.Errorf("recovering interrupted transaction: %w", err)
+	}
+
+	// a staged snapshot activated after this upgrade would roll the system back
+	staged, err := u.GetStaged()
+	if err != nil {
+		return NoReboot, err
+	} else if staged != nil {
+		return NoReboot, fmt.Errorf("transaction '%d' is staged, activate or discard it first", staged.TransactionID)
 	}
 
 	trans, err := u.t.Start()
 	if err != nil {
-		return fmt.Errorf("starting transaction: %w", err)
+		return NoReboot, fmt.Errorf("starting transaction: %w", err)
 	}
 	cleanup.PushErrorOnly(func() error { return u.t.Rollback(trans, err) })
+	cleanup.PushErrorOnly(func() error { return u.runHooks(d, deployment.OnRollback, trans) })
+
+	err = u.beginJournal(trans)
+	if err != nil {
+		return NoReboot, fmt.Errorf("starting transaction journal: %w", err)
+	}
+	cleanup.PushErrorOnly(u.revertJournal)
+
+	err = u.prepare(d, uh, trans)
+	if err != nil {
+		return NoReboot, err
+	}
+
+	mode, err = u.activate(d, uh, trans)
+	if err != nil {
+		return NoReboot, err
+	}
+
+	u.finishJournal()
+	u.reportConflicts(trans)
+	return mode, nil
+}
+
+// prepare populates the snapshot of the given in progress transaction with the new OS image.
+// Once prepared the snapshot is locked and ready to be activated.
+//
+//nolint:gocyclo
+func (u Upgrader) prepare(d *deployment.Deployment, uh transaction.UpgradeHelper, trans *transaction.Transaction) error {
+	err := u.runHooks(d, deployment.PreSync, trans)
+	if err != nil {
+		return err
+	}
 
 	err = uh.SyncImageContent(d.SourceOS, trans, u.unpackOpts...)
 	if err != nil {
 		return fmt.Errorf("syncing OS image content: %w", err)
 	}
 
+	err = u.recordPhase(journal.Synced)
+	if err != nil {
+		return err
+	}
+
+	if u.postSync != nil {
+		err = u.postSync(trans.Path)
+		if err != nil {
+			return fmt.Errorf("processing synced OS image: %w", err)
+		}
+	}
+
+	err = u.runHooks(d, deployment.PostSync, trans)
+	if err != nil {
+		return err
+	}
+
 	err = uh.Merge(trans)
 	if err != nil {
 		return fmt.Errorf("merging RW volumes: %w", err)
 	}
 
+	err = u.recordPhase(journal.Merged)
+	if err != nil {
+		return err
+	}
+
+	err = u.runHooks(d, deployment.PostMerge, trans)
+	if err != nil {
+		return err
+	}
+
 	err = uh.UpdateFstab(trans)
 	if err != nil {
 		return fmt.Errorf("updating fstab: %w", err)
@@ -161,6 +260,11 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("locking transaction '%d': %w", trans.ID, err)
 	}
 
+	err = u.recordPhase(journal.Locked)
+	if err != nil {
+		return err
+	}
+
 	if d.OverlayTree != nil && !d.OverlayTree.IsEmpty() {
 		unpacker, err := unpack.NewUnpacker(
 			u.s, d.OverlayTree, unpack.WithRsyncFlags(rsync.OverlayTreeSyncFlags()...),
@@ -180,6 +284,16 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 			return fmt.Errorf("executing configuration hook: %w", err)
 		}
 	}
+	return nil
+}
+
+// activate installs the bootloader for the given prepared transaction and commits it, so
+// the new snapshot is booted by default.
+func (u Upgrader) activate(d *deployment.Deployment, uh transaction.UpgradeHelper, trans *transaction.Transaction) (RebootMode, error) {
+	esp := d.GetEfiPartition()
+	if esp == nil {
+		return NoReboot, fmt.Errorf("no EFI partition defined in deployment")
+	}
 
 	cmdline := ""
 	if d.BootConfig != nil {
@@ -192,34 +306,73 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		recKernelCmdline = strings.TrimSpace(fmt.Sprintf("%s %s", d.RecoveryKernelCmdline(), d.Installer.KernelCmdline))
 	}
 
+	err := u.runHooks(d, deployment.PreBootloader, trans)
+	if err != nil {
+		return NoReboot, err
+	}
+
+	// the boot state is backed up from the host ESP mountpoint, as the transaction
+	// mountpoints are gone if the upgrade needs to be recovered after a reboot
+	err = u.backupBootState(esp.MountPoint)
+	if err != nil {
+		return NoReboot, err
+	}
+
 	espDir := filepath.Join(trans.Path, esp.MountPoint)
+
 	err = u.b.Install(trans.Path, espDir, esp.Label, strconv.Itoa(trans.ID), kernelCmdline, recKernelCmdline)
 	if err != nil {
-		return fmt.Errorf("installing bootloader: %w", err)
+		return NoReboot, fmt.Errorf("installing bootloader: %w", err)
+	}
+
+	err = u.recordPhase(journal.Bootloader)
+	if err != nil {
+		return NoReboot, err
 	}
 
 	if d.Firmware != nil {
 		err = u.bm.CreateBootEntries(d.Firmware.BootEntries)
 		if err != nil {
-			return fmt.Errorf("creating EFI boot entries: %w", err)
+			return NoReboot, fmt.Errorf("creating EFI boot entries: %w", err)
 		}
 	}
 
+	mode, err := u.prepareReboot(trans, kernelCmdline)
+	if err != nil {
+		return NoReboot, err
+	}
+
 	commitCleanup := func() error {
 		snapshots, err := u.t.GetActiveSnapshotIDs()
 		if err != nil {
 			return fmt.Errorf("get active snapshots: %w", err)
 		}
 
-		return u.b.Prune(trans.Path, filepath.Join(trans.Path, esp.MountPoint), snapshots)
+		err = u.b.Prune(trans.Path, filepath.Join(trans.Path, esp.MountPoint), snapshots)
+		if err != nil {
+			return err
+		}
+
+		return u.runHooks(d, deployment.PostCommit, trans)
 	}
 
 	err = u.t.Commit(trans, commitCleanup)
 	if err != nil {
-		return fmt.Errorf("committing transaction: %w", err)
+		u.cancelReboot(mode)
+		return NoReboot, fmt.Errorf("committing transaction: %w", err)
 	}
+	return mode, nil
+}
 
-	return nil
+// reportConflicts prints the merge conflicts of the given transaction, if any
+func (u Upgrader) reportConflicts(trans *transaction.Transaction) {
+	if len(trans.Conflicts) == 0 {
+		return
+	}
+	u.s.Logger().Warn("%d merge conflicts found, report stored at '%s':", len(trans.Conflicts), transaction.ConflictsReport)
+	for _, c := range trans.Conflicts {
+		u.s.Logger().Warn("  %s: %s", c.Path, c.Policy)
+	}
 }
 
 func (u Upgrader) configHook(config string, root string) error {
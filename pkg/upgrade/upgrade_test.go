@@ -20,6 +20,7 @@ package upgrade_test
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -28,6 +29,7 @@ import (
 
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/journal"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
@@ -67,6 +69,7 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 			"/snapshot/path/empty":   []byte{},
 			"/opt/overlaytree/empty": []byte{},
 			"/opt/config.sh":         []byte{},
+			"/opt/migrate.sh":        []byte{},
 		})
 		Expect(err).ToNot(HaveOccurred())
 		s, err = sys.NewSystem(
@@ -122,6 +125,28 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		Expect(err).To(MatchError("syncing OS image content: failed sync"))
 		Expect(t.RollbackCalled()).To(BeTrue())
 	})
+	It("calls the post sync function with the synced snapshot", func() {
+		var root string
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithPostSyncFunc(func(path string) error {
+				root = path
+				return nil
+			}),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(root).To(Equal("/snapshot/path"))
+	})
+	It("fails if the post sync function fails", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+			upgrade.WithPostSyncFunc(func(string) error { return fmt.Errorf("post sync failed") }),
+		)
+		Expect(u.Upgrade(d)).To(MatchError("processing synced OS image: post sync failed"))
+		Expect(t.RollbackCalled()).To(BeTrue())
+	})
 	It("fails on image merge", func() {
 		t.UpgradeHelper.MergeError = fmt.Errorf("failed merge")
 		err := u.Upgrade(d)
@@ -181,7 +206,7 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		efiBootMgrCalled := false
 		disk := "/dev/sdz"
 		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
-			if cmd == "efibootmgr" {
+			if cmd == "efibootmgr" && slices.Contains(args, "--create") {
 				Expect(args).To(ContainElement(disk))
 				Expect(args).To(ContainElement("loader"))
 				efiBootMgrCalled = true
@@ -204,4 +229,322 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(efiBootMgrCalled).To(BeTrue())
 	})
+	Describe("hooks", func() {
+		BeforeEach(func() {
+			d.CfgScript = ""
+			d.OverlayTree = nil
+			d.Hooks = []deployment.Hook{
+				{Phase: deployment.PostCommit, Script: "/opt/commit.sh"},
+				{Phase: deployment.PreSync, Script: "/opt/presync.sh"},
+				{Phase: deployment.PostMerge, Script: "/opt/migrate.sh", Chroot: true},
+				{Phase: deployment.OnRollback, Script: "/opt/rollback.sh"},
+				{Phase: deployment.PreBootloader, Script: "/opt/bootloader.sh"},
+				{Phase: deployment.PostSync, Script: "/opt/postsync.sh"},
+			}
+		})
+		It("runs hooks of each phase in order", func() {
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{
+					"env", "ELEMENTAL_HOOK_PHASE=pre-sync", "ELEMENTAL_TRANSACTION_ID=2",
+					"ELEMENTAL_TRANSACTION_PATH=/snapshot/path", "ELEMENTAL_SOURCE_DIGEST=", "/opt/presync.sh",
+				},
+				{
+					"env", "ELEMENTAL_HOOK_PHASE=post-sync", "ELEMENTAL_TRANSACTION_ID=2",
+					"ELEMENTAL_TRANSACTION_PATH=/snapshot/path", "ELEMENTAL_SOURCE_DIGEST=imagedigest", "/opt/postsync.sh",
+				},
+				{
+					"env", "ELEMENTAL_HOOK_PHASE=post-merge", "ELEMENTAL_TRANSACTION_ID=2",
+					"ELEMENTAL_TRANSACTION_PATH=/", "ELEMENTAL_SOURCE_DIGEST=imagedigest", "/etc/elemental/hook.sh",
+				},
+				{"env", "ELEMENTAL_HOOK_PHASE=pre-bootloader"},
+				{"env", "ELEMENTAL_HOOK_PHASE=post-commit"},
+			})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"env", "ELEMENTAL_HOOK_PHASE=on-rollback"}})).NotTo(Succeed())
+		})
+		It("runs rollback hooks if a hook fails", func() {
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "env" && args[len(args)-1] == "/opt/bootloader.sh" {
+					return []byte{}, fmt.Errorf("hook failed")
+				}
+				return []byte{}, nil
+			}
+			err := u.Upgrade(d)
+			Expect(err).To(MatchError("running pre-bootloader hook '/opt/bootloader.sh': hook failed"))
+			Expect(runner.IncludesCmds([][]string{{"env", "ELEMENTAL_HOOK_PHASE=on-rollback"}})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"env", "ELEMENTAL_HOOK_PHASE=post-commit"}})).NotTo(Succeed())
+			Expect(t.RollbackCalled()).To(BeTrue())
+		})
+	})
+	Describe("journal", func() {
+		var j *journal.Journal
+		BeforeEach(func() {
+			Expect(vfs.MkdirAll(fs, "/boot/efi", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/boot/efi/grubenv", []byte("saved_entry=active"), vfs.FilePerm)).To(Succeed())
+			j = journal.New(s, journal.DefaultDir)
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithJournal(j),
+			)
+		})
+		It("closes the journal of a successful upgrade", func() {
+			Expect(u.Upgrade(d)).To(Succeed())
+			rec, err := j.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec).To(BeNil())
+			Expect(t.Recovered()).To(BeNil())
+		})
+		It("closes the journal of a failed upgrade", func() {
+			t.UpgradeHelper.LockError = fmt.Errorf("failed locking")
+			Expect(u.Upgrade(d)).NotTo(Succeed())
+			rec, err := j.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec).To(BeNil())
+		})
+		It("recovers an interrupted transaction before upgrading", func() {
+			Expect(j.Begin(1, "/.snapshots/1/snapshot")).To(Succeed())
+			Expect(j.Backup("/boot/efi/grubenv")).To(Succeed())
+			Expect(j.Record(journal.Bootloader)).To(Succeed())
+			Expect(fs.WriteFile("/boot/efi/grubenv", []byte("saved_entry=interrupted"), vfs.FilePerm)).To(Succeed())
+
+			Expect(u.Recover(d)).To(Succeed())
+			Expect(t.Recovered()).To(Equal(&transaction.Transaction{ID: 1, Path: "/.snapshots/1/snapshot"}))
+			data, err := fs.ReadFile("/boot/efi/grubenv")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("saved_entry=active"))
+			rec, err := j.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec).To(BeNil())
+		})
+		It("keeps the boot state of an interrupted transaction already committed", func() {
+			t.Committed = true
+			Expect(j.Begin(1, "/.snapshots/1/snapshot")).To(Succeed())
+			Expect(j.Backup("/boot/efi/grubenv")).To(Succeed())
+			Expect(fs.WriteFile("/boot/efi/grubenv", []byte("saved_entry=committed"), vfs.FilePerm)).To(Succeed())
+
+			Expect(u.Upgrade(d)).To(Succeed())
+			data, err := fs.ReadFile("/boot/efi/grubenv")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("saved_entry=committed"))
+		})
+		It("keeps a staged upgrade whose activation was interrupted", func() {
+			d.CfgScript = ""
+			d.OverlayTree = nil
+			Expect(u.Stage(d)).To(Succeed())
+			Expect(j.Begin(2, "/snapshot/path")).To(Succeed())
+			Expect(j.Backup("/boot/efi/grubenv")).To(Succeed())
+			Expect(j.Record(journal.Bootloader)).To(Succeed())
+			Expect(fs.WriteFile("/boot/efi/grubenv", []byte("saved_entry=interrupted"), vfs.FilePerm)).To(Succeed())
+
+			Expect(u.Recover(d)).To(Succeed())
+			Expect(t.RecoveredStaged()).To(BeTrue())
+			data, err := fs.ReadFile("/boot/efi/grubenv")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("saved_entry=active"))
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged.TransactionID).To(Equal(2))
+		})
+		It("removes a staged upgrade whose interrupted activation was already committed", func() {
+			d.CfgScript = ""
+			d.OverlayTree = nil
+			Expect(u.Stage(d)).To(Succeed())
+			t.Committed = true
+			Expect(j.Begin(2, "/snapshot/path")).To(Succeed())
+
+			Expect(u.Recover(d)).To(Succeed())
+			Expect(t.RecoveredStaged()).To(BeTrue())
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(BeNil())
+		})
+		It("fails to upgrade if the interrupted transaction can't be recovered", func() {
+			t.RecoverErr = fmt.Errorf("delete failed")
+			Expect(j.Begin(1, "/.snapshots/1/snapshot")).To(Succeed())
+			err := u.Upgrade(d)
+			Expect(err).To(MatchError("recovering interrupted transaction: reverting transaction '1': delete failed"))
+			Expect(t.RollbackCalled()).To(BeFalse())
+		})
+	})
+	Describe("staged upgrades", func() {
+		BeforeEach(func() {
+			d.CfgScript = ""
+			d.OverlayTree = nil
+		})
+		It("stages an upgrade without committing it", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			Expect(t.Staged()).To(BeTrue())
+			Expect(t.RollbackCalled()).To(BeFalse())
+
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged.TransactionID).To(Equal(2))
+			Expect(staged.Path).To(Equal("/snapshot/path"))
+			Expect(staged.Digest).To(Equal("imagedigest"))
+		})
+		It("fails to stage an upgrade if there is already one staged", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			Expect(u.Stage(d)).To(MatchError("transaction '2' is already staged, discard it first"))
+		})
+		It("rolls back the transaction if staging fails", func() {
+			t.StageErr = fmt.Errorf("umount failed")
+			Expect(u.Stage(d)).To(MatchError("staging transaction: umount failed"))
+			Expect(t.RollbackCalled()).To(BeTrue())
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(BeNil())
+		})
+		It("activates a staged upgrade", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			Expect(u.Activate(d)).To(Succeed())
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(BeNil())
+		})
+		It("keeps the upgrade staged if the activation fails", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			t.CommitErr = fmt.Errorf("commit failed")
+			Expect(u.Activate(d)).To(MatchError("committing transaction: commit failed"))
+			Expect(t.RollbackCalled()).To(BeFalse())
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged.TransactionID).To(Equal(2))
+		})
+		It("refuses to upgrade while an upgrade is staged", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			Expect(u.Upgrade(d)).To(MatchError("transaction '2' is staged, activate or discard it first"))
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged.TransactionID).To(Equal(2))
+
+			By("activating the staged upgrade before upgrading again")
+			Expect(u.Activate(d)).To(Succeed())
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(u.Activate(d)).To(MatchError("no staged upgrade found"))
+		})
+		It("fails to activate if there is no staged upgrade", func() {
+			Expect(u.Activate(d)).To(MatchError("no staged upgrade found"))
+		})
+		It("discards a staged upgrade", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			Expect(u.Discard(d)).To(Succeed())
+			Expect(t.Discarded()).To(BeTrue())
+			staged, err := u.GetStaged()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(BeNil())
+		})
+		Describe("scheduled activation", func() {
+			const timer = "/etc/systemd/system/elemental-upgrade-activate.timer"
+			const service = "/etc/systemd/system/elemental-upgrade-activate.service"
+			BeforeEach(func() {
+				Expect(u.Stage(d)).To(Succeed())
+				Expect(u.ScheduleActivation("Sat 02:00", "/usr/bin/elemental3ctl", "upgrade", "--activate")).To(Succeed())
+				Expect(runner.IncludesCmds([][]string{
+					{"systemd-analyze", "calendar", "Sat 02:00"},
+					{"systemctl", "enable", "--now", "elemental-upgrade-activate.timer"},
+				})).To(Succeed())
+				data, err := fs.ReadFile(timer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("Persistent=true\n"))
+				data, err = fs.ReadFile(service)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("ExecStart=/usr/bin/elemental3ctl upgrade --activate\n"))
+				runner.ClearCmds()
+			})
+			It("removes the activation timer once the staged upgrade is activated", func() {
+				Expect(u.Activate(d)).To(Succeed())
+				Expect(runner.IncludesCmds([][]string{
+					{"systemctl", "disable", "--now", "elemental-upgrade-activate.timer"},
+				})).To(Succeed())
+				Expect(vfs.Exists(fs, timer)).To(BeFalse())
+				Expect(vfs.Exists(fs, service)).To(BeFalse())
+			})
+			It("removes the activation timer once the staged upgrade is discarded", func() {
+				Expect(u.Discard(d)).To(Succeed())
+				Expect(runner.IncludesCmds([][]string{
+					{"systemctl", "disable", "--now", "elemental-upgrade-activate.timer"},
+				})).To(Succeed())
+				Expect(vfs.Exists(fs, timer)).To(BeFalse())
+				Expect(vfs.Exists(fs, service)).To(BeFalse())
+			})
+		})
+		It("fails to schedule an activation with an invalid time", func() {
+			Expect(u.Stage(d)).To(Succeed())
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "systemd-analyze" {
+					return []byte("Failed to parse calendar specification"), fmt.Errorf("exit status 1")
+				}
+				return nil, nil
+			}
+			err := u.ScheduleActivation("someday", "/usr/bin/elemental3ctl")
+			Expect(err).To(MatchError(ContainSubstring("invalid activation time 'someday'")))
+			Expect(vfs.Exists(fs, "/etc/systemd/system/elemental-upgrade-activate.timer")).To(BeFalse())
+		})
+	})
+	Describe("reboot", func() {
+		newUpgrader := func(mode upgrade.RebootMode) *upgrade.Upgrader {
+			return upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReboot(mode),
+			)
+		}
+		BeforeEach(func() {
+			d.CfgScript = ""
+			d.OverlayTree = nil
+			Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib/modules/6.4.0", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/snapshot/path/usr/lib/modules/6.4.0/vmlinuz", []byte{}, vfs.FilePerm)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/proc/sys/kernel", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/proc/sys/kernel/osrelease", []byte("6.4.0\n"), vfs.FilePerm)).To(Succeed())
+		})
+		It("reboots with kexec into the new snapshot", func() {
+			u = newUpgrader(upgrade.KexecReboot)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{
+					"kexec", "--load", "/snapshot/path/usr/lib/modules/6.4.0/vmlinuz",
+					"--initrd", "/snapshot/path/usr/lib/modules/6.4.0/initrd", "--append",
+				},
+				{"systemctl", "kexec"},
+			})).To(Succeed())
+		})
+		It("soft reboots if the kernel did not change", func() {
+			u = newUpgrader(upgrade.SoftReboot)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"systemctl", "soft-reboot"}})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"kexec"}})).NotTo(Succeed())
+			Expect(mounter.IsMountPoint("/run/nextroot")).To(BeTrue())
+		})
+		It("unmounts the next root if the commit fails", func() {
+			t.CommitErr = fmt.Errorf("commit failed")
+			u = newUpgrader(upgrade.SoftReboot)
+			Expect(u.Upgrade(d)).To(MatchError("committing transaction: commit failed"))
+			Expect(mounter.IsMountPoint("/run/nextroot")).To(BeFalse())
+			Expect(runner.IncludesCmds([][]string{{"systemctl", "soft-reboot"}})).NotTo(Succeed())
+		})
+		It("falls back to kexec if the kernel changed", func() {
+			Expect(fs.WriteFile("/proc/sys/kernel/osrelease", []byte("6.3.0\n"), vfs.FilePerm)).To(Succeed())
+			u = newUpgrader(upgrade.SoftReboot)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"systemctl", "kexec"}})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"systemctl", "soft-reboot"}})).NotTo(Succeed())
+		})
+		It("unloads the kexec kernel if the commit fails", func() {
+			t.CommitErr = fmt.Errorf("commit failed")
+			u = newUpgrader(upgrade.KexecReboot)
+			Expect(u.Upgrade(d)).To(MatchError("committing transaction: commit failed"))
+			Expect(runner.IncludesCmds([][]string{{"kexec", "--unload"}})).To(Succeed())
+			Expect(runner.IncludesCmds([][]string{{"systemctl", "kexec"}})).NotTo(Succeed())
+		})
+		It("does not roll back the upgrade if the reboot fails", func() {
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "systemctl" {
+					return []byte{}, fmt.Errorf("reboot failed")
+				}
+				return []byte{}, nil
+			}
+			u = newUpgrader(upgrade.KexecReboot)
+			Expect(u.Upgrade(d)).To(MatchError("rebooting into the new snapshot: reboot failed"))
+			Expect(t.RollbackCalled()).To(BeFalse())
+		})
+	})
 })